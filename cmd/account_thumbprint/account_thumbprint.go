@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	letsencryptUtilsCli "github.com/altshiftab/letsencrypt_utils/internal/cli"
+	letsencryptUtilsKey "github.com/altshiftab/letsencrypt_utils/pkg/key"
+	"log/slog"
+	"os"
+)
+
+func main() {
+	var accountCredentialsPath string
+	flag.StringVar(
+		&accountCredentialsPath,
+		"credentials",
+		"account_credentials.json",
+		"The path of the account credentials file.",
+	)
+	credentialsConfig := letsencryptUtilsCli.AddCredentialsFlags(flag.CommandLine)
+
+	logConfig := letsencryptUtilsCli.AddLogFlags(flag.CommandLine)
+
+	err := letsencryptUtilsCli.ParseFlags(flag.CommandLine, letsencryptUtilsCli.ConfigFlagName, os.Args[1:])
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when resolving the flags.", err, slog.Default())
+	}
+
+	logger, err := logConfig.Logger()
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when configuring the logger.", err, slog.Default())
+	}
+
+	accountCredentials, err := credentialsConfig.Load(accountCredentialsPath)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when loading the account credentials.", err, logger)
+	}
+
+	key, err := accountCredentials.Signer()
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when parsing the account key.", err, logger)
+	}
+
+	thumbprint, err := letsencryptUtilsKey.Thumbprint(key)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when computing the thumbprint.", err, logger)
+	}
+
+	fmt.Println(thumbprint)
+}
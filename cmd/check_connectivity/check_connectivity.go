@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsCli "github.com/altshiftab/letsencrypt_utils/internal/cli"
+	letsencryptUtilsDirectory "github.com/altshiftab/letsencrypt_utils/pkg/directory"
+	letsencryptUtilsHttpclient "github.com/altshiftab/letsencrypt_utils/pkg/httpclient"
+	"log/slog"
+	"os"
+	"time"
+)
+
+type connectivityReport struct {
+	DirectoryUrl    string    `json:"directory_url"`
+	StatusCode      int       `json:"status_code,omitempty"`
+	RoundTripTimeMs int64     `json:"round_trip_time_ms"`
+	ServerTime      time.Time `json:"server_time,omitzero"`
+	ClockSkewMs     int64     `json:"clock_skew_ms"`
+	Error           string    `json:"error,omitempty"`
+}
+
+func printText(report *connectivityReport) {
+	fmt.Printf("directory_url: %s\n", report.DirectoryUrl)
+	if report.StatusCode != 0 {
+		fmt.Printf("status_code: %d\n", report.StatusCode)
+		fmt.Printf("round_trip_time: %s\n", time.Duration(report.RoundTripTimeMs)*time.Millisecond)
+	}
+	if !report.ServerTime.IsZero() {
+		fmt.Printf("server_time: %s\n", report.ServerTime.Format(time.RFC3339))
+		fmt.Printf("clock_skew: %s\n", time.Duration(report.ClockSkewMs)*time.Millisecond)
+	}
+	if report.Error != "" {
+		fmt.Printf("error: %s\n", report.Error)
+	}
+}
+
+func main() {
+	directoryConfig := letsencryptUtilsCli.AddDirectoryFlags(flag.CommandLine)
+	networkConfig := letsencryptUtilsCli.AddNetworkFlags(flag.CommandLine)
+
+	var maxClockSkew time.Duration
+	flag.DurationVar(
+		&maxClockSkew,
+		"max-clock-skew",
+		letsencryptUtilsDirectory.DefaultMaxClockSkew,
+		"The maximum acceptable difference between the local clock and that of the ACME server.",
+	)
+
+	var timeout time.Duration
+	flag.DurationVar(&timeout, "timeout", 30*time.Second, "The maximum duration of the check.")
+
+	var outputJson bool
+	flag.BoolVar(&outputJson, "json", false, "Whether to write the report as JSON rather than as text.")
+
+	logConfig := letsencryptUtilsCli.AddLogFlags(flag.CommandLine)
+
+	err := letsencryptUtilsCli.ParseFlags(flag.CommandLine, letsencryptUtilsCli.ConfigFlagName, os.Args[1:])
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when resolving the flags.", err, slog.Default())
+	}
+
+	logger, err := logConfig.Logger()
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when configuring the logger.", err, slog.Default())
+	}
+
+	httpClient, err := networkConfig.HttpClient(logger)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when configuring the network.", err, logger)
+	}
+
+	directoryUrl, err := directoryConfig.DirectoryUrl(logger)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when selecting the directory URL.", err, logger)
+	}
+
+	signalCtx, stopSignals := letsencryptUtilsCli.SignalContext(logger)
+	defer stopSignals()
+	ctx := letsencryptUtilsHttpclient.ContextWithClient(signalCtx, httpClient)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Clock skew breaks the validity of ACME requests, so it is checked along with the reachability.
+
+	health, err := letsencryptUtilsDirectory.Probe(ctx, httpClient, directoryUrl)
+	if err == nil {
+		err = health.CheckClockSkew(maxClockSkew)
+	}
+
+	report := &connectivityReport{DirectoryUrl: directoryUrl}
+	if health != nil {
+		report.StatusCode = health.StatusCode
+		report.RoundTripTimeMs = health.RoundTripTime.Milliseconds()
+		report.ServerTime = health.ServerTime
+		report.ClockSkewMs = health.ClockSkew.Milliseconds()
+	}
+	if err != nil {
+		report.Error = err.Error()
+	}
+
+	if outputJson {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			msg := "An error occurred when writing the report to stdout."
+			letsencryptUtilsCli.LogFatalWithExitingMessage(msg, &motmedelErrors.CauseError{Message: msg, Cause: err}, logger)
+		}
+	} else {
+		printText(report)
+	}
+
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("The connectivity check failed.", err, logger)
+	}
+}
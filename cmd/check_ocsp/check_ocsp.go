@@ -0,0 +1,95 @@
+package main
+
+import (
+	"crypto/x509"
+	"flag"
+	"fmt"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsCli "github.com/altshiftab/letsencrypt_utils/internal/cli"
+	letsencryptUtilsCertificate "github.com/altshiftab/letsencrypt_utils/pkg/certificate"
+	letsencryptUtilsOcsp "github.com/altshiftab/letsencrypt_utils/pkg/ocsp"
+	"golang.org/x/crypto/ocsp"
+	"log/slog"
+	"os"
+	"time"
+)
+
+func readCertificates(path string, logger *slog.Logger) []*x509.Certificate {
+	pemData, err := os.ReadFile(path)
+	if err != nil {
+		msg := "An error occurred when reading the certificate file."
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			msg,
+			&motmedelErrors.InputError{Message: msg, Cause: err, Input: path},
+			logger,
+		)
+	}
+
+	certificates, err := letsencryptUtilsCertificate.ParsePemChain(pemData)
+	if err != nil {
+		msg := "An error occurred when parsing the certificate file."
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			msg,
+			&motmedelErrors.InputError{Message: msg, Cause: err, Input: path},
+			logger,
+		)
+	}
+
+	return certificates
+}
+
+func main() {
+	var certificatePath string
+	flag.StringVar(
+		&certificatePath,
+		"certificate",
+		"certificate.pem",
+		"The path of the certificate PEM file. If it contains a chain, the second certificate is used as the issuer.",
+	)
+
+	var issuerPath string
+	flag.StringVar(&issuerPath, "issuer", "", "The path of the issuer certificate PEM file.")
+
+	logConfig := letsencryptUtilsCli.AddLogFlags(flag.CommandLine)
+
+	err := letsencryptUtilsCli.ParseFlags(flag.CommandLine, letsencryptUtilsCli.ConfigFlagName, os.Args[1:])
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when resolving the flags.", err, slog.Default())
+	}
+
+	logger, err := logConfig.Logger()
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when configuring the logger.", err, slog.Default())
+	}
+
+	certificates := readCertificates(certificatePath, logger)
+	leaf := certificates[0]
+
+	var issuer *x509.Certificate
+	if issuerPath != "" {
+		issuer = readCertificates(issuerPath, logger)[0]
+	} else if len(certificates) > 1 {
+		issuer = certificates[1]
+	} else {
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			"No issuer certificate is available; provide a chain file or the -issuer flag.",
+			nil,
+			logger,
+		)
+	}
+
+	ctx, stopSignals := letsencryptUtilsCli.SignalContext(logger)
+	defer stopSignals()
+
+	response, err := letsencryptUtilsOcsp.Check(ctx, nil, leaf, issuer)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when checking the OCSP status.", err, logger)
+	}
+
+	fmt.Printf("status: %s\n", letsencryptUtilsOcsp.StatusString(response.Status))
+
+	if response.Status == ocsp.Revoked {
+		fmt.Printf("revoked_at: %s\n", response.RevokedAt.UTC().Format(time.RFC3339))
+		os.Exit(letsencryptUtilsCli.ExitCodeRevoked)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsCli "github.com/altshiftab/letsencrypt_utils/internal/cli"
+	letsencryptUtilsAccount "github.com/altshiftab/letsencrypt_utils/pkg/account"
+	letsencryptUtilsHttpclient "github.com/altshiftab/letsencrypt_utils/pkg/httpclient"
+	"log/slog"
+	"os"
+)
+
+func main() {
+	var accountCredentialsPath string
+	flag.StringVar(
+		&accountCredentialsPath,
+		"credentials",
+		"account_credentials.json",
+		"The path of the account credentials file.",
+	)
+	credentialsConfig := letsencryptUtilsCli.AddCredentialsFlags(flag.CommandLine)
+
+	directoryConfig := letsencryptUtilsCli.AddDirectoryFlags(flag.CommandLine)
+	networkConfig := letsencryptUtilsCli.AddNetworkFlags(flag.CommandLine)
+
+	var confirm bool
+	flag.BoolVar(&confirm, "confirm", false, "Confirm that the account is to be permanently deactivated.")
+
+	var rename bool
+	flag.BoolVar(
+		&rename,
+		"rename",
+		false,
+		"Whether to rename the credentials file with a \".deactivated\" suffix after deactivation.",
+	)
+
+	logConfig := letsencryptUtilsCli.AddLogFlags(flag.CommandLine)
+
+	err := letsencryptUtilsCli.ParseFlags(flag.CommandLine, letsencryptUtilsCli.ConfigFlagName, os.Args[1:])
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when resolving the flags.", err, slog.Default())
+	}
+
+	logger, err := logConfig.Logger()
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when configuring the logger.", err, slog.Default())
+	}
+
+	httpClient, err := networkConfig.HttpClient(logger)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when configuring the network.", err, logger)
+	}
+	signalCtx, stopSignals := letsencryptUtilsCli.SignalContext(logger)
+	defer stopSignals()
+	ctx := letsencryptUtilsHttpclient.ContextWithClient(signalCtx, httpClient)
+
+	accountCredentials, err := credentialsConfig.Load(accountCredentialsPath)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when loading the account credentials.", err, logger)
+	}
+
+	directoryUrl, err := directoryConfig.AccountDirectoryUrl(accountCredentials, logger)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when selecting the directory URL.", err, logger)
+	}
+
+	logger.Info(
+		"The account is to be permanently deactivated.",
+		slog.String("uri", accountCredentials.Uri),
+		slog.String("directory_url", directoryUrl),
+	)
+
+	if !confirm {
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			"Deactivation is irreversible and must be confirmed with the -confirm flag.",
+			nil,
+			logger,
+		)
+	}
+
+	if err := letsencryptUtilsAccount.Deactivate(ctx, accountCredentials, directoryUrl); err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when deactivating the account.", err, logger)
+	}
+
+	logger.Info("The account was deactivated.", slog.String("uri", accountCredentials.Uri))
+
+	if rename {
+		deactivatedPath := accountCredentialsPath + ".deactivated"
+		if err := os.Rename(accountCredentialsPath, deactivatedPath); err != nil {
+			msg := "An error occurred when renaming the account credentials file."
+			letsencryptUtilsCli.LogFatalWithExitingMessage(
+				msg,
+				&motmedelErrors.InputError{
+					Message: msg,
+					Cause:   err,
+					Input:   []string{accountCredentialsPath, deactivatedPath},
+				},
+				logger,
+			)
+		}
+	}
+}
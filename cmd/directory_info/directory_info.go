@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsCli "github.com/altshiftab/letsencrypt_utils/internal/cli"
+	letsencryptUtilsDirectory "github.com/altshiftab/letsencrypt_utils/pkg/directory"
+	letsencryptUtilsHttpclient "github.com/altshiftab/letsencrypt_utils/pkg/httpclient"
+	letsencryptUtilsRetry "github.com/altshiftab/letsencrypt_utils/pkg/retry"
+	"golang.org/x/crypto/acme"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+type directoryInfo struct {
+	DirectoryUrl            string            `json:"directory_url"`
+	TermsOfService          string            `json:"terms_of_service,omitempty"`
+	Website                 string            `json:"website,omitempty"`
+	CaaIdentities           []string          `json:"caa_identities,omitempty"`
+	Profiles                map[string]string `json:"profiles,omitempty"`
+	ExternalAccountRequired bool              `json:"external_account_required"`
+	RenewalInfo             bool              `json:"renewal_info"`
+}
+
+func printText(info *directoryInfo, profileNames []string) {
+	fmt.Printf("directory_url: %s\n", info.DirectoryUrl)
+	if info.TermsOfService != "" {
+		fmt.Printf("terms_of_service: %s\n", info.TermsOfService)
+	}
+	if info.Website != "" {
+		fmt.Printf("website: %s\n", info.Website)
+	}
+	if len(info.CaaIdentities) != 0 {
+		fmt.Printf("caa_identities: %s\n", strings.Join(info.CaaIdentities, ", "))
+	}
+	for _, profileName := range profileNames {
+		fmt.Printf("profile: %s: %s\n", profileName, info.Profiles[profileName])
+	}
+	fmt.Printf("external_account_required: %t\n", info.ExternalAccountRequired)
+	fmt.Printf("renewal_info: %t\n", info.RenewalInfo)
+}
+
+func main() {
+	directoryConfig := letsencryptUtilsCli.AddDirectoryFlags(flag.CommandLine)
+	networkConfig := letsencryptUtilsCli.AddNetworkFlags(flag.CommandLine)
+
+	var outputJson bool
+	flag.BoolVar(&outputJson, "json", false, "Whether to write the directory metadata as JSON rather than as text.")
+
+	logConfig := letsencryptUtilsCli.AddLogFlags(flag.CommandLine)
+
+	err := letsencryptUtilsCli.ParseFlags(flag.CommandLine, letsencryptUtilsCli.ConfigFlagName, os.Args[1:])
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when resolving the flags.", err, slog.Default())
+	}
+
+	logger, err := logConfig.Logger()
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when configuring the logger.", err, slog.Default())
+	}
+
+	httpClient, err := networkConfig.HttpClient(logger)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when configuring the network.", err, logger)
+	}
+
+	directoryUrl, err := directoryConfig.DirectoryUrl(logger)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when selecting the directory URL.", err, logger)
+	}
+
+	signalCtx, stopSignals := letsencryptUtilsCli.SignalContext(logger)
+	defer stopSignals()
+	ctx := letsencryptUtilsHttpclient.ContextWithClient(signalCtx, httpClient)
+
+	client := &acme.Client{
+		DirectoryURL: directoryUrl,
+		HTTPClient:   httpClient,
+		RetryBackoff: letsencryptUtilsRetry.ClientBackoff,
+	}
+	acmeDirectory, err := client.Discover(ctx)
+	if err != nil {
+		msg := "An error occurred when discovering the directory."
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			msg,
+			&motmedelErrors.InputError{Message: msg, Cause: err, Input: directoryUrl},
+			logger,
+		)
+	}
+
+	// The acme package does not expose the profiles and the renewal information resource.
+	directory, err := letsencryptUtilsDirectory.Fetch(ctx, httpClient, directoryUrl)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when fetching the directory.", err, logger)
+	}
+
+	info := &directoryInfo{
+		DirectoryUrl:            directoryUrl,
+		TermsOfService:          acmeDirectory.Terms,
+		Website:                 acmeDirectory.Website,
+		CaaIdentities:           acmeDirectory.CAA,
+		Profiles:                directory.Meta.Profiles,
+		ExternalAccountRequired: acmeDirectory.ExternalAccountRequired,
+		RenewalInfo:             directory.RenewalInfo != "",
+	}
+
+	if !outputJson {
+		printText(info, directory.ProfileNames())
+		return
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(info); err != nil {
+		msg := "An error occurred when writing the directory metadata to stdout."
+		letsencryptUtilsCli.LogFatalWithExitingMessage(msg, &motmedelErrors.CauseError{Message: msg, Cause: err}, logger)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsCli "github.com/altshiftab/letsencrypt_utils/internal/cli"
+	letsencryptUtilsCertificate "github.com/altshiftab/letsencrypt_utils/pkg/certificate"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+type inspection struct {
+	FullChain    bool                                   `json:"full_chain"`
+	Certificates []*letsencryptUtilsCertificate.Summary `json:"certificates"`
+	Scts         *sctInspection                         `json:"signed_certificate_timestamps,omitempty"`
+}
+
+// sctInspection describes the Signed Certificate Timestamps embedded in the leaf certificate.
+type sctInspection struct {
+	Count      int                                                       `json:"count"`
+	Timestamps []*letsencryptUtilsCertificate.SignedCertificateTimestamp `json:"timestamps"`
+}
+
+func printText(inspection *inspection) {
+	for index, summary := range inspection.Certificates {
+		if index != 0 {
+			fmt.Println()
+		}
+		fmt.Printf("certificate: %d\n", index)
+		fmt.Printf("subject: %s\n", summary.Subject)
+		if len(summary.DnsNames) != 0 {
+			fmt.Printf("dns_names: %s\n", strings.Join(summary.DnsNames, ", "))
+		}
+		if len(summary.IpAddresses) != 0 {
+			fmt.Printf("ip_addresses: %s\n", strings.Join(summary.IpAddresses, ", "))
+		}
+		fmt.Printf("issuer: %s\n", summary.Issuer)
+		fmt.Printf("serial_number: %s\n", summary.SerialNumber)
+		fmt.Printf("not_before: %s\n", summary.NotBefore.Format(time.RFC3339))
+		fmt.Printf("not_after: %s\n", summary.NotAfter.Format(time.RFC3339))
+		fmt.Printf("days_remaining: %d\n", summary.DaysRemaining)
+		fmt.Printf("key_algorithm: %s\n", summary.KeyAlgorithm)
+		fmt.Printf("is_ca: %t\n", summary.IsCa)
+	}
+	fmt.Printf("\nfull_chain: %t\n", inspection.FullChain)
+
+	if inspection.Scts == nil {
+		return
+	}
+	if inspection.Scts.Count == 0 {
+		fmt.Println("\nsct_count: 0 (none are embedded; they may be delivered via OCSP or TLS instead)")
+		return
+	}
+	fmt.Printf("\nsct_count: %d\n", inspection.Scts.Count)
+	for index, signedCertificateTimestamp := range inspection.Scts.Timestamps {
+		fmt.Printf("\nsct: %d\n", index)
+		fmt.Printf("log_id: %s\n", signedCertificateTimestamp.LogId)
+		fmt.Printf("timestamp: %s\n", signedCertificateTimestamp.Timestamp.Format(time.RFC3339))
+	}
+}
+
+func main() {
+	var certificatePath string
+	flag.StringVar(
+		&certificatePath,
+		"certificate",
+		"certificate.pem",
+		"The path of the certificate PEM file, which may contain a chain.",
+	)
+
+	var outputJson bool
+	flag.BoolVar(&outputJson, "json", false, "Whether to write the summary as JSON rather than as text.")
+
+	var showScts bool
+	flag.BoolVar(
+		&showScts,
+		"sct",
+		false,
+		"Whether to decode the Signed Certificate Timestamps embedded in the leaf certificate, confirming that it "+
+			"was logged to Certificate Transparency.",
+	)
+
+	logConfig := letsencryptUtilsCli.AddLogFlags(flag.CommandLine)
+
+	err := letsencryptUtilsCli.ParseFlags(flag.CommandLine, letsencryptUtilsCli.ConfigFlagName, os.Args[1:])
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when resolving the flags.", err, slog.Default())
+	}
+
+	logger, err := logConfig.Logger()
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when configuring the logger.", err, slog.Default())
+	}
+
+	pemData, err := os.ReadFile(certificatePath)
+	if err != nil {
+		msg := "An error occurred when reading the certificate file."
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			msg,
+			&motmedelErrors.InputError{Message: msg, Cause: err, Input: certificatePath},
+			logger,
+		)
+	}
+
+	certificates, err := letsencryptUtilsCertificate.ParsePemChain(pemData)
+	if err != nil {
+		msg := "An error occurred when parsing the certificate file."
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			msg,
+			&motmedelErrors.InputError{Message: msg, Cause: err, Input: certificatePath},
+			logger,
+		)
+	}
+
+	now := time.Now()
+	result := &inspection{FullChain: letsencryptUtilsCertificate.IsFullChain(certificates)}
+	for _, certificate := range certificates {
+		result.Certificates = append(result.Certificates, letsencryptUtilsCertificate.Summarize(certificate, now))
+	}
+
+	if showScts {
+		signedCertificateTimestamps, err := letsencryptUtilsCertificate.SignedCertificateTimestamps(certificates[0])
+		if err != nil {
+			msg := "An error occurred when decoding the Signed Certificate Timestamps."
+			letsencryptUtilsCli.LogFatalWithExitingMessage(
+				msg,
+				&motmedelErrors.InputError{Message: msg, Cause: err, Input: certificatePath},
+				logger,
+			)
+		}
+		result.Scts = &sctInspection{
+			Count:      len(signedCertificateTimestamps),
+			Timestamps: append([]*letsencryptUtilsCertificate.SignedCertificateTimestamp{}, signedCertificateTimestamps...),
+		}
+	}
+
+	if !outputJson {
+		printText(result)
+		return
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		msg := "An error occurred when writing the summary to stdout."
+		letsencryptUtilsCli.LogFatalWithExitingMessage(msg, &motmedelErrors.CauseError{Message: msg, Cause: err}, logger)
+	}
+}
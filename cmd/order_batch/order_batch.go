@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	motmedelLog "github.com/Motmedel/utils_go/pkg/log"
+	letsencryptUtilsCli "github.com/altshiftab/letsencrypt_utils/internal/cli"
+	letsencryptUtilsHttpclient "github.com/altshiftab/letsencrypt_utils/pkg/httpclient"
+	letsencryptUtilsSolver "github.com/altshiftab/letsencrypt_utils/pkg/solver"
+	"golang.org/x/crypto/acme"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// certificateRequest is an entry of the batch file, describing a certificate and where it is to be written.
+type certificateRequest struct {
+	Domains      []string `json:"domains"`
+	CertOut      string   `json:"cert_out,omitempty"`
+	ChainOut     string   `json:"chain_out,omitempty"`
+	FullchainOut string   `json:"fullchain_out,omitempty"`
+	KeyOut       string   `json:"key_out"`
+}
+
+func order(
+	ctx context.Context,
+	client *acme.Client,
+	orderConfig *letsencryptUtilsCli.OrderConfig,
+	solvers map[string]letsencryptUtilsSolver.Solver,
+	request *certificateRequest,
+) error {
+	if len(request.Domains) == 0 {
+		return &motmedelErrors.CauseError{Message: "No domains were provided."}
+	}
+	if request.KeyOut == "" {
+		return &motmedelErrors.InputError{Message: "No key output path was provided.", Input: request.Domains}
+	}
+	if request.CertOut == "" && request.ChainOut == "" && request.FullchainOut == "" {
+		return &motmedelErrors.InputError{Message: "No certificate output path was provided.", Input: request.Domains}
+	}
+
+	certificate, err := orderConfig.ObtainWithSolvers(ctx, client, request.Domains, request.KeyOut, solvers)
+	if err != nil {
+		return err
+	}
+
+	outputConfig := &letsencryptUtilsCli.CertificateOutputConfig{
+		CertOutPath:      request.CertOut,
+		ChainOutPath:     request.ChainOut,
+		FullchainOutPath: request.FullchainOut,
+		KeyOutPath:       request.KeyOut,
+	}
+	return outputConfig.Write(certificate, "")
+}
+
+func main() {
+	var accountCredentialsPath string
+	flag.StringVar(
+		&accountCredentialsPath,
+		"credentials",
+		"account_credentials.json",
+		"The path of the account credentials file.",
+	)
+	credentialsConfig := letsencryptUtilsCli.AddCredentialsFlags(flag.CommandLine)
+
+	var batchPath string
+	flag.StringVar(
+		&batchPath,
+		"batch",
+		"batch.json",
+		"The path of a JSON file containing a list of certificate requests, each with domains and output paths.",
+	)
+
+	var concurrency int
+	flag.IntVar(&concurrency, "concurrency", 4, "The maximum number of certificates to be ordered concurrently.")
+
+	var timeout time.Duration
+	flag.DurationVar(&timeout, "timeout", time.Hour, "The maximum duration of the whole batch.")
+
+	var orderTimeout time.Duration
+	flag.DurationVar(&orderTimeout, "order-timeout", 10*time.Minute, "The maximum duration of each order.")
+
+	directoryConfig := letsencryptUtilsCli.AddDirectoryFlags(flag.CommandLine)
+	networkConfig := letsencryptUtilsCli.AddNetworkFlags(flag.CommandLine)
+	orderConfig := letsencryptUtilsCli.AddOrderFlags(flag.CommandLine)
+	logConfig := letsencryptUtilsCli.AddLogFlags(flag.CommandLine)
+
+	err := letsencryptUtilsCli.ParseFlags(flag.CommandLine, letsencryptUtilsCli.ConfigFlagName, os.Args[1:])
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when resolving the flags.", err, slog.Default())
+	}
+
+	logger, err := logConfig.Logger()
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when configuring the logger.", err, slog.Default())
+	}
+
+	httpClient, err := networkConfig.HttpClient(logger)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when configuring the network.", err, logger)
+	}
+
+	if orderConfig.StatePath != "" {
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			"The -state-file flag is unsupported for batches, as the orders would share the file.",
+			nil,
+			logger,
+		)
+	}
+
+	if concurrency < 1 {
+		msg := "The concurrency must be at least 1."
+		letsencryptUtilsCli.LogFatalWithExitingMessage(msg, &motmedelErrors.InputError{Message: msg, Input: concurrency}, logger)
+	}
+
+	batchData, err := os.ReadFile(batchPath)
+	if err != nil {
+		msg := "An error occurred when reading the batch file."
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			msg,
+			&motmedelErrors.InputError{Message: msg, Cause: err, Input: batchPath},
+			logger,
+		)
+	}
+
+	var requests []*certificateRequest
+	if err := json.Unmarshal(batchData, &requests); err != nil {
+		msg := "An error occurred when parsing the batch file."
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			msg,
+			&motmedelErrors.InputError{Message: msg, Cause: err, Input: batchPath},
+			logger,
+		)
+	}
+
+	accountCredentials, err := credentialsConfig.Load(accountCredentialsPath)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when loading the account credentials.", err, logger)
+	}
+
+	directoryUrl, err := directoryConfig.AccountDirectoryUrl(accountCredentials, logger)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when selecting the directory URL.", err, logger)
+	}
+
+	client, err := accountCredentials.Client(directoryUrl)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when creating the ACME client.", err, logger)
+	}
+	client.HTTPClient = httpClient
+
+	signalCtx, stopSignals := letsencryptUtilsCli.SignalContext(logger)
+	defer stopSignals()
+	ctx := letsencryptUtilsHttpclient.ContextWithClient(signalCtx, httpClient)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if orderConfig.Profile != "" {
+		if err := letsencryptUtilsCli.ValidateProfile(ctx, client, orderConfig.Profile); err != nil {
+			letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when validating the profile.", err, logger)
+		}
+	}
+
+	// A single solver per challenge type is shared by the workers, so that e.g. only one HTTP-01 server is bound.
+
+	solvers, err := orderConfig.Solvers(client)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when creating the solvers.", err, logger)
+	}
+
+	// Order the certificates with a bounded pool of workers. A failure does not abort the other orders.
+
+	requestChannel := make(chan int)
+	errs := make([]error, len(requests))
+
+	var waitGroup sync.WaitGroup
+	for range min(concurrency, len(requests)) {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for index := range requestChannel {
+				orderCtx, orderCancel := context.WithTimeout(ctx, orderTimeout)
+				errs[index] = order(orderCtx, client, orderConfig, solvers, requests[index])
+				orderCancel()
+			}
+		}()
+	}
+
+	for index := range requests {
+		requestChannel <- index
+	}
+	close(requestChannel)
+	waitGroup.Wait()
+
+	var numFailed int
+	for index, request := range requests {
+		if err := errs[index]; err != nil {
+			numFailed++
+			motmedelLog.LogError(
+				"An error occurred when ordering a certificate.",
+				&motmedelErrors.InputError{
+					Message: "An error occurred when ordering a certificate.",
+					Cause:   err,
+					Input:   request.Domains,
+				},
+				logger,
+			)
+			continue
+		}
+		logger.Info("The certificate was ordered.", slog.Any("domains", request.Domains))
+	}
+
+	logger.Info(
+		"The batch has completed.",
+		slog.Int("total", len(requests)),
+		slog.Int("succeeded", len(requests)-numFailed),
+		slog.Int("failed", numFailed),
+	)
+
+	if numFailed != 0 {
+		cancel()
+		// Once interrupted, the remaining orders fail as cancelled, which is reported as the interruption.
+		if signalCtx.Err() != nil {
+			os.Exit(letsencryptUtilsCli.ExitCodeInterrupted)
+		}
+		os.Exit(letsencryptUtilsCli.ExitCodeFailure)
+	}
+}
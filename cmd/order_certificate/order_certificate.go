@@ -0,0 +1,210 @@
+package main
+
+import (
+	"crypto/x509"
+	"flag"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsCli "github.com/altshiftab/letsencrypt_utils/internal/cli"
+	letsencryptUtilsHttpclient "github.com/altshiftab/letsencrypt_utils/pkg/httpclient"
+	letsencryptUtilsOrder "github.com/altshiftab/letsencrypt_utils/pkg/order"
+	"log/slog"
+	"os"
+	"slices"
+)
+
+func main() {
+	var accountCredentialsPath string
+	flag.StringVar(
+		&accountCredentialsPath,
+		"credentials",
+		"account_credentials.json",
+		"The path of the account credentials file.",
+	)
+	credentialsConfig := letsencryptUtilsCli.AddCredentialsFlags(flag.CommandLine)
+
+	var domains letsencryptUtilsCli.StringSliceFlag
+	flag.Var(
+		&domains,
+		"domain",
+		"A domain or IP address to be included in the certificate. May be specified multiple times. "+
+			"Internationalized domains are converted to their ASCII-compatible encoding.",
+	)
+
+	var csrPath string
+	flag.StringVar(
+		&csrPath,
+		"csr",
+		"",
+		"The path of a pre-generated PKCS#10 CSR (PEM or DER) to be used instead of generating a key and building a "+
+			"CSR. The domains are taken from the CSR, and no private key file is written.",
+	)
+
+	var certificateOutPath string
+	flag.StringVar(
+		&certificateOutPath,
+		"output",
+		"certificate.pem",
+		"The path where the certificate chain file is to be written, if none of -cert-out, -chain-out, "+
+			"-fullchain-out, and -output-dir are set.",
+	)
+
+	outputConfig := letsencryptUtilsCli.AddCertificateOutputFlags(flag.CommandLine)
+
+	var outputDir string
+	flag.StringVar(
+		&outputDir,
+		"output-dir",
+		"",
+		"The path of a directory in which the certificate and its private key are to be written to a directory "+
+			"named after the first domain, as cert.pem, chain.pem, fullchain.pem, and privkey.pem, instead of to the "+
+			"other output paths. A wildcard domain *.example.com is named wildcard.example.com.",
+	)
+
+	directoryConfig := letsencryptUtilsCli.AddDirectoryFlags(flag.CommandLine)
+	networkConfig := letsencryptUtilsCli.AddNetworkFlags(flag.CommandLine)
+	orderConfig := letsencryptUtilsCli.AddOrderFlags(flag.CommandLine)
+	pkcs12Config := letsencryptUtilsCli.AddPkcs12Flags(flag.CommandLine)
+	hookConfig := letsencryptUtilsCli.AddHookFlags(flag.CommandLine)
+	logConfig := letsencryptUtilsCli.AddLogFlags(flag.CommandLine)
+
+	flag.StringVar(
+		&orderConfig.OrderUrl,
+		"order-url",
+		"",
+		"The URL of an existing order to be continued rather than a new order being created, e.g. one logged by an "+
+			"earlier run that was interrupted. The order must be for the domains. An order that was already finalized "+
+			"can only be completed with -csr and the CSR it was finalized with.",
+	)
+
+	err := letsencryptUtilsCli.ParseFlags(flag.CommandLine, letsencryptUtilsCli.ConfigFlagName, os.Args[1:])
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when resolving the flags.", err, slog.Default())
+	}
+
+	logger, err := logConfig.Logger()
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when configuring the logger.", err, slog.Default())
+	}
+
+	httpClient, err := networkConfig.HttpClient(logger)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when configuring the network.", err, logger)
+	}
+	signalCtx, stopSignals := letsencryptUtilsCli.SignalContext(logger)
+	defer stopSignals()
+	ctx := letsencryptUtilsHttpclient.ContextWithClient(signalCtx, httpClient)
+
+	if err := outputConfig.Validate(); err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("The output configuration is invalid.", err, logger)
+	}
+
+	if err := pkcs12Config.Validate(); err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("The PKCS#12 configuration is invalid.", err, logger)
+	}
+
+	var csr *x509.CertificateRequest
+	if csrPath != "" {
+		if pkcs12Config.OutPath != "" {
+			letsencryptUtilsCli.LogFatalWithExitingMessage(
+				"The -pkcs12-out flag cannot be used with -csr, as the private key is not known.",
+				nil,
+				logger,
+			)
+		}
+
+		csrData, err := os.ReadFile(csrPath)
+		if err != nil {
+			letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when reading the CSR.", err, logger)
+		}
+		csr, err = letsencryptUtilsOrder.ParseCSR(csrData)
+		if err != nil {
+			letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when parsing the CSR.", err, logger)
+		}
+
+		// Domains provided along with the CSR must be the ones it contains.
+		csrDomains := letsencryptUtilsOrder.CSRDomains(csr)
+		if len(domains) != 0 {
+			asciiDomains, err := letsencryptUtilsOrder.ToAsciiDomains(domains)
+			if err != nil {
+				letsencryptUtilsCli.LogFatalWithExitingMessage("The domains are invalid.", err, logger)
+			}
+			if !slices.Equal(slices.Sorted(slices.Values(asciiDomains)), slices.Sorted(slices.Values(csrDomains))) {
+				letsencryptUtilsCli.LogFatalWithExitingMessage(
+					"The domains do not match those of the CSR.",
+					&motmedelErrors.InputError{Message: "The domain lists differ.", Input: []any{domains, csrDomains}},
+					logger,
+				)
+			}
+		}
+		domains = csrDomains
+	}
+
+	if len(domains) == 0 {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("No domains were provided.", nil, logger)
+	}
+
+	if outputDir != "" {
+		outputConfig, err = outputConfig.InDirectory(outputDir, domains)
+		if err != nil {
+			letsencryptUtilsCli.LogFatalWithExitingMessage(
+				"An error occurred when preparing the output directory.",
+				err,
+				logger,
+			)
+		}
+	}
+
+	// Reconstruct the ACME client from the account credentials.
+
+	accountCredentials, err := credentialsConfig.Load(accountCredentialsPath)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when loading the account credentials.", err, logger)
+	}
+
+	directoryUrl, err := directoryConfig.AccountDirectoryUrl(accountCredentials, logger)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when selecting the directory URL.", err, logger)
+	}
+
+	client, err := accountCredentials.Client(directoryUrl)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when creating the ACME client.", err, logger)
+	}
+	client.HTTPClient = httpClient
+
+	// Order the certificate.
+
+	hookEnvironment := &letsencryptUtilsCli.HookEnvironment{
+		Domains:  domains,
+		CertPath: outputConfig.CertificatePath(certificateOutPath),
+	}
+	if csr == nil {
+		hookEnvironment.KeyPath = outputConfig.KeyOutPath
+	}
+
+	if err := hookConfig.RunPreHook(ctx, hookEnvironment); err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when running the pre-hook.", err, logger)
+	}
+
+	var certificate *letsencryptUtilsCli.Certificate
+	if csr != nil {
+		certificate, err = orderConfig.ObtainForCsr(ctx, client, csr)
+	} else {
+		certificate, err = orderConfig.Obtain(ctx, client, domains, outputConfig.KeyOutPath)
+	}
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when obtaining the certificate.", err, logger)
+	}
+
+	if err := outputConfig.Write(certificate, certificateOutPath); err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when writing the certificate.", err, logger)
+	}
+
+	if err := pkcs12Config.Write(certificate); err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when writing the PKCS#12 file.", err, logger)
+	}
+
+	if err := hookConfig.RunPostHook(ctx, hookEnvironment); err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when running the post-hook.", err, logger)
+	}
+}
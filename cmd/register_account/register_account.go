@@ -2,27 +2,51 @@ package main
 
 import (
 	"context"
-	"crypto/ecdsa"
-	"crypto/elliptic"
-	"crypto/rand"
-	"crypto/x509"
+	"crypto"
+	"encoding/base64"
 	"encoding/json"
-	"encoding/pem"
+	"errors"
 	"flag"
+	"fmt"
 	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
-	motmedelLog "github.com/Motmedel/utils_go/pkg/log"
+	letsencryptUtilsCli "github.com/altshiftab/letsencrypt_utils/internal/cli"
+	letsencryptUtilsFileutil "github.com/altshiftab/letsencrypt_utils/internal/fileutil"
+	letsencryptUtilsAccount "github.com/altshiftab/letsencrypt_utils/pkg/account"
+	letsencryptUtilsDirectory "github.com/altshiftab/letsencrypt_utils/pkg/directory"
+	letsencryptUtilsHttpclient "github.com/altshiftab/letsencrypt_utils/pkg/httpclient"
+	letsencryptUtilsKey "github.com/altshiftab/letsencrypt_utils/pkg/key"
+	letsencryptUtilsRedact "github.com/altshiftab/letsencrypt_utils/pkg/redact"
+	letsencryptUtilsRetry "github.com/altshiftab/letsencrypt_utils/pkg/retry"
 	letsencryptUtilsTypes "github.com/altshiftab/letsencrypt_utils/pkg/types"
-	"golang.org/x/crypto/acme"
+	"io/fs"
 	"log/slog"
-	"net/mail"
 	"os"
+	"strings"
+	"time"
 )
 
-func main() {
-	logger := slog.Default()
+// emailEnvironmentVariable is an environment variable from which the email address is resolved if the flag is not
+// set, as with the LEU_EMAIL variable.
+const emailEnvironmentVariable = "ACME_EMAIL"
+
+const (
+	storeFile    = "file"
+	storeKeyring = "keyring"
+)
 
-	var emailAddress string
-	flag.StringVar(&emailAddress, "email", "", "The email address to be used for contact.")
+// isTimeout reports whether the error is the result of the context's deadline being exceeded.
+func isTimeout(ctx context.Context, err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded)
+}
+
+func main() {
+	var emailAddresses letsencryptUtilsCli.StringSliceFlag
+	flag.Var(
+		&emailAddresses,
+		"email",
+		"An email address to be used for contact. May be specified multiple times. If not set, it is taken from "+
+			"the "+emailEnvironmentVariable+" environment variable, which takes precedence over the -config file.",
+	)
 
 	var accountCredentialsOutPath string
 	flag.StringVar(&accountCredentialsOutPath,
@@ -31,93 +55,503 @@ func main() {
 		"The path where the account credentials file is to be written.",
 	)
 
-	var useStaging bool
-	flag.BoolVar(&useStaging, "staging", false, "Whether to use the staging environment.")
+	var lowercaseEmail bool
+	flag.BoolVar(
+		&lowercaseEmail,
+		"lowercase-email",
+		false,
+		"Whether to lowercase the email addresses in whole before sending them to the CA, rather than only their domains.",
+	)
 
-	flag.Parse()
+	directoryConfig := letsencryptUtilsCli.AddDirectoryFlags(flag.CommandLine)
+	networkConfig := letsencryptUtilsCli.AddNetworkFlags(flag.CommandLine)
 
-	if emailAddress == "" {
-		motmedelLog.LogFatalWithExitingMessage("The email address is empty.", nil, logger)
-	}
+	var keyType string
+	flag.StringVar(
+		&keyType,
+		"key-type",
+		string(letsencryptUtilsKey.TypeEcdsa),
+		"The account key type (ecdsa or rsa).",
+	)
 
-	// Best-effort email address validation.
-	if _, err := mail.ParseAddress(emailAddress); err != nil {
-		msg := "The email address is invalid."
-		motmedelLog.LogFatalWithExitingMessage(
-			msg,
-			&motmedelErrors.InputError{Message: msg, Cause: err, Input: emailAddress},
-			logger,
-		)
-	}
+	var curve string
+	flag.StringVar(
+		&curve,
+		"curve",
+		string(letsencryptUtilsKey.CurveP256),
+		"The ECDSA curve (p256, p384, or p521), used with the ecdsa key type.",
+	)
+
+	var rsaBits int
+	flag.IntVar(
+		&rsaBits,
+		"rsa-bits",
+		letsencryptUtilsKey.DefaultRsaBits,
+		"The RSA key size, used with the rsa key type.",
+	)
+
+	var keyPath string
+	flag.StringVar(
+		&keyPath,
+		"key",
+		"",
+		"The path of an existing account key PEM file to register with, or to look up with -only-existing. "+
+			"Takes precedence over the key type flags.",
+	)
+
+	var onlyExisting bool
+	flag.BoolVar(
+		&onlyExisting,
+		"only-existing",
+		false,
+		"Whether to only look up the existing account of the key provided with -key, rather than registering one.",
+	)
+
+	var eabKeyId string
+	flag.StringVar(&eabKeyId, "eab-kid", "", "The key identifier of an external account binding.")
+
+	var eabHmacKey string
+	flag.StringVar(
+		&eabHmacKey,
+		"eab-hmac-key",
+		"",
+		"The base64url-encoded HMAC key of an external account binding.",
+	)
 
-	// Produce an account key.
+	passphraseConfig := letsencryptUtilsCli.AddPassphraseFlags(flag.CommandLine)
+	letsencryptUtilsCli.AddLockFlags(flag.CommandLine)
+	backupCount := letsencryptUtilsCli.AddBackupFlags(flag.CommandLine)
 
-	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	var store string
+	flag.StringVar(
+		&store,
+		"store",
+		storeFile,
+		"Where the account key is to be stored (file, or keyring for the OS keyring, with the metadata in the file).",
+	)
+
+	var force bool
+	flag.BoolVar(
+		&force,
+		"force",
+		false,
+		"Whether to overwrite an existing account credentials file, which is first backed up per -backup-count, "+
+			"and to register a production account with an email address that looks like a placeholder.",
+	)
+
+	var timeout time.Duration
+	flag.DurationVar(&timeout, "timeout", 60*time.Second, "The maximum duration of the ACME operations.")
+
+	var retries int
+	flag.IntVar(
+		&retries,
+		"retries",
+		letsencryptUtilsRetry.DefaultMaxAttempts,
+		"The maximum number of registration attempts, retrying on transient failures.",
+	)
+
+	var acceptTos bool
+	flag.BoolVar(
+		&acceptTos,
+		"accept-tos",
+		false,
+		"Whether to agree to the terms of service of the CA, which is required for registration.",
+	)
+
+	var outputJson bool
+	flag.BoolVar(
+		&outputJson,
+		"output-json",
+		false,
+		"Whether to write the account URI and email address as a JSON object to stdout after registration.",
+	)
+
+	var printThumbprint bool
+	flag.BoolVar(
+		&printThumbprint,
+		"print-thumbprint",
+		false,
+		"Whether to write the JWK SHA-256 thumbprint of the account key to stdout, or to include it in the "+
+			"-output-json object.",
+	)
+
+	var dryRun bool
+	flag.BoolVar(
+		&dryRun,
+		"dry-run",
+		false,
+		"Whether to only perform the local steps and log what would be sent, without registering or writing files.",
+	)
+
+	logConfig := letsencryptUtilsCli.AddLogFlags(flag.CommandLine)
+
+	// The email address is resolved from the environment variable along with the LEU_ variables, so that it takes
+	// precedence over the configuration file.
+	letsencryptUtilsCli.AddFlagEnvironmentAlias(flag.CommandLine, "email", emailEnvironmentVariable)
+
+	err := letsencryptUtilsCli.ParseFlags(flag.CommandLine, letsencryptUtilsCli.ConfigFlagName, os.Args[1:])
 	if err != nil {
-		msg := "An error occurred when generating an account key."
-		motmedelLog.LogFatalWithExitingMessage(msg, &motmedelErrors.CauseError{Message: msg, Cause: err}, logger)
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when resolving the flags.", err, slog.Default())
 	}
 
-	keyDerData, err := x509.MarshalECPrivateKey(key)
+	logger, err := logConfig.Logger()
 	if err != nil {
-		msg := "An error occurred when marshalling the account key data."
-		motmedelLog.LogFatalWithExitingMessage(msg, &motmedelErrors.CauseError{Message: msg, Cause: err}, logger)
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when configuring the logger.", err, slog.Default())
 	}
 
-	keyPemData := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDerData})
+	httpClient, err := networkConfig.HttpClient(logger)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when configuring the network.", err, logger)
+	}
 
-	// Register an account with Let's Encrypt.
+	// The directory flags are resolved up front, so that an invalid directory URL is reported before anything else.
+	directoryUrl, err := directoryConfig.DirectoryUrl(logger)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when selecting the directory URL.", err, logger)
+	}
 
-	directoryUrl := acme.LetsEncryptURL
-	if useStaging {
-		directoryUrl = "https://acme-staging-v02.api.letsencrypt.org/directory"
+	// The key type flags are ignored if a key is provided.
+	if keyPath == "" {
+		keySpec := &letsencryptUtilsKey.Spec{
+			Type:    letsencryptUtilsKey.Type(keyType),
+			Curve:   letsencryptUtilsKey.Curve(curve),
+			RsaBits: rsaBits,
+		}
+		err := letsencryptUtilsKey.ValidateSpec(keySpec)
+		if err == nil {
+			err = letsencryptUtilsAccount.CheckKeyType(keySpec.Type)
+		}
+		if err != nil {
+			msg := "The account key type flags are invalid."
+			letsencryptUtilsCli.LogFatalWithExitingMessage(
+				msg,
+				&motmedelErrors.CauseError{Message: msg, Cause: errors.Join(letsencryptUtilsCli.ErrInvalidInput, err)},
+				logger,
+			)
+		}
 	}
 
-	contactAddress := "mailto:" + emailAddress
-	client := &acme.Client{Key: key, DirectoryURL: directoryUrl}
-	account, err := client.Register(
-		context.Background(),
-		&acme.Account{Contact: []string{contactAddress}},
-		acme.AcceptTOS,
-	)
-	if err != nil {
-		msg := "An error occurred when registering the account."
-		motmedelLog.LogFatalWithExitingMessage(
+	// Check the output path before anything irreversible is done, so that an in-use account key is not lost.
+
+	_, err = os.Stat(accountCredentialsOutPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		msg := "An error occurred when checking the existing account credentials file."
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			msg,
+			&motmedelErrors.InputError{Message: msg, Cause: err, Input: accountCredentialsOutPath},
+			logger,
+		)
+	}
+	outputExists := err == nil
+	if outputExists && !force {
+		msg := "The account credentials file already exists; pass -force to overwrite it."
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
 			msg,
 			&motmedelErrors.InputError{
 				Message: msg,
-				Cause:   err,
-				Input:   []any{contactAddress, directoryUrl},
+				Cause:   letsencryptUtilsAccount.ErrCredentialsExist,
+				Input:   accountCredentialsOutPath,
 			},
 			logger,
 		)
 	}
-	if account == nil {
-		motmedelLog.LogFatalWithExitingMessage("The account is nil.", nil, logger)
+
+	passphrase, err := passphraseConfig.Passphrase()
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when reading the passphrase.", err, logger)
 	}
 
-	accountUri := account.URI
-	if accountUri == "" {
-		motmedelLog.LogFatalWithExitingMessage("The account URI is empty.", nil, logger)
+	switch store {
+	case storeFile:
+	case storeKeyring:
+		if len(passphrase) != 0 {
+			letsencryptUtilsCli.LogFatalWithExitingMessage(
+				"A passphrase cannot be combined with the keyring store.",
+				nil,
+				logger,
+			)
+		}
+	default:
+		msg := "The store is unsupported."
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			msg,
+			&motmedelErrors.InputError{Message: msg, Cause: letsencryptUtilsCli.ErrInvalidInput, Input: store},
+			logger,
+		)
 	}
 
-	accountCredentials := letsencryptUtilsTypes.AccountCredentials{
-		Uri: accountUri,
-		Key: string(keyPemData),
+	if dryRun {
+		if onlyExisting {
+			letsencryptUtilsCli.LogFatalWithExitingMessage(
+				"The -dry-run flag cannot be combined with the -only-existing flag.",
+				nil,
+				logger,
+			)
+		}
+		if err := letsencryptUtilsFileutil.CheckWritable(accountCredentialsOutPath); err != nil {
+			letsencryptUtilsCli.LogFatalWithExitingMessage("The account credentials file cannot be written.", err, logger)
+		}
 	}
 
-	accountCredentialsData, err := json.Marshal(accountCredentials)
-	if err != nil {
-		msg := "An error occurred when marshalling the account credentials."
-		motmedelLog.LogFatalWithExitingMessage(msg, &motmedelErrors.CauseError{Message: msg, Cause: err}, logger)
+	// A production account registered with a placeholder address, e.g. one copied from an example, cannot be reached
+	// by the CA; staging accounts are exempt, as they are commonly registered with such addresses.
+	if letsencryptUtilsDirectory.Environment(directoryUrl) == letsencryptUtilsDirectory.EnvironmentProduction {
+		for _, emailAddress := range emailAddresses {
+			reason := letsencryptUtilsAccount.PlaceholderEmailReason(emailAddress)
+			if reason == "" {
+				continue
+			}
+			if !force {
+				msg := "The email address looks like a placeholder, and a production account is to be registered; " +
+					"pass -force to proceed. " + reason
+				letsencryptUtilsCli.LogFatalWithExitingMessage(
+					msg,
+					&motmedelErrors.InputError{
+						Message: msg,
+						Cause:   letsencryptUtilsAccount.ErrInvalidEmail,
+						Input:   letsencryptUtilsRedact.Email(emailAddress),
+					},
+					logger,
+				)
+			}
+			logger.Warn(
+				"The email address looks like a placeholder, but -force is set.",
+				slog.String("email", letsencryptUtilsRedact.Email(emailAddress)),
+				slog.String("reason", reason),
+			)
+		}
+	}
+
+	if (eabKeyId == "") != (eabHmacKey == "") {
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			"The -eab-kid and -eab-hmac-key flags must be provided together.",
+			nil,
+			logger,
+		)
+	}
+
+	if preset := letsencryptUtilsDirectory.PresetOf(directoryUrl); preset != nil && preset.RequiresEab && eabKeyId == "" {
+		logger.Warn(
+			"The CA requires an external account binding; supply the -eab-kid and -eab-hmac-key flags with the "+
+				"credentials from its dashboard.",
+			slog.String("ca", preset.Name),
+		)
 	}
 
-	if err := os.WriteFile(accountCredentialsOutPath, accountCredentialsData, 0600); err != nil {
+	var eabHmacKeyData []byte
+	if eabHmacKey != "" {
+		var err error
+		eabHmacKeyData, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(eabHmacKey, "="))
+		if err != nil {
+			msg := "An error occurred when decoding the external account binding HMAC key."
+			letsencryptUtilsCli.LogFatalWithExitingMessage(msg, &motmedelErrors.CauseError{Message: msg, Cause: err}, logger)
+		}
+	}
+
+	signalCtx, stopSignals := letsencryptUtilsCli.SignalContext(logger)
+	defer stopSignals()
+	ctx := letsencryptUtilsHttpclient.ContextWithClient(signalCtx, httpClient)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var key crypto.Signer
+	if keyPath != "" {
+		keyPemData, err := os.ReadFile(keyPath)
+		if err != nil {
+			msg := "An error occurred when reading the account key file."
+			letsencryptUtilsCli.LogFatalWithExitingMessage(
+				msg,
+				&motmedelErrors.InputError{Message: msg, Cause: err, Input: keyPath},
+				logger,
+			)
+		}
+
+		key, err = letsencryptUtilsKey.ParsePem(keyPemData)
+		if err != nil {
+			msg := "An error occurred when parsing the account key."
+			letsencryptUtilsCli.LogFatalWithExitingMessage(
+				msg,
+				&motmedelErrors.InputError{Message: msg, Cause: err, Input: keyPath},
+				logger,
+			)
+		}
+
+		flag.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "key-type", "curve", "rsa-bits":
+				logger.Warn("The key type flags are ignored as -key is set.", slog.String("flag", f.Name))
+			}
+		})
+	}
+
+	var thumbprint string
+	computeThumbprint := func(key crypto.Signer) {
+		if !printThumbprint {
+			return
+		}
+		var err error
+		thumbprint, err = letsencryptUtilsKey.Thumbprint(key)
+		if err != nil {
+			letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when computing the thumbprint.", err, logger)
+		}
+	}
+
+	var accountCredentials *letsencryptUtilsTypes.AccountCredentials
+	if onlyExisting {
+		if keyPath == "" {
+			letsencryptUtilsCli.LogFatalWithExitingMessage("The -only-existing flag requires the -key flag.", nil, logger)
+		}
+
+		accountCredentials, err = letsencryptUtilsAccount.FindAccount(
+			ctx,
+			key,
+			letsencryptUtilsAccount.WithDirectoryUrl(directoryUrl),
+		)
+		if err != nil {
+			if isTimeout(ctx, err) {
+				letsencryptUtilsCli.LogFatalWithExitingMessage("Timed out when finding the account.", err, logger)
+			}
+			letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when finding the account.", err, logger)
+		}
+		computeThumbprint(key)
+	} else {
+		opts := []letsencryptUtilsAccount.Option{
+			letsencryptUtilsAccount.WithContext(ctx),
+			letsencryptUtilsAccount.WithDirectoryUrl(directoryUrl),
+			letsencryptUtilsAccount.WithKeyType(letsencryptUtilsKey.Type(keyType)),
+			letsencryptUtilsAccount.WithCurve(letsencryptUtilsKey.Curve(curve)),
+			letsencryptUtilsAccount.WithRsaBits(rsaBits),
+			letsencryptUtilsAccount.WithRetries(retries),
+			letsencryptUtilsAccount.WithAcceptTos(acceptTos),
+			letsencryptUtilsAccount.WithLowercaseEmails(lowercaseEmail),
+		}
+		if key != nil {
+			opts = append(opts, letsencryptUtilsAccount.WithKey(key))
+		}
+		var emailAddress string
+		if len(emailAddresses) != 0 {
+			emailAddress = emailAddresses[0]
+			opts = append(opts, letsencryptUtilsAccount.WithContacts(emailAddresses[1:]...))
+		}
+		if eabKeyId != "" {
+			opts = append(opts, letsencryptUtilsAccount.WithEAB(eabKeyId, eabHmacKeyData))
+		}
+
+		registration, err := letsencryptUtilsAccount.PrepareRegistration(emailAddress, opts...)
+		if err != nil {
+			letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when preparing the registration.", err, logger)
+		}
+		computeThumbprint(registration.Key)
+
+		if dryRun {
+			keySpec, err := letsencryptUtilsKey.SpecOf(registration.Key)
+			if err != nil {
+				letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when inspecting the account key.", err, logger)
+			}
+
+			var eabKeyIdAttribute string
+			if registration.ExternalAccountBinding != nil {
+				eabKeyIdAttribute = registration.ExternalAccountBinding.KID
+			}
+
+			logger.Info(
+				"Dry run; the account would be registered as follows.",
+				slog.String("directory_url", registration.DirectoryUrl),
+				slog.Any("contacts", letsencryptUtilsRedact.Contacts(registration.Contacts)),
+				slog.String("key_type", string(keySpec.Type)),
+				slog.String("curve", string(keySpec.Curve)),
+				slog.Int("rsa_bits", keySpec.RsaBits),
+				slog.String("eab_kid", eabKeyIdAttribute),
+				slog.String("output", accountCredentialsOutPath),
+				slog.Bool("encrypted", len(passphrase) != 0),
+			)
+			if thumbprint != "" {
+				fmt.Println(thumbprint)
+			}
+			return
+		}
+
+		// The terms of service are to be read and explicitly agreed to before registering.
+
+		directory, err := letsencryptUtilsDirectory.Fetch(ctx, httpClient, registration.DirectoryUrl)
+		if err != nil {
+			letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when fetching the directory.", err, logger)
+		}
+
+		if termsOfService := directory.Meta.TermsOfService; termsOfService != "" {
+			logger.Info("The CA has terms of service.", slog.String("terms_of_service", termsOfService))
+			if !acceptTos {
+				msg := "The terms of service must be agreed to with -accept-tos before registering."
+				letsencryptUtilsCli.LogFatalWithExitingMessage(
+					msg,
+					&motmedelErrors.InputError{Message: msg, Input: termsOfService},
+					logger,
+				)
+			}
+		}
+
+		accountCredentials, err = registration.Register(ctx)
+		if err != nil {
+			if isTimeout(ctx, err) {
+				letsencryptUtilsCli.LogFatalWithExitingMessage("Timed out when registering an account.", err, logger)
+			}
+			letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when registering an account.", err, logger)
+		}
+	}
+
+	if store == storeKeyring {
+		accountCredentials, err = letsencryptUtilsTypes.StoreKeyInKeyring(accountCredentials)
+		if err != nil {
+			letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when storing the account key.", err, logger)
+		}
+	}
+
+	var accountCredentialsData []byte
+	if len(passphrase) != 0 {
+		accountCredentialsData, err = letsencryptUtilsTypes.EncryptAccountCredentials(accountCredentials, passphrase)
+		if err != nil {
+			letsencryptUtilsCli.LogFatalWithExitingMessage(
+				"An error occurred when encrypting the account credentials.",
+				err,
+				logger,
+			)
+		}
+	} else {
+		accountCredentialsData, err = json.Marshal(accountCredentials)
+		if err != nil {
+			msg := "An error occurred when marshalling the account credentials."
+			letsencryptUtilsCli.LogFatalWithExitingMessage(msg, &motmedelErrors.CauseError{Message: msg, Cause: err}, logger)
+		}
+	}
+
+	// An existing file, overwritten with -force, is backed up under the lock, in the state it is in when replaced.
+	writeStart := time.Now()
+	err = letsencryptUtilsTypes.WriteAccountCredentialsFile(
+		accountCredentialsOutPath,
+		accountCredentialsData,
+		*backupCount,
+	)
+	if err != nil {
 		msg := "An error occurred when writing the account credentials data to disk."
-		motmedelLog.LogFatalWithExitingMessage(
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
 			msg,
 			&motmedelErrors.InputError{Message: msg, Cause: err, Input: accountCredentialsOutPath},
 			logger,
 		)
 	}
+	logger.Debug("Account credentials write timing.", slog.Int64("write_ms", time.Since(writeStart).Milliseconds()))
+
+	if outputJson {
+		summary := struct {
+			Uri        string `json:"uri"`
+			Email      string `json:"email,omitempty"`
+			Thumbprint string `json:"thumbprint,omitempty"`
+		}{Uri: accountCredentials.Uri, Email: accountCredentials.Email, Thumbprint: thumbprint}
+		if err := json.NewEncoder(os.Stdout).Encode(summary); err != nil {
+			msg := "An error occurred when writing the account summary to stdout."
+			letsencryptUtilsCli.LogFatalWithExitingMessage(msg, &motmedelErrors.CauseError{Message: msg, Cause: err}, logger)
+		}
+	} else if thumbprint != "" {
+		fmt.Println(thumbprint)
+	}
 }
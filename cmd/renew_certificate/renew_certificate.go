@@ -0,0 +1,172 @@
+package main
+
+import (
+	"flag"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsCli "github.com/altshiftab/letsencrypt_utils/internal/cli"
+	letsencryptUtilsCertificate "github.com/altshiftab/letsencrypt_utils/pkg/certificate"
+	letsencryptUtilsHttpclient "github.com/altshiftab/letsencrypt_utils/pkg/httpclient"
+	"log/slog"
+	"os"
+	"time"
+)
+
+func main() {
+	var accountCredentialsPath string
+	flag.StringVar(
+		&accountCredentialsPath,
+		"credentials",
+		"account_credentials.json",
+		"The path of the account credentials file.",
+	)
+	credentialsConfig := letsencryptUtilsCli.AddCredentialsFlags(flag.CommandLine)
+
+	var certificatePath string
+	flag.StringVar(
+		&certificatePath,
+		"certificate",
+		"certificate.pem",
+		"The path of the certificate chain file, or of a DER-encoded leaf certificate, to be inspected, which is "+
+			"replaced when renewed if none of -cert-out, -chain-out, and -fullchain-out are set.",
+	)
+
+	var renewBefore time.Duration
+	flag.DurationVar(
+		&renewBefore,
+		"renew-before",
+		720*time.Hour,
+		"The window before expiry within which the certificate is renewed, used when the CA does not suggest a renewal window.",
+	)
+
+	outputConfig := letsencryptUtilsCli.AddCertificateOutputFlags(flag.CommandLine)
+	directoryConfig := letsencryptUtilsCli.AddDirectoryFlags(flag.CommandLine)
+	networkConfig := letsencryptUtilsCli.AddNetworkFlags(flag.CommandLine)
+	orderConfig := letsencryptUtilsCli.AddOrderFlags(flag.CommandLine)
+	pkcs12Config := letsencryptUtilsCli.AddPkcs12Flags(flag.CommandLine)
+	hookConfig := letsencryptUtilsCli.AddHookFlags(flag.CommandLine)
+	logConfig := letsencryptUtilsCli.AddLogFlags(flag.CommandLine)
+
+	err := letsencryptUtilsCli.ParseFlags(flag.CommandLine, letsencryptUtilsCli.ConfigFlagName, os.Args[1:])
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when resolving the flags.", err, slog.Default())
+	}
+
+	logger, err := logConfig.Logger()
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when configuring the logger.", err, slog.Default())
+	}
+
+	httpClient, err := networkConfig.HttpClient(logger)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when configuring the network.", err, logger)
+	}
+	signalCtx, stopSignals := letsencryptUtilsCli.SignalContext(logger)
+	defer stopSignals()
+	ctx := letsencryptUtilsHttpclient.ContextWithClient(signalCtx, httpClient)
+
+	// The account credentials are loaded up front, as they determine the directory whose renewal information is used.
+
+	accountCredentials, err := credentialsConfig.Load(accountCredentialsPath)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when loading the account credentials.", err, logger)
+	}
+
+	directoryUrl, err := directoryConfig.AccountDirectoryUrl(accountCredentials, logger)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when selecting the directory URL.", err, logger)
+	}
+
+	if err := outputConfig.Validate(); err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("The output configuration is invalid.", err, logger)
+	}
+
+	if err := pkcs12Config.Validate(); err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("The PKCS#12 configuration is invalid.", err, logger)
+	}
+
+	// Inspect the existing certificate.
+
+	certificateData, err := os.ReadFile(certificatePath)
+	if err != nil {
+		msg := "An error occurred when reading the certificate file."
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			msg,
+			&motmedelErrors.InputError{Message: msg, Cause: err, Input: certificatePath},
+			logger,
+		)
+	}
+
+	leaf, err := letsencryptUtilsCertificate.ParseLeaf(certificateData)
+	if err != nil {
+		msg := "An error occurred when parsing the certificate."
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			msg,
+			&motmedelErrors.InputError{Message: msg, Cause: err, Input: certificatePath},
+			logger,
+		)
+	}
+
+	// Prefer the renewal window suggested by the CA, falling back to the static threshold.
+
+	renewAt := letsencryptUtilsCli.RenewAt(ctx, httpClient, directoryUrl, leaf, renewBefore, logger)
+
+	if time.Now().Before(renewAt) {
+		logger.Info(
+			"The certificate is not yet due for renewal.",
+			slog.Time("not_after", leaf.NotAfter),
+			slog.Time("renew_at", renewAt),
+		)
+		return
+	}
+
+	domains := leaf.DNSNames
+	if len(domains) == 0 {
+		msg := "The certificate has no DNS names to renew."
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			msg,
+			&motmedelErrors.InputError{Message: msg, Input: certificatePath},
+			logger,
+		)
+	}
+
+	logger.Info(
+		"The certificate is due for renewal.",
+		slog.Time("not_after", leaf.NotAfter),
+		slog.Any("domains", domains),
+	)
+
+	// Renew the certificate.
+
+	client, err := accountCredentials.Client(directoryUrl)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when creating the ACME client.", err, logger)
+	}
+	client.HTTPClient = httpClient
+
+	hookEnvironment := &letsencryptUtilsCli.HookEnvironment{
+		Domains:  domains,
+		CertPath: outputConfig.CertificatePath(certificatePath),
+		KeyPath:  outputConfig.KeyOutPath,
+	}
+
+	if err := hookConfig.RunPreHook(ctx, hookEnvironment); err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when running the pre-hook.", err, logger)
+	}
+
+	certificate, err := orderConfig.Obtain(ctx, client, domains, outputConfig.KeyOutPath)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when obtaining the certificate.", err, logger)
+	}
+
+	if err := outputConfig.Write(certificate, certificatePath); err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when writing the certificate.", err, logger)
+	}
+
+	if err := pkcs12Config.Write(certificate); err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when writing the PKCS#12 file.", err, logger)
+	}
+
+	if err := hookConfig.RunPostHook(ctx, hookEnvironment); err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when running the post-hook.", err, logger)
+	}
+}
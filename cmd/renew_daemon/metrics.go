@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	motmedelLog "github.com/Motmedel/utils_go/pkg/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+const metricsNamespace = "letsencrypt_utils"
+
+// The metrics are registered with the default registry, so that the Go runtime and process metrics are exposed too.
+var (
+	managedCertificatesGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "managed_certificates",
+		Help:      "The number of managed certificates.",
+	})
+	daysUntilExpiryGauge = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "certificate_days_until_expiry",
+			Help:      "The number of days until a managed certificate expires.",
+		},
+		[]string{"certificate"},
+	)
+	renewalsCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "renewals_total",
+			Help:      "The number of renewals, by result.",
+		},
+		[]string{"result"},
+	)
+	acmeRequestDurationHistogram = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "acme_request_duration_seconds",
+			Help:      "The latency of the requests to the ACME server.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"method", "code"},
+	)
+)
+
+const (
+	renewalResultSuccess = "success"
+	renewalResultFailure = "failure"
+)
+
+func init() {
+	// The renewal counters are exposed from the start, so that an alert on an increase does not miss the first one.
+	for _, result := range []string{renewalResultSuccess, renewalResultFailure} {
+		renewalsCounter.WithLabelValues(result)
+	}
+}
+
+// instrumentHttpClient returns an HTTP client that makes the requests with the HTTP client and records their latency.
+func instrumentHttpClient(httpClient *http.Client) *http.Client {
+	transport := httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &http.Client{
+		Transport: promhttp.InstrumentRoundTripperDuration(acmeRequestDurationHistogram, transport),
+		Timeout:   httpClient.Timeout,
+	}
+}
+
+// setDaysUntilExpiry records the remaining validity of the certificate.
+func setDaysUntilExpiry(certificatePath string, notAfter time.Time) {
+	daysUntilExpiryGauge.WithLabelValues(certificatePath).Set(time.Until(notAfter).Hours() / 24)
+}
+
+// serveMetrics serves the metrics at /metrics on the address until the context is done.
+func serveMetrics(ctx context.Context, address string, logger *slog.Logger) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when listening on the metrics address.",
+			Cause:   err,
+			Input:   address,
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			motmedelLog.LogError(
+				"An error occurred when serving the metrics.",
+				&motmedelErrors.CauseError{Message: "An error occurred when serving the metrics.", Cause: err},
+				logger,
+			)
+		}
+	}()
+
+	logger.Info("Serving the metrics.", slog.String("address", listener.Addr().String()))
+
+	return nil
+}
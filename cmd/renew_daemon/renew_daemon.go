@@ -0,0 +1,473 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"flag"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	motmedelLog "github.com/Motmedel/utils_go/pkg/log"
+	letsencryptUtilsCli "github.com/altshiftab/letsencrypt_utils/internal/cli"
+	letsencryptUtilsSystemd "github.com/altshiftab/letsencrypt_utils/internal/systemd"
+	letsencryptUtilsCertificate "github.com/altshiftab/letsencrypt_utils/pkg/certificate"
+	letsencryptUtilsHttpclient "github.com/altshiftab/letsencrypt_utils/pkg/httpclient"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// managedCertificate is an entry of the configuration file, describing a certificate to be kept renewed and where
+// the renewed certificate is to be written.
+type managedCertificate struct {
+	// Certificate is the path of the certificate chain file to be inspected, which is replaced when renewed if none
+	// of the output paths are set.
+	Certificate  string `json:"certificate"`
+	CertOut      string `json:"cert_out,omitempty"`
+	ChainOut     string `json:"chain_out,omitempty"`
+	FullchainOut string `json:"fullchain_out,omitempty"`
+	KeyOut       string `json:"key_out"`
+}
+
+func (managed *managedCertificate) outputConfig() *letsencryptUtilsCli.CertificateOutputConfig {
+	return &letsencryptUtilsCli.CertificateOutputConfig{
+		CertOutPath:      managed.CertOut,
+		ChainOutPath:     managed.ChainOut,
+		FullchainOutPath: managed.FullchainOut,
+		KeyOutPath:       managed.KeyOut,
+	}
+}
+
+// loadConfig reads the managed certificates from the configuration file.
+func loadConfig(path string) ([]*managedCertificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when reading the configuration file.",
+			Cause:   err,
+			Input:   path,
+		}
+	}
+
+	var managedCertificates []*managedCertificate
+	if err := json.Unmarshal(data, &managedCertificates); err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when parsing the configuration file.",
+			Cause:   err,
+			Input:   path,
+		}
+	}
+
+	for _, managed := range managedCertificates {
+		if managed.Certificate == "" || managed.KeyOut == "" {
+			return nil, &motmedelErrors.InputError{
+				Message: "A managed certificate lacks a certificate or key path.",
+				Input:   path,
+			}
+		}
+	}
+
+	return managedCertificates, nil
+}
+
+// scanDirectory finds the managed certificates of the directory: each "<name>.pem" file is a certificate chain, whose
+// key is the "<name>.key" file next to it.
+func scanDirectory(path string) ([]*managedCertificate, error) {
+	certificatePaths, err := filepath.Glob(filepath.Join(path, "*.pem"))
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when listing the certificates directory.",
+			Cause:   err,
+			Input:   path,
+		}
+	}
+
+	var managedCertificates []*managedCertificate
+	for _, certificatePath := range certificatePaths {
+		managedCertificates = append(
+			managedCertificates,
+			&managedCertificate{
+				Certificate: certificatePath,
+				KeyOut:      strings.TrimSuffix(certificatePath, ".pem") + ".key",
+			},
+		)
+	}
+
+	return managedCertificates, nil
+}
+
+// daemon holds the settings of the renewal daemon.
+type daemon struct {
+	accountCredentialsPath string
+	credentialsConfig      *letsencryptUtilsCli.CredentialsConfig
+	directoryConfig        *letsencryptUtilsCli.DirectoryConfig
+	orderConfig            *letsencryptUtilsCli.OrderConfig
+	hookConfig             *letsencryptUtilsCli.HookConfig
+	httpClient             *http.Client
+	configPath             string
+	certificatesDirectory  string
+	renewBefore            time.Duration
+	renewalTimeout         time.Duration
+	logger                 *slog.Logger
+	ready                  bool
+}
+
+// notify sends the state to systemd, if run as a systemd service of type notify. A failure is only logged, as the
+// renewals do not depend on it.
+func (daemon *daemon) notify(state string) {
+	sent, err := letsencryptUtilsSystemd.Notify(state)
+	if err != nil {
+		motmedelLog.LogWarning("An error occurred when notifying systemd.", err, daemon.logger)
+		return
+	}
+	if sent {
+		daemon.logger.Debug("Notified systemd.", slog.String("state", state))
+	}
+}
+
+// managedCertificates lists the managed certificates, from the configuration file or the certificates directory.
+func (daemon *daemon) managedCertificates() ([]*managedCertificate, error) {
+	if daemon.configPath != "" {
+		return loadConfig(daemon.configPath)
+	}
+	return scanDirectory(daemon.certificatesDirectory)
+}
+
+// renew renews the managed certificate if it is due, reporting whether it was renewed.
+func (daemon *daemon) renew(
+	ctx context.Context,
+	managed *managedCertificate,
+	directoryUrl string,
+	obtain func(context.Context, []string, string) (*letsencryptUtilsCli.Certificate, error),
+) (bool, error) {
+	logger := daemon.logger.With(slog.String("certificate", managed.Certificate))
+
+	certificatePemData, err := os.ReadFile(managed.Certificate)
+	if err != nil {
+		return false, &motmedelErrors.InputError{
+			Message: "An error occurred when reading the certificate file.",
+			Cause:   err,
+			Input:   managed.Certificate,
+		}
+	}
+
+	leaf, err := letsencryptUtilsCertificate.ParsePemLeaf(certificatePemData)
+	if err != nil {
+		return false, &motmedelErrors.InputError{
+			Message: "An error occurred when parsing the certificate.",
+			Cause:   err,
+			Input:   managed.Certificate,
+		}
+	}
+
+	setDaysUntilExpiry(managed.Certificate, leaf.NotAfter)
+
+	renewAt := letsencryptUtilsCli.RenewAt(ctx, daemon.httpClient, directoryUrl, leaf, daemon.renewBefore, logger)
+	if time.Now().Before(renewAt) {
+		logger.Debug(
+			"The certificate is not yet due for renewal.",
+			slog.Time("not_after", leaf.NotAfter),
+			slog.Time("renew_at", renewAt),
+		)
+		return false, nil
+	}
+
+	domains := leaf.DNSNames
+	if len(domains) == 0 {
+		return false, &motmedelErrors.InputError{
+			Message: "The certificate has no DNS names to renew.",
+			Input:   managed.Certificate,
+		}
+	}
+
+	logger.Info(
+		"The certificate is due for renewal.",
+		slog.Time("not_after", leaf.NotAfter),
+		slog.Any("domains", domains),
+	)
+
+	outputConfig := managed.outputConfig()
+	hookEnvironment := &letsencryptUtilsCli.HookEnvironment{
+		Domains:  domains,
+		CertPath: outputConfig.CertificatePath(managed.Certificate),
+		KeyPath:  outputConfig.KeyOutPath,
+	}
+
+	if err := daemon.hookConfig.RunPreHook(ctx, hookEnvironment); err != nil {
+		return false, &motmedelErrors.CauseError{Message: "An error occurred when running the pre-hook.", Cause: err}
+	}
+
+	certificate, err := obtain(ctx, domains, outputConfig.KeyOutPath)
+	if err != nil {
+		return false, &motmedelErrors.CauseError{Message: "An error occurred when obtaining the certificate.", Cause: err}
+	}
+
+	if err := outputConfig.Write(certificate, managed.Certificate); err != nil {
+		return false, &motmedelErrors.CauseError{Message: "An error occurred when writing the certificate.", Cause: err}
+	}
+
+	if newLeaf, err := x509.ParseCertificate(certificate.DerChain[0]); err == nil {
+		setDaysUntilExpiry(managed.Certificate, newLeaf.NotAfter)
+	}
+
+	if err := daemon.hookConfig.RunPostHook(ctx, hookEnvironment); err != nil {
+		return true, &motmedelErrors.CauseError{Message: "An error occurred when running the post-hook.", Cause: err}
+	}
+
+	return true, nil
+}
+
+// cycle checks each of the managed certificates once, renewing those that are due. A failure of one certificate does
+// not prevent the others from being checked. Starting renewals stops when the stop context is done, while the context
+// of the renewals is only done once an in-flight renewal is to be cancelled.
+func (daemon *daemon) cycle(stopCtx context.Context, renewCtx context.Context) error {
+	managedCertificates, err := daemon.managedCertificates()
+	if err != nil {
+		return err
+	}
+
+	// The expiry gauges are reset, so that no stale ones remain for certificates that are no longer managed.
+	managedCertificatesGauge.Set(float64(len(managedCertificates)))
+	daysUntilExpiryGauge.Reset()
+
+	// The credentials are loaded anew each cycle, so that e.g. a key rollover is picked up.
+
+	accountCredentials, err := daemon.credentialsConfig.Load(daemon.accountCredentialsPath)
+	if err != nil {
+		return &motmedelErrors.CauseError{Message: "An error occurred when loading the account credentials.", Cause: err}
+	}
+
+	directoryUrl, err := daemon.directoryConfig.AccountDirectoryUrl(accountCredentials, daemon.logger)
+	if err != nil {
+		return &motmedelErrors.CauseError{Message: "An error occurred when selecting the directory URL.", Cause: err}
+	}
+
+	// The service is ready once the configuration has been loaded successfully the first time.
+	if !daemon.ready {
+		daemon.ready = true
+		daemon.notify(letsencryptUtilsSystemd.StateReady)
+	}
+
+	client, err := accountCredentials.Client(directoryUrl)
+	if err != nil {
+		return &motmedelErrors.CauseError{Message: "An error occurred when creating the ACME client.", Cause: err}
+	}
+	client.HTTPClient = daemon.httpClient
+
+	obtain := func(ctx context.Context, domains []string, keyPath string) (*letsencryptUtilsCli.Certificate, error) {
+		return daemon.orderConfig.Obtain(ctx, client, domains, keyPath)
+	}
+
+	var numRenewed, numFailed int
+	for _, managed := range managedCertificates {
+		if stopCtx.Err() != nil {
+			break
+		}
+
+		ctx, cancel := context.WithTimeout(renewCtx, daemon.renewalTimeout)
+		renewed, err := daemon.renew(ctx, managed, directoryUrl, obtain)
+		cancel()
+
+		if renewed && err == nil {
+			renewalsCounter.WithLabelValues(renewalResultSuccess).Inc()
+		}
+		if renewed {
+			numRenewed++
+			daemon.logger.Info("The certificate was renewed.", slog.String("certificate", managed.Certificate))
+		}
+		if err != nil {
+			numFailed++
+			renewalsCounter.WithLabelValues(renewalResultFailure).Inc()
+			motmedelLog.LogError(
+				"An error occurred when renewing a certificate.",
+				&motmedelErrors.InputError{
+					Message: "An error occurred when renewing a certificate.",
+					Cause:   err,
+					Input:   managed.Certificate,
+				},
+				daemon.logger,
+			)
+		}
+	}
+
+	daemon.logger.Info(
+		"The renewal cycle has completed.",
+		slog.Int("total", len(managedCertificates)),
+		slog.Int("renewed", numRenewed),
+		slog.Int("failed", numFailed),
+	)
+
+	return nil
+}
+
+func main() {
+	daemon := &daemon{}
+
+	flag.StringVar(
+		&daemon.accountCredentialsPath,
+		"credentials",
+		"account_credentials.json",
+		"The path of the account credentials file.",
+	)
+	daemon.credentialsConfig = letsencryptUtilsCli.AddCredentialsFlags(flag.CommandLine)
+
+	flag.StringVar(
+		&daemon.configPath,
+		"config",
+		"",
+		"The path of a JSON file containing a list of managed certificates, each with a certificate path and output "+
+			"paths. Takes precedence over -certificates-dir.",
+	)
+	flag.StringVar(
+		&daemon.certificatesDirectory,
+		"certificates-dir",
+		"",
+		"A directory of managed certificates, in which each \"<name>.pem\" certificate chain file has its key in a "+
+			"\"<name>.key\" file.",
+	)
+
+	var interval time.Duration
+	flag.DurationVar(
+		&interval,
+		"interval",
+		12*time.Hour,
+		"The interval at which the certificates are checked. Under systemd, WatchdogSec must exceed it, as the "+
+			"watchdog is pinged once per interval.",
+	)
+
+	flag.DurationVar(
+		&daemon.renewBefore,
+		"renew-before",
+		720*time.Hour,
+		"The window before expiry within which a certificate is renewed, used when the CA does not suggest a renewal "+
+			"window.",
+	)
+	flag.DurationVar(
+		&daemon.renewalTimeout,
+		"renewal-timeout",
+		10*time.Minute,
+		"The maximum duration of each renewal.",
+	)
+
+	var shutdownTimeout time.Duration
+	flag.DurationVar(
+		&shutdownTimeout,
+		"shutdown-timeout",
+		time.Minute,
+		"The maximum duration an in-flight renewal may take to finish after a shutdown signal, before it is cancelled.",
+	)
+
+	var metricsAddress string
+	flag.StringVar(
+		&metricsAddress,
+		"metrics-addr",
+		"",
+		"The address on which Prometheus metrics are served at /metrics, e.g. \":9100\". No metrics are served if "+
+			"empty.",
+	)
+
+	daemon.directoryConfig = letsencryptUtilsCli.AddDirectoryFlags(flag.CommandLine)
+	networkConfig := letsencryptUtilsCli.AddNetworkFlags(flag.CommandLine)
+	daemon.orderConfig = letsencryptUtilsCli.AddOrderFlags(flag.CommandLine)
+	daemon.hookConfig = letsencryptUtilsCli.AddHookFlags(flag.CommandLine)
+	logConfig := letsencryptUtilsCli.AddLogFlags(flag.CommandLine)
+
+	// The -config flag selects the managed certificates, so the file of flag values is selected with -flags-config.
+	if err := letsencryptUtilsCli.ParseFlags(flag.CommandLine, "flags-config", os.Args[1:]); err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when resolving the flags.", err, slog.Default())
+	}
+
+	logger, err := logConfig.Logger()
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when configuring the logger.", err, slog.Default())
+	}
+	daemon.logger = logger
+
+	httpClient, err := networkConfig.HttpClient(logger)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when configuring the network.", err, logger)
+	}
+	daemon.httpClient = instrumentHttpClient(httpClient)
+
+	if daemon.configPath == "" && daemon.certificatesDirectory == "" {
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			"Either -config or -certificates-dir must be provided.",
+			nil,
+			logger,
+		)
+	}
+
+	if interval <= 0 {
+		msg := "The interval must be positive."
+		letsencryptUtilsCli.LogFatalWithExitingMessage(msg, &motmedelErrors.InputError{Message: msg, Input: interval}, logger)
+	}
+
+	if daemon.orderConfig.StatePath != "" {
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			"The -state-file flag is unsupported by the daemon, as the renewals would share the file.",
+			nil,
+			logger,
+		)
+	}
+
+	if metricsAddress != "" {
+		metricsCtx, stopMetrics := context.WithCancel(context.Background())
+		defer stopMetrics()
+		if err := serveMetrics(metricsCtx, metricsAddress, logger); err != nil {
+			letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when serving the metrics.", err, logger)
+		}
+	}
+
+	// On a shutdown signal, no further renewals are started, and an in-flight renewal is given the shutdown timeout
+	// to finish before it is cancelled. A second signal cancels it immediately.
+
+	stopCtx, stop := context.WithCancel(context.Background())
+	defer stop()
+	renewCtx, cancelRenewals := context.WithCancel(
+		letsencryptUtilsHttpclient.ContextWithClient(context.Background(), httpClient),
+	)
+	defer cancelRenewals()
+
+	signalChannel := make(chan os.Signal, 2)
+	signal.Notify(signalChannel, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		receivedSignal := <-signalChannel
+		logger.Info(
+			"Shutting down; an in-flight renewal is allowed to finish.",
+			slog.String("signal", receivedSignal.String()),
+			slog.Duration("shutdown_timeout", shutdownTimeout),
+		)
+		stop()
+
+		select {
+		case <-signalChannel:
+			logger.Warn("Shutting down immediately; an in-flight renewal is cancelled.")
+		case <-time.After(shutdownTimeout):
+			logger.Warn("The shutdown timeout has elapsed; an in-flight renewal is cancelled.")
+		}
+		cancelRenewals()
+	}()
+
+	logger.Info("The renewal daemon has started.", slog.Duration("interval", interval))
+
+	for {
+		if err := daemon.cycle(stopCtx, renewCtx); err != nil {
+			motmedelLog.LogError("An error occurred in the renewal cycle.", err, logger)
+		} else {
+			// The watchdog timeout of the service must exceed the interval, as the watchdog is pinged each cycle.
+			daemon.notify(letsencryptUtilsSystemd.StateWatchdog)
+		}
+
+		select {
+		case <-stopCtx.Done():
+			daemon.notify(letsencryptUtilsSystemd.StateStopping)
+			logger.Info("The renewal daemon has stopped.")
+			return
+		case <-time.After(interval):
+		}
+	}
+}
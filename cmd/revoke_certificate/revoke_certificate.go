@@ -0,0 +1,172 @@
+package main
+
+import (
+	"crypto"
+	"errors"
+	"flag"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsCli "github.com/altshiftab/letsencrypt_utils/internal/cli"
+	letsencryptUtilsCertificate "github.com/altshiftab/letsencrypt_utils/pkg/certificate"
+	letsencryptUtilsHttpclient "github.com/altshiftab/letsencrypt_utils/pkg/httpclient"
+	letsencryptUtilsKey "github.com/altshiftab/letsencrypt_utils/pkg/key"
+	letsencryptUtilsRevoke "github.com/altshiftab/letsencrypt_utils/pkg/revoke"
+	"log/slog"
+	"os"
+)
+
+func main() {
+	var certificatePath string
+	flag.StringVar(
+		&certificatePath,
+		"certificate",
+		"certificate.pem",
+		"The path of the certificate (or certificate chain) PEM file.",
+	)
+
+	var accountCredentialsPath string
+	flag.StringVar(
+		&accountCredentialsPath,
+		"credentials",
+		"account_credentials.json",
+		"The path of the account credentials file. Not used when -cert-key is provided.",
+	)
+	credentialsConfig := letsencryptUtilsCli.AddCredentialsFlags(flag.CommandLine)
+
+	var certificateKeyPath string
+	flag.StringVar(
+		&certificateKeyPath,
+		"cert-key",
+		"",
+		"The path of the certificate's private key PEM file, to be used instead of the account credentials.",
+	)
+
+	var reasonName string
+	flag.StringVar(
+		&reasonName,
+		"reason",
+		"unspecified",
+		"The revocation reason (unspecified, keyCompromise, superseded, or cessationOfOperation).",
+	)
+
+	directoryConfig := letsencryptUtilsCli.AddDirectoryFlags(flag.CommandLine)
+	networkConfig := letsencryptUtilsCli.AddNetworkFlags(flag.CommandLine)
+
+	logConfig := letsencryptUtilsCli.AddLogFlags(flag.CommandLine)
+
+	err := letsencryptUtilsCli.ParseFlags(flag.CommandLine, letsencryptUtilsCli.ConfigFlagName, os.Args[1:])
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when resolving the flags.", err, slog.Default())
+	}
+
+	logger, err := logConfig.Logger()
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when configuring the logger.", err, slog.Default())
+	}
+
+	httpClient, err := networkConfig.HttpClient(logger)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when configuring the network.", err, logger)
+	}
+	signalCtx, stopSignals := letsencryptUtilsCli.SignalContext(logger)
+	defer stopSignals()
+	ctx := letsencryptUtilsHttpclient.ContextWithClient(signalCtx, httpClient)
+
+	directoryUrl, err := directoryConfig.DirectoryUrl(logger)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when selecting the directory URL.", err, logger)
+	}
+
+	reason, err := letsencryptUtilsRevoke.ParseReason(reasonName)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when parsing the revocation reason.", err, logger)
+	}
+
+	certificatePemData, err := os.ReadFile(certificatePath)
+	if err != nil {
+		msg := "An error occurred when reading the certificate file."
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			msg,
+			&motmedelErrors.InputError{Message: msg, Cause: err, Input: certificatePath},
+			logger,
+		)
+	}
+
+	var signer crypto.Signer
+	revokeCert := letsencryptUtilsRevoke.RevokeCert
+	if certificateKeyPath != "" {
+		keyPemData, err := os.ReadFile(certificateKeyPath)
+		if err != nil {
+			msg := "An error occurred when reading the certificate key file."
+			letsencryptUtilsCli.LogFatalWithExitingMessage(
+				msg,
+				&motmedelErrors.InputError{Message: msg, Cause: err, Input: certificateKeyPath},
+				logger,
+			)
+		}
+
+		signer, err = letsencryptUtilsKey.ParsePem(keyPemData)
+		if err != nil {
+			msg := "An error occurred when parsing the certificate key."
+			letsencryptUtilsCli.LogFatalWithExitingMessage(
+				msg,
+				&motmedelErrors.InputError{Message: msg, Cause: err, Input: certificateKeyPath},
+				logger,
+			)
+		}
+		// The certificate key identifies itself rather than an account, and is checked against the certificate.
+		revokeCert = letsencryptUtilsRevoke.RevokeCertWithCertificateKey
+	} else {
+		accountCredentials, err := credentialsConfig.Load(accountCredentialsPath)
+		if err != nil {
+			letsencryptUtilsCli.LogFatalWithExitingMessage(
+				"An error occurred when loading the account credentials.",
+				err,
+				logger,
+			)
+		}
+
+		directoryUrl, err = directoryConfig.AccountDirectoryUrl(accountCredentials, logger)
+		if err != nil {
+			letsencryptUtilsCli.LogFatalWithExitingMessage(
+				"An error occurred when selecting the directory URL.",
+				err,
+				logger,
+			)
+		}
+
+		signer, err = accountCredentials.Signer()
+		if err != nil {
+			msg := "An error occurred when parsing the account key."
+			letsencryptUtilsCli.LogFatalWithExitingMessage(msg, &motmedelErrors.CauseError{Message: msg, Cause: err}, logger)
+		}
+	}
+
+	if err := revokeCert(
+		ctx,
+		certificatePemData,
+		signer,
+		reason,
+		directoryUrl,
+	); err != nil {
+		var alreadyRevokedError *letsencryptUtilsRevoke.AlreadyRevokedError
+		if errors.As(err, &alreadyRevokedError) {
+			logger.Info(
+				"The certificate was already revoked.",
+				slog.String("path", certificatePath),
+				slog.String("serial_number", alreadyRevokedError.SerialNumber),
+			)
+			return
+		}
+		if errors.Is(err, letsencryptUtilsCertificate.ErrKeyMismatch) {
+			msg := "The certificate key does not correspond to the certificate."
+			letsencryptUtilsCli.LogFatalWithExitingMessage(
+				msg,
+				&motmedelErrors.InputError{Message: msg, Cause: err, Input: []any{certificatePath, certificateKeyPath}},
+				logger,
+			)
+		}
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when revoking the certificate.", err, logger)
+	}
+
+	logger.Info("The certificate was revoked.", slog.String("path", certificatePath))
+}
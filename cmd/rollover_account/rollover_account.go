@@ -0,0 +1,194 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	motmedelLog "github.com/Motmedel/utils_go/pkg/log"
+	letsencryptUtilsCli "github.com/altshiftab/letsencrypt_utils/internal/cli"
+	letsencryptUtilsFileutil "github.com/altshiftab/letsencrypt_utils/internal/fileutil"
+	letsencryptUtilsAccount "github.com/altshiftab/letsencrypt_utils/pkg/account"
+	letsencryptUtilsHttpclient "github.com/altshiftab/letsencrypt_utils/pkg/httpclient"
+	letsencryptUtilsTypes "github.com/altshiftab/letsencrypt_utils/pkg/types"
+	"log/slog"
+	"os"
+)
+
+// newCredentialsSuffix is appended to the path of the credentials file to form the path to which the new credentials
+// are written, should the file itself not be writable.
+const newCredentialsSuffix = ".new"
+
+func main() {
+	var accountCredentialsPath string
+	flag.StringVar(
+		&accountCredentialsPath,
+		"credentials",
+		"account_credentials.json",
+		"The path of the account credentials file, which is updated with the new key.",
+	)
+	credentialsConfig := letsencryptUtilsCli.AddCredentialsFlags(flag.CommandLine)
+	backupCount := letsencryptUtilsCli.AddBackupFlags(flag.CommandLine)
+
+	directoryConfig := letsencryptUtilsCli.AddDirectoryFlags(flag.CommandLine)
+	networkConfig := letsencryptUtilsCli.AddNetworkFlags(flag.CommandLine)
+
+	logConfig := letsencryptUtilsCli.AddLogFlags(flag.CommandLine)
+
+	err := letsencryptUtilsCli.ParseFlags(flag.CommandLine, letsencryptUtilsCli.ConfigFlagName, os.Args[1:])
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when resolving the flags.", err, slog.Default())
+	}
+
+	logger, err := logConfig.Logger()
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when configuring the logger.", err, slog.Default())
+	}
+
+	httpClient, err := networkConfig.HttpClient(logger)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when configuring the network.", err, logger)
+	}
+	signalCtx, stopSignals := letsencryptUtilsCli.SignalContext(logger)
+	defer stopSignals()
+	ctx := letsencryptUtilsHttpclient.ContextWithClient(signalCtx, httpClient)
+
+	passphrase, err := credentialsConfig.Passphrase.Passphrase()
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when reading the passphrase.", err, logger)
+	}
+
+	accountCredentials, err := letsencryptUtilsTypes.LoadSelectedAccountCredentials(
+		accountCredentialsPath,
+		passphrase,
+		credentialsConfig.Selector(),
+	)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when loading the account credentials.", err, logger)
+	}
+
+	directoryUrl, err := directoryConfig.AccountDirectoryUrl(accountCredentials, logger)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when selecting the directory URL.", err, logger)
+	}
+
+	// The file is locked until the new credentials are written, so that a concurrent write is neither lost nor
+	// overwritten. The lock is released by the OS should the process exit early.
+
+	accountCredentialsLock, err := letsencryptUtilsTypes.LockAccountCredentialsFile(accountCredentialsPath)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when locking the account credentials.", err, logger)
+	}
+	defer func() { _ = accountCredentialsLock.Release() }()
+
+	// Back up the original credentials before anything is changed, so that they are retained whatever happens.
+
+	accountCredentialsData, err := accountCredentialsLock.Read()
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			"An error occurred when reading the account credentials file.",
+			err,
+			logger,
+		)
+	}
+
+	// The other accounts of the file are written back unchanged.
+	accountCredentialsFile, err := letsencryptUtilsTypes.ParseAccountCredentialsFile(accountCredentialsData, passphrase)
+	if err != nil {
+		msg := "An error occurred when parsing the account credentials file."
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			msg,
+			&motmedelErrors.InputError{Message: msg, Cause: err, Input: accountCredentialsPath},
+			logger,
+		)
+	}
+
+	if err := accountCredentialsLock.Backup(accountCredentialsData, *backupCount); err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			"An error occurred when backing up the account credentials file.",
+			err,
+			logger,
+		)
+	}
+
+	newAccountCredentials, err := letsencryptUtilsAccount.RolloverKey(
+		ctx,
+		accountCredentials,
+		directoryUrl,
+	)
+	if newAccountCredentials == nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage("An error occurred when rolling over the account key.", err, logger)
+	}
+	// The rollover has taken effect even if it could not be verified, so the new credentials are written before the
+	// verification error is reported; the old key no longer works.
+	verificationErr := err
+	if verificationErr != nil {
+		motmedelLog.LogWarning(
+			"The account key was rolled over, but the account could not be fetched with the new key; the new "+
+				"credentials are written regardless.",
+			verificationErr,
+			logger,
+		)
+	}
+
+	if newAccountCredentials.Keyring != nil {
+		storedAccountCredentials, err := letsencryptUtilsTypes.StoreKeyInKeyring(newAccountCredentials)
+		if err != nil {
+			// The rollover has taken effect, so the new key must not be lost; it is written to the file instead.
+			motmedelLog.LogWarning(
+				"An error occurred when storing the new account key in the keyring; it is written to the file instead.",
+				err,
+				logger,
+			)
+			newAccountCredentials.Keyring = nil
+		} else {
+			newAccountCredentials = storedAccountCredentials
+		}
+	}
+
+	if err := accountCredentialsFile.Replace(newAccountCredentials); err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			"An error occurred when replacing the account credentials.",
+			err,
+			logger,
+		)
+	}
+
+	// An encrypted credentials file remains encrypted with the same passphrase.
+	var encodingPassphrase []byte
+	if letsencryptUtilsTypes.IsEncrypted(accountCredentialsData) {
+		encodingPassphrase = passphrase
+	}
+	newAccountCredentialsData, err := accountCredentialsFile.Encode(encodingPassphrase)
+	if err != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			"An error occurred when encoding the account credentials.",
+			err,
+			logger,
+		)
+	}
+
+	if err := accountCredentialsLock.Write(newAccountCredentialsData); err != nil {
+		// The new key must not be lost, so it is written next to the credentials file as a last resort.
+		fallbackPath := accountCredentialsPath + newCredentialsSuffix
+		fallbackErr := letsencryptUtilsFileutil.WriteFileAtomic(fallbackPath, newAccountCredentialsData, 0600)
+		if fallbackErr == nil {
+			logger.Error(
+				"The new account credentials were written to the fallback path instead.",
+				slog.String("path", fallbackPath),
+			)
+		}
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			"An error occurred when writing the account credentials data to disk.",
+			errors.Join(err, fallbackErr),
+			logger,
+		)
+	}
+
+	if verificationErr != nil {
+		letsencryptUtilsCli.LogFatalWithExitingMessage(
+			"The new account credentials were written, but the rollover could not be verified.",
+			verificationErr,
+			logger,
+		)
+	}
+}
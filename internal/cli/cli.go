@@ -0,0 +1,18 @@
+package cli
+
+import "strings"
+
+// StringSliceFlag is a flag value that may be specified multiple times, collecting each value.
+type StringSliceFlag []string
+
+func (stringSliceFlag *StringSliceFlag) String() string {
+	if stringSliceFlag == nil {
+		return ""
+	}
+	return strings.Join(*stringSliceFlag, ",")
+}
+
+func (stringSliceFlag *StringSliceFlag) Set(value string) error {
+	*stringSliceFlag = append(*stringSliceFlag, value)
+	return nil
+}
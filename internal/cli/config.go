@@ -0,0 +1,278 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ConfigFlagName is the name of the flag selecting the configuration file, unless a command uses it otherwise.
+const ConfigFlagName = "config"
+
+// FlagEnvironmentPrefix prefixes the environment variables from which flags are resolved. The prefix is followed by
+// the flag name in upper case, with dashes replaced by underscores, e.g. LEU_LOG_FORMAT for -log-format.
+const FlagEnvironmentPrefix = "LEU_"
+
+// FlagEnvironmentVariable returns the environment variable from which the flag is resolved.
+func FlagEnvironmentVariable(name string) string {
+	return FlagEnvironmentPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+var (
+	flagEnvironmentAliasesMutex sync.Mutex
+	flagEnvironmentAliases      = make(map[*flag.FlagSet]map[string][]string)
+)
+
+// AddFlagEnvironmentAlias makes the flag of the flag set also resolvable from the environment variable, e.g. one that
+// was established before the LEU_ variables. The alias ranks alongside the LEU_ variable of the flag, which it yields
+// to, so a flag set on the command line takes precedence over it and it takes precedence over the configuration
+// file. An alias that is set but empty is ignored.
+func AddFlagEnvironmentAlias(flagSet *flag.FlagSet, name string, environmentVariable string) {
+	flagEnvironmentAliasesMutex.Lock()
+	defer flagEnvironmentAliasesMutex.Unlock()
+
+	if flagEnvironmentAliases[flagSet] == nil {
+		flagEnvironmentAliases[flagSet] = make(map[string][]string)
+	}
+	flagEnvironmentAliases[flagSet][name] = append(flagEnvironmentAliases[flagSet][name], environmentVariable)
+}
+
+// lookupFlagEnvironment returns the value of the environment variable from which the flag is resolved, if any is
+// set: its LEU_ variable, or otherwise the first of its aliases that is set and non-empty.
+func lookupFlagEnvironment(flagSet *flag.FlagSet, name string) (string, string, bool) {
+	environmentVariable := FlagEnvironmentVariable(name)
+	if value, ok := os.LookupEnv(environmentVariable); ok {
+		return environmentVariable, value, true
+	}
+
+	flagEnvironmentAliasesMutex.Lock()
+	aliases := slices.Clone(flagEnvironmentAliases[flagSet][name])
+	flagEnvironmentAliasesMutex.Unlock()
+
+	for _, alias := range aliases {
+		if value := os.Getenv(alias); value != "" {
+			return alias, value, true
+		}
+	}
+	return "", "", false
+}
+
+// ParseFlags parses the command-line arguments, and resolves the flags they do not set from the environment
+// variables of the flags and then from the configuration file, which is selected with a flag registered under the
+// name. The keys of the file are flag names; it is parsed as JSON if its extension is .json, and as TOML otherwise.
+//
+// The precedence is thus: a flag set on the command line, its environment variable, the configuration file, and
+// finally the default of the flag.
+func ParseFlags(flagSet *flag.FlagSet, configFlagName string, arguments []string) error {
+	configPath := flagSet.String(
+		configFlagName,
+		"",
+		"The path of a TOML or JSON file whose keys are flag names, providing values for the flags that are set "+
+			"neither on the command line nor via environment variables.",
+	)
+
+	usage := flagSet.Usage
+	flagSet.Usage = func() {
+		if usage != nil {
+			usage()
+		} else {
+			fmt.Fprintf(flagSet.Output(), "Usage of %s:\n", flagSet.Name())
+			flagSet.PrintDefaults()
+		}
+		fmt.Fprintf(
+			flagSet.Output(),
+			"\nEach flag may also be set via the environment variable of its name in upper case, with dashes "+
+				"replaced by underscores and prefixed with %s, e.g. %s for -log-format. A flag set on the command "+
+				"line takes precedence over its environment variable, which takes precedence over the file selected "+
+				"with -%s, which takes precedence over the default.\n",
+			FlagEnvironmentPrefix,
+			FlagEnvironmentVariable("log-format"),
+			configFlagName,
+		)
+	}
+
+	if err := flagSet.Parse(arguments); err != nil {
+		return err
+	}
+
+	resolved, err := ApplyEnvironment(flagSet)
+	if err != nil {
+		return err
+	}
+
+	if *configPath == "" {
+		return nil
+	}
+
+	values, err := loadConfigFile(*configPath)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		if name == configFlagName {
+			return &motmedelErrors.InputError{
+				Message: "The configuration file cannot select another configuration file.",
+				Input:   *configPath,
+			}
+		}
+
+		definedFlag := flagSet.Lookup(name)
+		if definedFlag == nil {
+			return &motmedelErrors.InputError{
+				Message: "The configuration file sets an unknown flag.",
+				Input:   []any{*configPath, name},
+			}
+		}
+		if resolved[name] {
+			continue
+		}
+
+		_, repeatable := definedFlag.Value.(*StringSliceFlag)
+		flagValues, err := configValueStrings(values[name], repeatable)
+		if err != nil {
+			return &motmedelErrors.InputError{
+				Message: "The configuration file value is invalid.",
+				Cause:   err,
+				Input:   []any{*configPath, name},
+			}
+		}
+		for _, flagValue := range flagValues {
+			if err := flagSet.Set(name, flagValue); err != nil {
+				return &motmedelErrors.InputError{
+					Message: "The configuration file value is invalid.",
+					Cause:   err,
+					Input:   []any{*configPath, name},
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// ApplyEnvironment sets the flags that were not set on the command line from their environment variables, or their
+// aliases, if set. It is to be called after the flag set is parsed, and returns the names of the flags that are set,
+// by either means.
+func ApplyEnvironment(flagSet *flag.FlagSet) (map[string]bool, error) {
+	resolved := make(map[string]bool)
+	flagSet.Visit(func(setFlag *flag.Flag) {
+		resolved[setFlag.Name] = true
+	})
+
+	var err error
+	flagSet.VisitAll(func(definedFlag *flag.Flag) {
+		if err != nil || resolved[definedFlag.Name] {
+			return
+		}
+
+		environmentVariable, value, ok := lookupFlagEnvironment(flagSet, definedFlag.Name)
+		if !ok {
+			return
+		}
+		if setErr := flagSet.Set(definedFlag.Name, value); setErr != nil {
+			err = &motmedelErrors.InputError{
+				Message: "The flag value of the environment variable is invalid.",
+				Cause:   setErr,
+				Input:   environmentVariable,
+			}
+			return
+		}
+		resolved[definedFlag.Name] = true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resolved, nil
+}
+
+// loadConfigFile reads the configuration file at the path into a map of flag names to values.
+func loadConfigFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when reading the configuration file.",
+			Cause:   err,
+			Input:   path,
+		}
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+
+		var values map[string]any
+		if err := decoder.Decode(&values); err != nil {
+			return nil, &motmedelErrors.InputError{
+				Message: "An error occurred when parsing the JSON configuration file.",
+				Cause:   err,
+				Input:   path,
+			}
+		}
+		return values, nil
+	}
+
+	values, err := parseToml(data)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when parsing the TOML configuration file.",
+			Cause:   err,
+			Input:   path,
+		}
+	}
+	return values, nil
+}
+
+// configValueStrings returns the flag values of a configuration file value. Arrays are only accepted for repeatable
+// flags, which are set once per element.
+func configValueStrings(value any, repeatable bool) ([]string, error) {
+	elements, ok := value.([]any)
+	if !ok {
+		flagValue, err := configScalarString(value)
+		if err != nil {
+			return nil, err
+		}
+		return []string{flagValue}, nil
+	}
+
+	if !repeatable {
+		return nil, &motmedelErrors.CauseError{Message: "An array is only accepted for a repeatable flag."}
+	}
+
+	flagValues := make([]string, 0, len(elements))
+	for _, element := range elements {
+		flagValue, err := configScalarString(element)
+		if err != nil {
+			return nil, err
+		}
+		flagValues = append(flagValues, flagValue)
+	}
+	return flagValues, nil
+}
+
+func configScalarString(value any) (string, error) {
+	switch typedValue := value.(type) {
+	case string:
+		return typedValue, nil
+	case bool:
+		return strconv.FormatBool(typedValue), nil
+	case int64:
+		return strconv.FormatInt(typedValue, 10), nil
+	case float64:
+		return strconv.FormatFloat(typedValue, 'g', -1, 64), nil
+	case json.Number:
+		return typedValue.String(), nil
+	default:
+		return "", &motmedelErrors.CauseError{Message: "The value is neither a string, a number, nor a boolean."}
+	}
+}
@@ -0,0 +1,266 @@
+package cli_test
+
+import (
+	"flag"
+	letsencryptUtilsCli "github.com/altshiftab/letsencrypt_utils/internal/cli"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// testFlags are the flags of the flag set of the configuration tests.
+type testFlags struct {
+	name    *string
+	count   *int
+	ratio   *float64
+	enabled *bool
+	domains *letsencryptUtilsCli.StringSliceFlag
+}
+
+func newFlagSet() (*flag.FlagSet, *testFlags) {
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+
+	flags := &testFlags{
+		name:    flagSet.String("name", "default", ""),
+		count:   flagSet.Int("count", 1, ""),
+		ratio:   flagSet.Float64("ratio", 0.5, ""),
+		enabled: flagSet.Bool("enabled", false, ""),
+		domains: &letsencryptUtilsCli.StringSliceFlag{},
+	}
+	flagSet.Var(flags.domains, "domain", "")
+
+	return flagSet, flags
+}
+
+func writeConfig(t *testing.T, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func TestParseFlagsToml(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		want    string
+		domains []string
+	}{
+		{name: "basic string", content: `name = "value"`, want: "value"},
+		{name: "literal string", content: `name = 'C:\path'`, want: `C:\path`},
+		{name: "escapes", content: `name = "a\"b\\c\td\u00e9"`, want: "a\"b\\c\td\u00e9"},
+		{name: "hash in string", content: `name = "a # b"`, want: "a # b"},
+		{name: "comment after value", content: "name = \"value\" # comment", want: "value"},
+		{name: "comment lines", content: "# comment\n\n  # another\nname = \"value\"\n", want: "value"},
+		{name: "quoted key", content: `"name" = "value"`, want: "value"},
+		{name: "crlf", content: "name = \"value\"\r\n", want: "value"},
+		{
+			name:    "array for repeatable flag",
+			content: "domain = [\n  \"example.org\", # first\n  'www.example.org',\n]",
+			want:    "default",
+			domains: []string{"example.org", "www.example.org"},
+		},
+		{name: "empty array", content: "domain = []", want: "default"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			flagSet, flags := newFlagSet()
+			path := writeConfig(t, "config.toml", testCase.content)
+
+			err := letsencryptUtilsCli.ParseFlags(flagSet, letsencryptUtilsCli.ConfigFlagName, []string{"-config", path})
+			if err != nil {
+				t.Fatalf("ParseFlags: %v", err)
+			}
+			if *flags.name != testCase.want {
+				t.Errorf("name = %q, want %q", *flags.name, testCase.want)
+			}
+			if !slices.Equal(*flags.domains, testCase.domains) {
+				t.Errorf("domains = %q, want %q", *flags.domains, testCase.domains)
+			}
+		})
+	}
+}
+
+func TestParseFlagsTomlScalars(t *testing.T) {
+	flagSet, flags := newFlagSet()
+	path := writeConfig(t, "config.toml", "count = 1_000\nratio = 2.5\nenabled = true\n")
+
+	err := letsencryptUtilsCli.ParseFlags(flagSet, letsencryptUtilsCli.ConfigFlagName, []string{"-config", path})
+	if err != nil {
+		t.Fatalf("ParseFlags: %v", err)
+	}
+	if *flags.count != 1000 {
+		t.Errorf("count = %d, want 1000", *flags.count)
+	}
+	if *flags.ratio != 2.5 {
+		t.Errorf("ratio = %v, want 2.5", *flags.ratio)
+	}
+	if !*flags.enabled {
+		t.Error("enabled = false, want true")
+	}
+}
+
+func TestParseFlagsTomlInvalid(t *testing.T) {
+	testCases := map[string]string{
+		"table":                     "[section]\nname = \"value\"",
+		"missing equals sign":       `name "value"`,
+		"missing value":             "name =",
+		"unterminated string":       `name = "value`,
+		"unterminated array":        `domain = ["example.org"`,
+		"multi-line string":         `name = """value"""`,
+		"invalid escape":            `name = "\q"`,
+		"duplicate key":             "name = \"a\"\nname = \"b\"",
+		"trailing content":          `name = "a" "b"`,
+		"bare word":                 "name = value",
+		"array for scalar flag":     `name = ["a", "b"]`,
+		"unknown flag":              `unknown = "value"`,
+		"invalid flag value":        `count = "many"`,
+		"configuration file in use": `config = "other.toml"`,
+	}
+
+	for name, content := range testCases {
+		t.Run(name, func(t *testing.T) {
+			flagSet, _ := newFlagSet()
+			path := writeConfig(t, "config.toml", content)
+
+			err := letsencryptUtilsCli.ParseFlags(flagSet, letsencryptUtilsCli.ConfigFlagName, []string{"-config", path})
+			if err == nil {
+				t.Error("ParseFlags succeeded")
+			}
+		})
+	}
+}
+
+func TestParseFlagsJson(t *testing.T) {
+	flagSet, flags := newFlagSet()
+	path := writeConfig(t, "config.json", `{"name": "value", "count": 3, "domain": ["example.org"]}`)
+
+	err := letsencryptUtilsCli.ParseFlags(flagSet, letsencryptUtilsCli.ConfigFlagName, []string{"-config", path})
+	if err != nil {
+		t.Fatalf("ParseFlags: %v", err)
+	}
+	if *flags.name != "value" || *flags.count != 3 || !slices.Equal(*flags.domains, []string{"example.org"}) {
+		t.Errorf("name = %q, count = %d, domains = %q", *flags.name, *flags.count, *flags.domains)
+	}
+}
+
+func TestParseFlagsPrecedence(t *testing.T) {
+	path := writeConfig(t, "config.toml", "name = \"file\"\ncount = 3\nratio = 4.5\n")
+
+	testCases := []struct {
+		name        string
+		arguments   []string
+		environment map[string]string
+		wantName    string
+		wantCount   int
+		wantRatio   float64
+	}{
+		{name: "file over default", wantName: "file", wantCount: 3, wantRatio: 4.5},
+		{
+			name:        "environment over file",
+			environment: map[string]string{"LEU_NAME": "environment"},
+			wantName:    "environment",
+			wantCount:   3,
+			wantRatio:   4.5,
+		},
+		{
+			name:        "command line over environment",
+			arguments:   []string{"-name", "command-line"},
+			environment: map[string]string{"LEU_NAME": "environment", "LEU_COUNT": "5"},
+			wantName:    "command-line",
+			wantCount:   5,
+			wantRatio:   4.5,
+		},
+		{
+			name:      "command line over file",
+			arguments: []string{"-count", "7"},
+			wantName:  "file",
+			wantCount: 7,
+			wantRatio: 4.5,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			for key, value := range testCase.environment {
+				t.Setenv(key, value)
+			}
+
+			flagSet, flags := newFlagSet()
+			arguments := append([]string{"-config", path}, testCase.arguments...)
+			if err := letsencryptUtilsCli.ParseFlags(flagSet, letsencryptUtilsCli.ConfigFlagName, arguments); err != nil {
+				t.Fatalf("ParseFlags: %v", err)
+			}
+
+			if *flags.name != testCase.wantName {
+				t.Errorf("name = %q, want %q", *flags.name, testCase.wantName)
+			}
+			if *flags.count != testCase.wantCount {
+				t.Errorf("count = %d, want %d", *flags.count, testCase.wantCount)
+			}
+			if *flags.ratio != testCase.wantRatio {
+				t.Errorf("ratio = %v, want %v", *flags.ratio, testCase.wantRatio)
+			}
+		})
+	}
+}
+
+func TestParseFlagsInvalidEnvironment(t *testing.T) {
+	t.Setenv("LEU_COUNT", "many")
+
+	flagSet, _ := newFlagSet()
+	if err := letsencryptUtilsCli.ParseFlags(flagSet, letsencryptUtilsCli.ConfigFlagName, nil); err == nil {
+		t.Error("ParseFlags succeeded")
+	}
+}
+
+func TestParseFlagsEnvironmentAlias(t *testing.T) {
+	path := writeConfig(t, "config.toml", "name = \"file\"\n")
+
+	testCases := []struct {
+		name        string
+		arguments   []string
+		environment map[string]string
+		want        string
+	}{
+		{name: "alias over file", environment: map[string]string{"TEST_NAME": "alias"}, want: "alias"},
+		{
+			name:        "environment variable over alias",
+			environment: map[string]string{"TEST_NAME": "alias", "LEU_NAME": "environment"},
+			want:        "environment",
+		},
+		{
+			name:        "command line over alias",
+			arguments:   []string{"-name", "command-line"},
+			environment: map[string]string{"TEST_NAME": "alias"},
+			want:        "command-line",
+		},
+		{name: "empty alias ignored", environment: map[string]string{"TEST_NAME": ""}, want: "file"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			for key, value := range testCase.environment {
+				t.Setenv(key, value)
+			}
+
+			flagSet, flags := newFlagSet()
+			letsencryptUtilsCli.AddFlagEnvironmentAlias(flagSet, "name", "TEST_NAME")
+			arguments := append([]string{"-config", path}, testCase.arguments...)
+			if err := letsencryptUtilsCli.ParseFlags(flagSet, letsencryptUtilsCli.ConfigFlagName, arguments); err != nil {
+				t.Fatalf("ParseFlags: %v", err)
+			}
+
+			if *flags.name != testCase.want {
+				t.Errorf("name = %q, want %q", *flags.name, testCase.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"flag"
+	letsencryptUtilsTypes "github.com/altshiftab/letsencrypt_utils/pkg/types"
+	"time"
+)
+
+type CredentialsConfig struct {
+	AccountName  string
+	AccountEmail string
+	Passphrase   *PassphraseConfig
+}
+
+// AddCredentialsFlags registers the -account-name, -account-email, -passphrase-file, and -lock-timeout flags, which
+// control how the account credentials file is loaded.
+func AddCredentialsFlags(flagSet *flag.FlagSet) *CredentialsConfig {
+	credentialsConfig := &CredentialsConfig{}
+	flagSet.StringVar(
+		&credentialsConfig.AccountName,
+		"account-name",
+		"",
+		"The name of the account to use, if the account credentials file holds several accounts.",
+	)
+	flagSet.StringVar(
+		&credentialsConfig.AccountEmail,
+		"account-email",
+		"",
+		"The email address of the account to use, if the account credentials file holds several accounts. Should "+
+			"several accounts share it, -account-name must also be provided.",
+	)
+	credentialsConfig.Passphrase = AddPassphraseFlags(flagSet)
+	AddLockFlags(flagSet)
+	return credentialsConfig
+}
+
+// lockTimeoutFlag is a flag value that sets the lock timeout of the account credentials files when parsed.
+type lockTimeoutFlag struct{}
+
+func (lockTimeoutFlag) String() string {
+	return letsencryptUtilsTypes.LockTimeout().String()
+}
+
+func (lockTimeoutFlag) Set(value string) error {
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	letsencryptUtilsTypes.SetLockTimeout(timeout)
+	return nil
+}
+
+// AddLockFlags registers the -lock-timeout flag, which sets the maximum duration to wait for the lock of the account
+// credentials file while another process reads or writes it.
+func AddLockFlags(flagSet *flag.FlagSet) {
+	flagSet.Var(
+		lockTimeoutFlag{},
+		"lock-timeout",
+		"The maximum duration to wait for the lock of the account credentials file, held by another process.",
+	)
+}
+
+// Load loads the selected account from the account credentials file at the path, decrypting the file with the
+// passphrase if it is encrypted.
+func (credentialsConfig *CredentialsConfig) Load(path string) (*letsencryptUtilsTypes.AccountCredentials, error) {
+	passphrase, err := credentialsConfig.Passphrase.Passphrase()
+	if err != nil {
+		return nil, err
+	}
+	return letsencryptUtilsTypes.LoadSelectedAccountCredentials(path, passphrase, credentialsConfig.Selector())
+}
+
+// Selector returns the selector of the account to use, from the -account-name and -account-email flags.
+func (credentialsConfig *CredentialsConfig) Selector() letsencryptUtilsTypes.AccountSelector {
+	return letsencryptUtilsTypes.AccountSelector{
+		Name:  credentialsConfig.AccountName,
+		Email: credentialsConfig.AccountEmail,
+	}
+}
+
+// AddBackupFlags registers the -backup-count flag, which sets the number of backups kept of the account credentials
+// file when it is rewritten.
+func AddBackupFlags(flagSet *flag.FlagSet) *int {
+	var backupCount int
+	flagSet.IntVar(
+		&backupCount,
+		"backup-count",
+		letsencryptUtilsTypes.DefaultBackupCount,
+		"The number of backups kept of the account credentials file when it is rewritten, as <path>.1 (the most "+
+			"recent) through <path>.<count>, or 0 to keep none.",
+	)
+	return &backupCount
+}
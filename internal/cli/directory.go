@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsDirectory "github.com/altshiftab/letsencrypt_utils/pkg/directory"
+	letsencryptUtilsTypes "github.com/altshiftab/letsencrypt_utils/pkg/types"
+	"log/slog"
+	"strings"
+)
+
+type DirectoryConfig struct {
+	Staging bool
+	// Ca is the name of a directory preset, e.g. "buypass".
+	Ca  string
+	Url string
+}
+
+// AddDirectoryFlags registers the -staging, -ca, and -directory-url flags. Commands using account credentials default
+// to the directory stored in them; see AccountDirectoryUrl.
+func AddDirectoryFlags(flagSet *flag.FlagSet) *DirectoryConfig {
+	directoryConfig := &DirectoryConfig{}
+	flagSet.BoolVar(&directoryConfig.Staging, "staging", false, "Whether to use the staging environment.")
+	flagSet.StringVar(
+		&directoryConfig.Ca,
+		"ca",
+		"",
+		"The name of a well-known ACME directory to use instead of Let's Encrypt ("+
+			strings.Join(letsencryptUtilsDirectory.PresetNames(), ", ")+"). Cannot be combined with -staging.",
+	)
+	flagSet.StringVar(
+		&directoryConfig.Url,
+		"directory-url",
+		"",
+		"The URL of an ACME directory to use instead of Let's Encrypt. Overrides -ca and -staging.",
+	)
+	return directoryConfig
+}
+
+// DirectoryUrl returns the selected directory URL. A custom directory URL takes precedence over -ca and -staging,
+// which are then ignored with a warning.
+func (directoryConfig *DirectoryConfig) DirectoryUrl(logger *slog.Logger) (string, error) {
+	if directoryConfig.Url == "" {
+		if directoryConfig.Ca == "" {
+			return letsencryptUtilsDirectory.Url(directoryConfig.Staging), nil
+		}
+
+		// The presets name their staging directories, so -staging would be ambiguous.
+		if directoryConfig.Staging {
+			return "", &motmedelErrors.InputError{
+				Message: "The -staging flag cannot be combined with -ca; select a staging preset instead.",
+				Cause:   ErrInvalidInput,
+				Input:   directoryConfig.Ca,
+			}
+		}
+
+		preset, err := letsencryptUtilsDirectory.LookupPreset(directoryConfig.Ca)
+		if err != nil {
+			return "", &motmedelErrors.InputError{
+				Message: "The -ca flag does not name a known CA.",
+				Cause:   errors.Join(ErrInvalidInput, err),
+				Input:   directoryConfig.Ca,
+			}
+		}
+		return preset.Url, nil
+	}
+
+	if (directoryConfig.Staging || directoryConfig.Ca != "") && logger != nil {
+		logger.Warn("The -ca and -staging flags are ignored as -directory-url is set.")
+	}
+
+	if err := letsencryptUtilsDirectory.ValidateUrl(directoryConfig.Url); err != nil {
+		return "", &motmedelErrors.InputError{
+			Message: "The -directory-url flag is not a valid directory URL.",
+			Cause:   errors.Join(ErrInvalidInput, err),
+			Input:   directoryConfig.Url,
+		}
+	}
+
+	return directoryConfig.Url, nil
+}
+
+// AccountDirectoryUrl returns the directory URL to be used with the account. Unless a directory flag is set,
+// it is the directory URL stored in the account credentials, if any. A selected directory URL that contradicts the
+// stored one is used, but warned about, as the account is unlikely to exist in another directory.
+func (directoryConfig *DirectoryConfig) AccountDirectoryUrl(
+	accountCredentials *letsencryptUtilsTypes.AccountCredentials,
+	logger *slog.Logger,
+) (string, error) {
+	var storedDirectoryUrl string
+	if accountCredentials != nil {
+		storedDirectoryUrl = accountCredentials.DirectoryUrl
+	}
+
+	if storedDirectoryUrl != "" && !directoryConfig.Staging && directoryConfig.Ca == "" && directoryConfig.Url == "" {
+		if err := letsencryptUtilsDirectory.ValidateUrl(storedDirectoryUrl); err != nil {
+			return "", &motmedelErrors.InputError{
+				Message: "The directory URL stored in the account credentials is invalid.",
+				Cause:   err,
+				Input:   storedDirectoryUrl,
+			}
+		}
+		return storedDirectoryUrl, nil
+	}
+
+	directoryUrl, err := directoryConfig.DirectoryUrl(logger)
+	if err != nil {
+		return "", err
+	}
+
+	if storedDirectoryUrl != "" && directoryUrl != storedDirectoryUrl && logger != nil {
+		logger.Warn(
+			"The selected directory is not the one the account was registered with; the account most likely does "+
+				"not exist there, so staging and production accounts may have been mixed up.",
+			slog.String("directory_url", directoryUrl),
+			slog.String("environment", letsencryptUtilsDirectory.Environment(directoryUrl)),
+			slog.String("account_directory_url", storedDirectoryUrl),
+			slog.String("account_environment", accountCredentials.Environment),
+		)
+	}
+
+	return directoryUrl, nil
+}
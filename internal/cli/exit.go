@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	motmedelLog "github.com/Motmedel/utils_go/pkg/log"
+	letsencryptUtilsAccount "github.com/altshiftab/letsencrypt_utils/pkg/account"
+	letsencryptUtilsCertificate "github.com/altshiftab/letsencrypt_utils/pkg/certificate"
+	letsencryptUtilsDirectory "github.com/altshiftab/letsencrypt_utils/pkg/directory"
+	letsencryptUtilsOrder "github.com/altshiftab/letsencrypt_utils/pkg/order"
+	letsencryptUtilsRetry "github.com/altshiftab/letsencrypt_utils/pkg/retry"
+	"golang.org/x/crypto/acme"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/url"
+)
+
+// Exit codes of the commands, so that scripts can react differently to different kinds of failures:
+//
+//	1    an unclassified failure
+//	2    invalid input, e.g. missing or conflicting flags
+//	3    a network failure or an error reported by the ACME server
+//	4    a filesystem failure, e.g. an unreadable or unwritable file
+//	5    the ACME server rate-limited a request
+//	6    the certificate is revoked, as reported by check_ocsp
+//	7    the command was interrupted by SIGINT or SIGTERM
+//	10+  specific failures, corresponding to the sentinel errors of the packages
+//
+// The specific failures take precedence over the general kinds, but an interruption takes precedence over both.
+const (
+	ExitCodeFailure             = 1
+	ExitCodeInvalidInput        = 2
+	ExitCodeNetwork             = 3
+	ExitCodeFilesystem          = 4
+	ExitCodeRateLimited         = 5
+	ExitCodeRevoked             = 6
+	ExitCodeInterrupted         = 7
+	ExitCodeEmptyEmail          = 10
+	ExitCodeInvalidEmail        = 11
+	ExitCodeAccountExists       = 12
+	ExitCodeNoAccount           = 13
+	ExitCodeCredentialsExist    = 14
+	ExitCodeAuthorizationFailed = 15
+	ExitCodeClockSkew           = 16
+	ExitCodeKeyMismatch         = 17
+)
+
+// ErrInvalidInput marks the errors of flags and other input that is invalid as given, e.g. an unsupported value, so
+// that they are reported with ExitCodeInvalidInput.
+var ErrInvalidInput = errors.New("the input is invalid")
+
+// ExitCode returns the exit code corresponding to the error. A nil error is taken to mean that the command was invoked
+// incorrectly, as the commands report usage errors without an underlying error.
+func ExitCode(err error) int {
+	var rateLimitError *letsencryptUtilsRetry.RateLimitError
+	var acmeError *acme.Error
+	var authorizationError *acme.AuthorizationError
+	var orderError *acme.OrderError
+	var urlError *url.Error
+	var netError net.Error
+	var pathError *fs.PathError
+
+	switch {
+	case err == nil:
+		return ExitCodeInvalidInput
+	// Only the signal context is cancelled, and failures that follow from it are reported as the interruption.
+	case errors.Is(err, context.Canceled):
+		return ExitCodeInterrupted
+	case errors.Is(err, letsencryptUtilsAccount.ErrEmptyEmail):
+		return ExitCodeEmptyEmail
+	case errors.Is(err, letsencryptUtilsAccount.ErrInvalidEmail):
+		return ExitCodeInvalidEmail
+	case errors.Is(err, letsencryptUtilsAccount.ErrAccountExists):
+		return ExitCodeAccountExists
+	case errors.Is(err, letsencryptUtilsAccount.ErrNoAccount):
+		return ExitCodeNoAccount
+	case errors.Is(err, letsencryptUtilsAccount.ErrCredentialsExist):
+		return ExitCodeCredentialsExist
+	case errors.Is(err, letsencryptUtilsOrder.ErrAuthorizationFailed):
+		return ExitCodeAuthorizationFailed
+	case errors.Is(err, letsencryptUtilsDirectory.ErrClockSkew):
+		return ExitCodeClockSkew
+	case errors.Is(err, letsencryptUtilsCertificate.ErrKeyMismatch):
+		return ExitCodeKeyMismatch
+	case errors.Is(err, ErrInvalidInput):
+		return ExitCodeInvalidInput
+	case errors.Is(err, letsencryptUtilsDirectory.ErrUnexpectedStatus):
+		return ExitCodeNetwork
+	case errors.As(err, &rateLimitError):
+		return ExitCodeRateLimited
+	case errors.As(err, &acmeError):
+		if _, ok := acme.RateLimit(acmeError); ok {
+			return ExitCodeRateLimited
+		}
+		return ExitCodeNetwork
+	case errors.As(err, &authorizationError), errors.As(err, &orderError):
+		return ExitCodeNetwork
+	case errors.As(err, &urlError), errors.As(err, &netError):
+		return ExitCodeNetwork
+	case errors.As(err, &pathError):
+		return ExitCodeFilesystem
+	default:
+		return ExitCodeFailure
+	}
+}
+
+// LogFatalWithExitingMessage logs the message and the error, and exits with the exit code corresponding to the error.
+func LogFatalWithExitingMessage(message string, err error, logger *slog.Logger) {
+	motmedelLog.LogFatalWithExitCode(message+" Exiting.", err, logger, ExitCode(err))
+}
@@ -0,0 +1,110 @@
+package cli_test
+
+import (
+	"context"
+	"errors"
+	letsencryptUtilsCli "github.com/altshiftab/letsencrypt_utils/internal/cli"
+	letsencryptUtilsAccount "github.com/altshiftab/letsencrypt_utils/pkg/account"
+	letsencryptUtilsAcmetest "github.com/altshiftab/letsencrypt_utils/pkg/acmetest"
+	letsencryptUtilsRetry "github.com/altshiftab/letsencrypt_utils/pkg/retry"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestExitCodeRateLimited(t *testing.T) {
+	server, err := letsencryptUtilsAcmetest.NewServer(
+		letsencryptUtilsAcmetest.WithFaultInjector(func(request *http.Request) *letsencryptUtilsAcmetest.Fault {
+			if request.URL.Path != "/new-account" {
+				return nil
+			}
+			return &letsencryptUtilsAcmetest.Fault{
+				StatusCode:  http.StatusTooManyRequests,
+				ProblemType: "rateLimited",
+				Detail:      "Too many new accounts.",
+				RetryAfter:  time.Hour,
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	t.Cleanup(server.Close)
+
+	// The delay exceeds what is waited out, so the rate limit is reported at once rather than after the context ends.
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, err = letsencryptUtilsAccount.RegisterAccount(
+		ctx,
+		"user@example.org",
+		letsencryptUtilsAccount.WithDirectoryUrl(server.DirectoryUrl()),
+	)
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("the rate limit was reported after %v", elapsed)
+	}
+
+	var rateLimitError *letsencryptUtilsRetry.RateLimitError
+	if !errors.As(err, &rateLimitError) {
+		t.Fatalf("RegisterAccount error = %v, want a *RateLimitError", err)
+	}
+	if wait := time.Until(rateLimitError.RetryAfter); wait < 59*time.Minute || wait > time.Hour {
+		t.Errorf("RetryAfter is in %v, want in an hour", wait)
+	}
+	if exitCode := letsencryptUtilsCli.ExitCode(err); exitCode != letsencryptUtilsCli.ExitCodeRateLimited {
+		t.Errorf("ExitCode = %d, want %d", exitCode, letsencryptUtilsCli.ExitCodeRateLimited)
+	}
+}
+
+func TestExitCodeInvalidInput(t *testing.T) {
+	testCases := map[string]func() error{
+		"unknown ca": func() error {
+			_, err := (&letsencryptUtilsCli.DirectoryConfig{Ca: "nosuch"}).DirectoryUrl(nil)
+			return err
+		},
+		"staging with ca": func() error {
+			_, err := (&letsencryptUtilsCli.DirectoryConfig{Ca: "buypass", Staging: true}).DirectoryUrl(nil)
+			return err
+		},
+		"invalid directory url": func() error {
+			_, err := (&letsencryptUtilsCli.DirectoryConfig{Url: "http://example.org/directory"}).DirectoryUrl(nil)
+			return err
+		},
+		"unparsable directory url": func() error {
+			_, err := (&letsencryptUtilsCli.DirectoryConfig{Url: "https://[::1"}).DirectoryUrl(nil)
+			return err
+		},
+		"unsupported format": func() error {
+			return (&letsencryptUtilsCli.CertificateOutputConfig{Format: "xml"}).Validate()
+		},
+		"der without cert-out": func() error {
+			return (&letsencryptUtilsCli.CertificateOutputConfig{Format: letsencryptUtilsCli.FormatDer}).Validate()
+		},
+		"output directory with cert-out": func() error {
+			outputConfig := &letsencryptUtilsCli.CertificateOutputConfig{CertOutPath: "cert.pem"}
+			_, err := outputConfig.InDirectory(t.TempDir(), []string{"example.org"})
+			return err
+		},
+		"unsupported challenge type": func() error {
+			_, err := (&letsencryptUtilsCli.OrderConfig{Challenges: "http-02"}).ChallengeTypes()
+			return err
+		},
+		"repeated challenge type": func() error {
+			_, err := (&letsencryptUtilsCli.OrderConfig{Challenges: "http-01,http-01"}).ChallengeTypes()
+			return err
+		},
+	}
+
+	for name, validate := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := validate()
+			if err == nil {
+				t.Fatal("the validation succeeded")
+			}
+			if exitCode := letsencryptUtilsCli.ExitCode(err); exitCode != letsencryptUtilsCli.ExitCodeInvalidInput {
+				t.Errorf("ExitCode = %d, want %d", exitCode, letsencryptUtilsCli.ExitCodeInvalidInput)
+			}
+		})
+	}
+}
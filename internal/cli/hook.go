@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+const (
+	HookDomainsEnvironmentVariable  = "LETSENCRYPT_DOMAINS"
+	HookCertPathEnvironmentVariable = "LETSENCRYPT_CERT_PATH"
+	HookKeyPathEnvironmentVariable  = "LETSENCRYPT_KEY_PATH"
+)
+
+type HookConfig struct {
+	PreHook  string
+	PostHook string
+}
+
+// AddHookFlags registers the -pre-hook and -post-hook flags.
+func AddHookFlags(flagSet *flag.FlagSet) *HookConfig {
+	hookConfig := &HookConfig{}
+	flagSet.StringVar(
+		&hookConfig.PreHook,
+		"pre-hook",
+		"",
+		"A shell command to be run before validation, e.g. to open a firewall port.",
+	)
+	flagSet.StringVar(
+		&hookConfig.PostHook,
+		"post-hook",
+		"",
+		"A shell command to be run after the certificate has been written, e.g. to reload a web server.",
+	)
+	return hookConfig
+}
+
+// HookEnvironment is the context passed to hooks via environment variables.
+type HookEnvironment struct {
+	Domains  []string
+	CertPath string
+	KeyPath  string
+}
+
+// RunHook runs the command with the system shell, passing the context in environment variables. A failure, such as a
+// non-zero exit status, is returned with the captured stderr of the command as input.
+func RunHook(ctx context.Context, command string, environment *HookEnvironment) error {
+	if command == "" {
+		return nil
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+
+	cmd.Env = os.Environ()
+	if environment != nil {
+		cmd.Env = append(
+			cmd.Env,
+			HookDomainsEnvironmentVariable+"="+strings.Join(environment.Domains, " "),
+			HookCertPathEnvironmentVariable+"="+environment.CertPath,
+			HookKeyPathEnvironmentVariable+"="+environment.KeyPath,
+		)
+	}
+
+	// The standard output of the hook is kept off the standard output of the command.
+	var stderr bytes.Buffer
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return &motmedelErrors.InputError{
+			Message: "The hook failed.",
+			Cause:   err,
+			Input:   []any{command, strings.TrimSpace(stderr.String())},
+		}
+	}
+
+	return nil
+}
+
+// RunPreHook runs the pre-hook, if any.
+func (hookConfig *HookConfig) RunPreHook(ctx context.Context, environment *HookEnvironment) error {
+	return RunHook(ctx, hookConfig.PreHook, environment)
+}
+
+// RunPostHook runs the post-hook, if any.
+func (hookConfig *HookConfig) RunPostHook(ctx context.Context, environment *HookEnvironment) error {
+	return RunHook(ctx, hookConfig.PostHook, environment)
+}
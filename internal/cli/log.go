@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"flag"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsRedact "github.com/altshiftab/letsencrypt_utils/pkg/redact"
+	"log/slog"
+	"os"
+)
+
+const (
+	LogFormatText = "text"
+	LogFormatJson = "json"
+)
+
+type LogConfig struct {
+	Format   string
+	Level    string
+	NoRedact bool
+}
+
+// AddLogFlags registers the -log-format, -log-level, and -no-redact flags.
+func AddLogFlags(flagSet *flag.FlagSet) *LogConfig {
+	logConfig := &LogConfig{}
+	flagSet.StringVar(&logConfig.Format, "log-format", LogFormatText, "The log output format (text or json).")
+	flagSet.StringVar(&logConfig.Level, "log-level", "info", "The minimum log level (debug, info, warn, or error).")
+	flagSet.BoolVar(
+		&logConfig.NoRedact,
+		"no-redact",
+		false,
+		"Whether to log email addresses in full rather than masked, for debugging.",
+	)
+	return logConfig
+}
+
+// Logger creates a logger writing to standard error with the configured format and level, and makes it the default
+// logger. Email addresses in logged values are masked unless -no-redact is set.
+func (logConfig *LogConfig) Logger() (*slog.Logger, error) {
+	letsencryptUtilsRedact.SetEnabled(!logConfig.NoRedact)
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(logConfig.Level)); err != nil {
+		return nil, &motmedelErrors.InputError{Message: "The log level is invalid.", Cause: err, Input: logConfig.Level}
+	}
+
+	handlerOptions := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch logConfig.Format {
+	case LogFormatText, "":
+		handler = slog.NewTextHandler(os.Stderr, handlerOptions)
+	case LogFormatJson:
+		handler = slog.NewJSONHandler(os.Stderr, handlerOptions)
+	default:
+		return nil, &motmedelErrors.InputError{Message: "The log format is unsupported.", Input: logConfig.Format}
+	}
+
+	logger := slog.New(handler)
+	slog.SetDefault(logger)
+
+	return logger, nil
+}
@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsHttpclient "github.com/altshiftab/letsencrypt_utils/pkg/httpclient"
+	"golang.org/x/net/http/httpproxy"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// NetworkConfig holds the settings of the connection to the ACME server.
+type NetworkConfig struct {
+	Proxy              string
+	CaBundlePath       string
+	InsecureSkipVerify bool
+	DebugAcme          bool
+}
+
+// AddNetworkFlags registers the -proxy, -ca-bundle, -insecure-skip-verify, and -debug-acme flags.
+func AddNetworkFlags(flagSet *flag.FlagSet) *NetworkConfig {
+	networkConfig := &NetworkConfig{}
+	flagSet.StringVar(
+		&networkConfig.Proxy,
+		"proxy",
+		"",
+		"The URL of a proxy through which the ACME server is to be reached, overriding the HTTP_PROXY and "+
+			"HTTPS_PROXY environment variables; NO_PROXY is still honored. The proxy also applies to the HTTP-01 "+
+			"self-checks, if enabled.",
+	)
+	flagSet.StringVar(
+		&networkConfig.CaBundlePath,
+		"ca-bundle",
+		"",
+		"The path of a PEM bundle of CA certificates trusted for the TLS connection to the ACME server, in addition "+
+			"to the system ones, e.g. for an internal ACME server. It does not affect the validation of the issued "+
+			"certificates.",
+	)
+	flagSet.BoolVar(
+		&networkConfig.InsecureSkipVerify,
+		"insecure-skip-verify",
+		false,
+		"Whether to skip the verification of the TLS certificate of the ACME server. INSECURE: only for quick local "+
+			"testing, never against a real CA.",
+	)
+	flagSet.BoolVar(
+		&networkConfig.DebugAcme,
+		"debug-acme",
+		false,
+		"Whether to log each request to the ACME server and its response, with the bodies, at the debug level. "+
+			"Signatures and keys are redacted, but the logs may still hold data about the account and its orders.",
+	)
+	return networkConfig
+}
+
+// proxy returns the proxy function: one using the -proxy URL, if set, and otherwise the one honoring the
+// environment variables.
+func (networkConfig *NetworkConfig) proxy() (func(*http.Request) (*url.URL, error), error) {
+	if networkConfig.Proxy == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	proxyUrl, err := url.Parse(networkConfig.Proxy)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when parsing the proxy URL.",
+			Cause:   err,
+			Input:   networkConfig.Proxy,
+		}
+	}
+	switch proxyUrl.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, &motmedelErrors.InputError{
+			Message: "The proxy URL scheme is unsupported; use http, https, or socks5.",
+			Input:   networkConfig.Proxy,
+		}
+	}
+	if proxyUrl.Host == "" {
+		return nil, &motmedelErrors.InputError{Message: "The proxy URL has no host.", Input: networkConfig.Proxy}
+	}
+
+	proxyConfig := &httpproxy.Config{
+		HTTPProxy:  networkConfig.Proxy,
+		HTTPSProxy: networkConfig.Proxy,
+		NoProxy:    os.Getenv("NO_PROXY"),
+	}
+	if proxyConfig.NoProxy == "" {
+		proxyConfig.NoProxy = os.Getenv("no_proxy")
+	}
+	proxyFunc := proxyConfig.ProxyFunc()
+
+	return func(request *http.Request) (*url.URL, error) {
+		return proxyFunc(request.URL)
+	}, nil
+}
+
+// rootCas returns the system CA certificates together with those of the CA bundle.
+func (networkConfig *NetworkConfig) rootCas() (*x509.CertPool, error) {
+	caBundleData, err := os.ReadFile(networkConfig.CaBundlePath)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when reading the CA bundle.",
+			Cause:   err,
+			Input:   networkConfig.CaBundlePath,
+		}
+	}
+
+	rootCas, err := x509.SystemCertPool()
+	if err != nil {
+		rootCas = x509.NewCertPool()
+	}
+	if !rootCas.AppendCertsFromPEM(caBundleData) {
+		return nil, &motmedelErrors.InputError{
+			Message: "The CA bundle contains no PEM-encoded certificates.",
+			Input:   networkConfig.CaBundlePath,
+		}
+	}
+
+	return rootCas, nil
+}
+
+// HttpClient returns the HTTP client with which the ACME server is to be reached. Its TLS settings are only meant
+// for the ACME server.
+func (networkConfig *NetworkConfig) HttpClient(logger *slog.Logger) (*http.Client, error) {
+	proxy, err := networkConfig.proxy()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+
+	if networkConfig.CaBundlePath != "" || networkConfig.InsecureSkipVerify {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+		if transport.TLSClientConfig != nil {
+			tlsConfig = transport.TLSClientConfig.Clone()
+		}
+
+		if networkConfig.CaBundlePath != "" {
+			rootCas, err := networkConfig.rootCas()
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.RootCAs = rootCas
+		}
+
+		if networkConfig.InsecureSkipVerify {
+			if logger != nil {
+				logger.Warn(
+					"The TLS certificate of the ACME server is not verified, so the connection can be intercepted. " +
+						"Never use -insecure-skip-verify against a real CA.",
+				)
+			}
+			tlsConfig.InsecureSkipVerify = true
+		}
+
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	if networkConfig.DebugAcme {
+		if logger == nil {
+			logger = slog.Default()
+		}
+		if !logger.Enabled(context.Background(), slog.LevelDebug) {
+			logger.Warn("The ACME requests are logged at the debug level, which -log-level must allow.")
+		}
+		return &http.Client{Transport: letsencryptUtilsHttpclient.NewLoggingTransport(transport, logger)}, nil
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
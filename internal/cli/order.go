@@ -0,0 +1,734 @@
+package cli
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"flag"
+	"fmt"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	motmedelLog "github.com/Motmedel/utils_go/pkg/log"
+	letsencryptUtilsCaa "github.com/altshiftab/letsencrypt_utils/pkg/caa"
+	letsencryptUtilsCertificate "github.com/altshiftab/letsencrypt_utils/pkg/certificate"
+	letsencryptUtilsDirectory "github.com/altshiftab/letsencrypt_utils/pkg/directory"
+	letsencryptUtilsKey "github.com/altshiftab/letsencrypt_utils/pkg/key"
+	letsencryptUtilsOrder "github.com/altshiftab/letsencrypt_utils/pkg/order"
+	letsencryptUtilsSolver "github.com/altshiftab/letsencrypt_utils/pkg/solver"
+	// The built-in DNS-01 providers register themselves with the solver package.
+	_ "github.com/altshiftab/letsencrypt_utils/pkg/solver/cloudflare"
+	letsencryptUtilsRfc2136 "github.com/altshiftab/letsencrypt_utils/pkg/solver/rfc2136"
+	_ "github.com/altshiftab/letsencrypt_utils/pkg/solver/route53"
+	"golang.org/x/crypto/acme"
+	"io/fs"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// OrderConfig holds the settings shared by the commands that order certificates.
+type OrderConfig struct {
+	Challenges             string
+	HttpPort               int
+	HttpSelfCheck          bool
+	HttpSelfCheckTimeout   time.Duration
+	Webroot                string
+	TlsAlpnPort            int
+	DnsProvider            string
+	DnsOptions             StringSliceFlag
+	DnsWait                time.Duration
+	DnsPropagationTimeout  time.Duration
+	DnsPropagationInterval time.Duration
+	DnsTtl                 time.Duration
+	DnsKeep                bool
+	Rfc2136Server          string
+	Rfc2136TsigKeyName     string
+	Rfc2136TsigAlgorithm   string
+	Rfc2136TsigSecret      string
+	Profile                string
+	CheckCaa               bool
+	StatePath              string
+	DeactivateAuthz        bool
+	MustStaple             bool
+	ReuseKey               bool
+	PollInterval           time.Duration
+	PollTimeout            time.Duration
+	// OrderUrl is the URL of an existing order to be continued. As it applies to a single order, it is registered by
+	// the commands that order one certificate rather than by AddOrderFlags.
+	OrderUrl string
+
+	Organization       string
+	OrganizationalUnit string
+	Country            string
+}
+
+// AddOrderFlags registers the flags for challenge solving and the certificate subject.
+func AddOrderFlags(flagSet *flag.FlagSet) *OrderConfig {
+	orderConfig := &OrderConfig{}
+
+	flagSet.StringVar(
+		&orderConfig.Challenges,
+		"challenge",
+		letsencryptUtilsSolver.ChallengeTypeHttp01,
+		"The challenge types to be solved (http-01, dns-01, or tls-alpn-01), comma-separated in order of preference, "+
+			"e.g. dns-01,http-01. Of the types offered for a domain, the first listed is solved. Wildcard domains are "+
+			"always validated via DNS-01, which must then be listed.",
+	)
+	flagSet.IntVar(
+		&orderConfig.HttpPort,
+		"http-port",
+		letsencryptUtilsSolver.DefaultHttp01Port,
+		"The port on which to serve HTTP-01 challenge responses.",
+	)
+	flagSet.BoolVar(
+		&orderConfig.HttpSelfCheck,
+		"http-self-check",
+		false,
+		"Whether to confirm that HTTP-01 challenge responses are reachable via the domains before validation.",
+	)
+	flagSet.DurationVar(
+		&orderConfig.HttpSelfCheckTimeout,
+		"http-self-check-timeout",
+		letsencryptUtilsSolver.DefaultSelfCheckTimeout,
+		"The maximum duration to retry the HTTP-01 self-check.",
+	)
+	flagSet.StringVar(
+		&orderConfig.Webroot,
+		"webroot",
+		"",
+		"The document root of an existing web server into which HTTP-01 challenge responses are written, "+
+			"instead of serving them with a standalone server.",
+	)
+	flagSet.IntVar(
+		&orderConfig.TlsAlpnPort,
+		"tls-alpn-port",
+		letsencryptUtilsSolver.DefaultTlsAlpn01Port,
+		"The port on which to serve TLS-ALPN-01 validation certificates.",
+	)
+	flagSet.StringVar(
+		&orderConfig.DnsProvider,
+		"dns-provider",
+		"manual",
+		fmt.Sprintf("The DNS-01 provider (%s).", strings.Join(letsencryptUtilsSolver.Providers(), ", ")),
+	)
+	flagSet.Var(
+		&orderConfig.DnsOptions,
+		"dns-opt",
+		"An option of the DNS-01 provider as key=value, e.g. api-token=... for cloudflare. May be repeated. The "+
+			"generic DNS-01 flags and the rfc2136 flags provide defaults for the options ttl, propagation-timeout, "+
+			"propagation-interval, wait, server, tsig-key, tsig-algorithm, and tsig-secret.",
+	)
+	flagSet.DurationVar(
+		&orderConfig.DnsWait,
+		"dns-wait",
+		0,
+		"A duration to wait after a DNS-01 record is to be created, instead of waiting for enter to be pressed.",
+	)
+	flagSet.DurationVar(
+		&orderConfig.DnsPropagationTimeout,
+		"dns-propagation-timeout",
+		letsencryptUtilsSolver.DefaultPropagationTimeout,
+		"The maximum duration to wait for a DNS-01 record to propagate to all authoritative nameservers.",
+	)
+	flagSet.DurationVar(
+		&orderConfig.DnsPropagationInterval,
+		"dns-propagation-interval",
+		letsencryptUtilsSolver.DefaultPropagationInterval,
+		"The interval at which the authoritative nameservers are polled for a DNS-01 record.",
+	)
+	flagSet.DurationVar(
+		&orderConfig.DnsTtl,
+		"dns-ttl",
+		time.Minute,
+		"The TTL of the DNS-01 records.",
+	)
+	flagSet.BoolVar(
+		&orderConfig.DnsKeep,
+		"dns-keep",
+		false,
+		"Whether to keep the DNS-01 records after validation rather than removing them, for debugging a failed "+
+			"validation.",
+	)
+
+	flagSet.StringVar(
+		&orderConfig.Rfc2136Server,
+		"rfc2136-server",
+		"",
+		"The nameserver accepting RFC 2136 dynamic updates, used with the rfc2136 DNS provider.",
+	)
+	flagSet.StringVar(
+		&orderConfig.Rfc2136TsigKeyName,
+		"rfc2136-tsig-key",
+		"",
+		"The name of the TSIG key with which the dynamic updates are signed.",
+	)
+	flagSet.StringVar(
+		&orderConfig.Rfc2136TsigAlgorithm,
+		"rfc2136-tsig-algorithm",
+		letsencryptUtilsRfc2136.DefaultTsigAlgorithm,
+		"The algorithm of the TSIG key.",
+	)
+	flagSet.StringVar(
+		&orderConfig.Rfc2136TsigSecret,
+		"rfc2136-tsig-secret",
+		"",
+		"The base64-encoded secret of the TSIG key. Takes precedence over the "+
+			letsencryptUtilsRfc2136.TsigSecretEnvironmentVariable+" environment variable.",
+	)
+
+	flagSet.StringVar(
+		&orderConfig.Profile,
+		"profile",
+		"",
+		"The certificate profile to be requested, which must be offered by the CA.",
+	)
+
+	flagSet.BoolVar(
+		&orderConfig.CheckCaa,
+		"check-caa",
+		false,
+		"Whether to check that the CAA records of the domains permit the CA to issue before ordering. The CA is "+
+			"identified by the CAA identities listed in its directory; the check is skipped if there are none.",
+	)
+
+	flagSet.StringVar(
+		&orderConfig.StatePath,
+		"state-file",
+		"",
+		"The path of a file in which the order progress is persisted, so that an interrupted order is resumed.",
+	)
+	flagSet.BoolVar(
+		&orderConfig.DeactivateAuthz,
+		"deactivate-authz",
+		false,
+		"Whether to deactivate the authorizations after issuance, so that the next order requires fresh validation.",
+	)
+
+	flagSet.DurationVar(
+		&orderConfig.PollInterval,
+		"poll-interval",
+		0,
+		"The interval at which authorizations and the order are polled. If zero, they are polled as suggested by "+
+			"the CA, and otherwise every second.",
+	)
+	flagSet.DurationVar(
+		&orderConfig.PollTimeout,
+		"poll-timeout",
+		0,
+		"The longest duration to wait for each authorization to become valid, and for the order to be issued. If "+
+			"zero, there is no limit.",
+	)
+
+	flagSet.BoolVar(
+		&orderConfig.MustStaple,
+		"must-staple",
+		false,
+		"Whether to request the OCSP Must-Staple extension, which requires servers using the certificate to staple "+
+			"OCSP responses. CAs that no longer provide OCSP, such as Let's Encrypt, reject such requests.",
+	)
+
+	flagSet.BoolVar(
+		&orderConfig.ReuseKey,
+		"reuse-key",
+		false,
+		"Whether to reuse the existing private key at the key output path for the new certificate, rather than "+
+			"generating a new one, so that the key pair is kept across renewals. The reused key keeps its type and "+
+			"size, whatever they are; a P-256 ECDSA key is generated if there is none.",
+	)
+
+	flagSet.StringVar(&orderConfig.Organization, "org", "", "The organization of the certificate subject.")
+	flagSet.StringVar(
+		&orderConfig.OrganizationalUnit,
+		"ou",
+		"",
+		"The organizational unit of the certificate subject.",
+	)
+	flagSet.StringVar(&orderConfig.Country, "country", "", "The country of the certificate subject.")
+
+	return orderConfig
+}
+
+// Subject returns the certificate subject described by the flags.
+func (orderConfig *OrderConfig) Subject() pkix.Name {
+	var subject pkix.Name
+	if orderConfig.Organization != "" {
+		subject.Organization = []string{orderConfig.Organization}
+	}
+	if orderConfig.OrganizationalUnit != "" {
+		subject.OrganizationalUnit = []string{orderConfig.OrganizationalUnit}
+	}
+	if orderConfig.Country != "" {
+		subject.Country = []string{orderConfig.Country}
+	}
+	return subject
+}
+
+// ChallengeTypes returns the selected challenge types in order of preference.
+func (orderConfig *OrderConfig) ChallengeTypes() ([]string, error) {
+	var challengeTypes []string
+	for _, challengeType := range strings.Split(orderConfig.Challenges, ",") {
+		challengeType = strings.ToLower(strings.TrimSpace(challengeType))
+		switch challengeType {
+		case letsencryptUtilsSolver.ChallengeTypeHttp01,
+			letsencryptUtilsSolver.ChallengeTypeDns01,
+			letsencryptUtilsSolver.ChallengeTypeTlsAlpn01:
+		default:
+			return nil, &motmedelErrors.InputError{
+				Message: "The challenge type is unsupported.",
+				Cause:   ErrInvalidInput,
+				Input:   challengeType,
+			}
+		}
+		if slices.Contains(challengeTypes, challengeType) {
+			return nil, &motmedelErrors.InputError{
+				Message: "The challenge type is selected more than once.",
+				Cause:   ErrInvalidInput,
+				Input:   challengeType,
+			}
+		}
+		challengeTypes = append(challengeTypes, challengeType)
+	}
+
+	return challengeTypes, nil
+}
+
+// Solvers creates a solver for each of the selected challenge types, keyed by type.
+func (orderConfig *OrderConfig) Solvers(client *acme.Client) (map[string]letsencryptUtilsSolver.Solver, error) {
+	challengeTypes, err := orderConfig.ChallengeTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	solvers := make(map[string]letsencryptUtilsSolver.Solver, len(challengeTypes))
+	for _, challengeType := range challengeTypes {
+		solver, err := orderConfig.solver(client, challengeType)
+		if err != nil {
+			return nil, err
+		}
+		solvers[challengeType] = solver
+	}
+
+	return solvers, nil
+}
+
+// solver creates the solver for the challenge type.
+func (orderConfig *OrderConfig) solver(
+	client *acme.Client,
+	challengeType string,
+) (letsencryptUtilsSolver.Solver, error) {
+	switch challengeType {
+	case letsencryptUtilsSolver.ChallengeTypeHttp01:
+		if orderConfig.Webroot != "" {
+			webrootSolver := letsencryptUtilsSolver.NewHttp01WebrootSolver(orderConfig.Webroot)
+			webrootSolver.SelfCheck = orderConfig.HttpSelfCheck
+			webrootSolver.SelfCheckTimeout = orderConfig.HttpSelfCheckTimeout
+			return webrootSolver, nil
+		}
+		http01Solver := letsencryptUtilsSolver.NewHttp01Solver(orderConfig.HttpPort)
+		http01Solver.SelfCheck = orderConfig.HttpSelfCheck
+		http01Solver.SelfCheckTimeout = orderConfig.HttpSelfCheckTimeout
+		return http01Solver, nil
+	case letsencryptUtilsSolver.ChallengeTypeTlsAlpn01:
+		return letsencryptUtilsSolver.NewTlsAlpn01Solver(client, orderConfig.TlsAlpnPort), nil
+	case letsencryptUtilsSolver.ChallengeTypeDns01:
+		dns01Solver, err := orderConfig.dns01Solver()
+		if err != nil {
+			return nil, err
+		}
+		if orderConfig.DnsKeep {
+			return letsencryptUtilsSolver.NewDns01KeepSolver(dns01Solver), nil
+		}
+		return dns01Solver, nil
+	default:
+		return nil, &motmedelErrors.InputError{
+			Message: "The challenge type is unsupported.",
+			Input:   challengeType,
+		}
+	}
+}
+
+// dns01Solver returns the solver of the configured DNS-01 provider, created by the factory that it registered.
+func (orderConfig *OrderConfig) dns01Solver() (letsencryptUtilsSolver.Solver, error) {
+	options, err := orderConfig.dnsProviderOptions()
+	if err != nil {
+		return nil, err
+	}
+	return letsencryptUtilsSolver.NewFromProvider(orderConfig.DnsProvider, options)
+}
+
+// dnsProviderOptions returns the options of the DNS-01 provider: those derived from the generic DNS-01 flags and the
+// rfc2136 flags, overridden by the ones set with -dns-opt.
+func (orderConfig *OrderConfig) dnsProviderOptions() (map[string]string, error) {
+	options := map[string]string{
+		letsencryptUtilsSolver.OptionTtl:                 orderConfig.DnsTtl.String(),
+		letsencryptUtilsSolver.OptionPropagationTimeout:  orderConfig.DnsPropagationTimeout.String(),
+		letsencryptUtilsSolver.OptionPropagationInterval: orderConfig.DnsPropagationInterval.String(),
+		letsencryptUtilsSolver.OptionWait:                orderConfig.DnsWait.String(),
+	}
+	rfc2136Options := map[string]string{
+		letsencryptUtilsRfc2136.OptionServer:        orderConfig.Rfc2136Server,
+		letsencryptUtilsRfc2136.OptionTsigKeyName:   orderConfig.Rfc2136TsigKeyName,
+		letsencryptUtilsRfc2136.OptionTsigAlgorithm: orderConfig.Rfc2136TsigAlgorithm,
+		letsencryptUtilsRfc2136.OptionTsigSecret:    orderConfig.Rfc2136TsigSecret,
+	}
+	for key, value := range rfc2136Options {
+		if value != "" {
+			options[key] = value
+		}
+	}
+
+	for _, option := range orderConfig.DnsOptions {
+		key, value, ok := strings.Cut(option, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, &motmedelErrors.InputError{
+				Message: "The DNS provider option is not of the form key=value.",
+				Input:   option,
+			}
+		}
+		options[key] = value
+	}
+
+	return options, nil
+}
+
+// Certificate is an obtained certificate chain and its private key, which is nil if the certificate was ordered for a
+// pre-generated CSR.
+type Certificate struct {
+	DerChain [][]byte
+	Key      crypto.Signer
+
+	ChainPem []byte
+	KeyPem   []byte
+}
+
+// ProfileFor returns the certificate profile to be requested for the domains: the selected profile or, if none is
+// selected and any of the entries is an IP address, the short-lived profile, which supports IP addresses.
+func (orderConfig *OrderConfig) ProfileFor(domains []string) string {
+	if orderConfig.Profile == "" && letsencryptUtilsOrder.HasIp(domains) {
+		return letsencryptUtilsOrder.ProfileShortLived
+	}
+	return orderConfig.Profile
+}
+
+// ValidateProfile checks that the CA offers the certificate profile.
+func ValidateProfile(ctx context.Context, client *acme.Client, profile string) error {
+	directory, err := letsencryptUtilsDirectory.Fetch(ctx, client.HTTPClient, client.DirectoryURL)
+	if err != nil {
+		return &motmedelErrors.CauseError{Message: "An error occurred when fetching the directory.", Cause: err}
+	}
+
+	if _, ok := directory.Meta.Profiles[profile]; !ok {
+		return &motmedelErrors.InputError{
+			Message: "The certificate profile is not offered by the CA.",
+			Input:   []any{profile, directory.ProfileNames()},
+		}
+	}
+
+	return nil
+}
+
+// Obtain generates a certificate key, builds a CSR for the domains, and orders a certificate for it. Internationalized
+// domains are converted to their ASCII-compatible encoding. With -reuse-key, the key at the key path, the path to
+// which the key of the certificate is to be written, is used instead of a generated one, if it exists. The reused key
+// pins the key type and size: a key of any type accepted for certificates is kept as it is.
+func (orderConfig *OrderConfig) Obtain(
+	ctx context.Context,
+	client *acme.Client,
+	domains []string,
+	keyPath string,
+) (*Certificate, error) {
+	if profile := orderConfig.ProfileFor(domains); profile != "" {
+		if err := ValidateProfile(ctx, client, profile); err != nil {
+			return nil, err
+		}
+	}
+
+	solvers, err := orderConfig.Solvers(client)
+	if err != nil {
+		return nil, err
+	}
+
+	return orderConfig.ObtainWithSolvers(ctx, client, domains, keyPath, solvers)
+}
+
+// ObtainWithSolvers is like Obtain, but uses the provided solvers, which may be shared between concurrent orders, and
+// does not validate the profile.
+func (orderConfig *OrderConfig) ObtainWithSolvers(
+	ctx context.Context,
+	client *acme.Client,
+	domains []string,
+	keyPath string,
+	solvers map[string]letsencryptUtilsSolver.Solver,
+) (*Certificate, error) {
+	// Internationalized domains are ordered in their ASCII-compatible encoding; the provided forms are left as they
+	// are for display.
+	domains, err := letsencryptUtilsOrder.ToAsciiDomains(domains)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := orderConfig.checkCaa(ctx, client, domains); err != nil {
+		return nil, err
+	}
+
+	// Produce a certificate key and a CSR.
+
+	certificateKey, err := orderConfig.reusedKey(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	if certificateKey == nil {
+		certificateKey, err = letsencryptUtilsKey.Generate(nil)
+		if err != nil {
+			return nil, &motmedelErrors.CauseError{
+				Message: "An error occurred when generating a certificate key.",
+				Cause:   err,
+			}
+		}
+	}
+
+	certificateKeyPemData, err := letsencryptUtilsKey.MarshalPem(certificateKey)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{
+			Message: "An error occurred when marshalling the certificate key data.",
+			Cause:   err,
+		}
+	}
+
+	csr, err := letsencryptUtilsOrder.BuildCSR(
+		certificateKey,
+		domains,
+		orderConfig.Subject(),
+		letsencryptUtilsOrder.WithMustStaple(orderConfig.MustStaple),
+	)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when building the CSR.", Cause: err}
+	}
+
+	// Order the certificate.
+
+	derChain, err := orderConfig.order(ctx, client, domains, csr, solvers)
+	if err != nil {
+		return nil, err
+	}
+
+	chainPemData := letsencryptUtilsOrder.EncodeChainPem(derChain)
+
+	// A certificate that does not match its key would only be noticed when deployed.
+	if err := letsencryptUtilsCertificate.VerifyKeyPair(chainPemData, certificateKeyPemData); err != nil {
+		return nil, &motmedelErrors.CauseError{
+			Message: "The issued certificate does not match the certificate key.",
+			Cause:   err,
+		}
+	}
+
+	if err := verifyIssued(chainPemData, domains, orderConfig.MustStaple); err != nil {
+		return nil, err
+	}
+
+	return &Certificate{
+		DerChain: derChain,
+		Key:      certificateKey,
+		ChainPem: chainPemData,
+		KeyPem:   certificateKeyPemData,
+	}, nil
+}
+
+// reusedKey loads the certificate key to be reused with -reuse-key, in PEM or DER form, from the key path. No key, and
+// no error, is returned if the key is not to be reused or does not exist yet. The key is only checked to be usable
+// for a certificate, not to be of the type that would otherwise be generated, as the type of the key on disk is the
+// one to be kept.
+func (orderConfig *OrderConfig) reusedKey(keyPath string) (crypto.Signer, error) {
+	if !orderConfig.ReuseKey || keyPath == "" {
+		return nil, nil
+	}
+
+	keyData, err := os.ReadFile(keyPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when reading the certificate key to be reused.",
+			Cause:   err,
+			Input:   keyPath,
+		}
+	}
+
+	certificateKey, err := letsencryptUtilsKey.Parse(keyData)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when parsing the certificate key to be reused.",
+			Cause:   err,
+			Input:   keyPath,
+		}
+	}
+	if err := letsencryptUtilsKey.CheckCertificateKey(certificateKey); err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "The certificate key to be reused is unsuitable.",
+			Cause:   err,
+			Input:   keyPath,
+		}
+	}
+
+	return certificateKey, nil
+}
+
+// ObtainForCsr orders a certificate for a pre-generated CSR, whose domains are the ones authorized. The private key
+// of the CSR is not known, so the returned certificate has none. The subject flags do not apply.
+func (orderConfig *OrderConfig) ObtainForCsr(
+	ctx context.Context,
+	client *acme.Client,
+	csr *x509.CertificateRequest,
+) (*Certificate, error) {
+	if csr == nil {
+		return nil, &motmedelErrors.CauseError{Message: "The CSR is nil."}
+	}
+
+	domains := letsencryptUtilsOrder.CSRDomains(csr)
+	if len(domains) == 0 {
+		return nil, &motmedelErrors.CauseError{Message: "The CSR contains no domains."}
+	}
+
+	mustStaple := slices.ContainsFunc(csr.Extensions, func(extension pkix.Extension) bool {
+		return extension.Id.Equal(letsencryptUtilsCertificate.TlsFeatureOid)
+	})
+	if orderConfig.MustStaple && !mustStaple {
+		return nil, &motmedelErrors.CauseError{
+			Message: "OCSP Must-Staple is requested, but the CSR lacks the extension.",
+		}
+	}
+
+	if profile := orderConfig.ProfileFor(domains); profile != "" {
+		if err := ValidateProfile(ctx, client, profile); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := orderConfig.checkCaa(ctx, client, domains); err != nil {
+		return nil, err
+	}
+
+	solvers, err := orderConfig.Solvers(client)
+	if err != nil {
+		return nil, err
+	}
+
+	derChain, err := orderConfig.order(ctx, client, domains, csr.Raw, solvers)
+	if err != nil {
+		return nil, err
+	}
+
+	chainPemData := letsencryptUtilsOrder.EncodeChainPem(derChain)
+
+	if err := letsencryptUtilsCertificate.VerifyPublicKey(chainPemData, csr.PublicKey); err != nil {
+		return nil, &motmedelErrors.CauseError{
+			Message: "The issued certificate does not match the public key of the CSR.",
+			Cause:   err,
+		}
+	}
+
+	if err := verifyIssued(chainPemData, domains, mustStaple); err != nil {
+		return nil, err
+	}
+
+	return &Certificate{DerChain: derChain, ChainPem: chainPemData}, nil
+}
+
+// checkCaa checks that the CAA records of the domains permit the CA to issue, if the check is enabled. The CA is
+// identified by the CAA identities advertised in its directory; the check is skipped, with a warning, if there are
+// none.
+func (orderConfig *OrderConfig) checkCaa(ctx context.Context, client *acme.Client, domains []string) error {
+	if !orderConfig.CheckCaa {
+		return nil
+	}
+
+	directory, err := letsencryptUtilsDirectory.Fetch(ctx, client.HTTPClient, client.DirectoryURL)
+	if err != nil {
+		return &motmedelErrors.CauseError{Message: "An error occurred when fetching the directory.", Cause: err}
+	}
+	caaIdentities := directory.Meta.CaaIdentities
+	if len(caaIdentities) == 0 {
+		motmedelLog.GetLoggerFromCtxWithDefault(ctx, nil).WarnContext(
+			ctx,
+			"The CAA check is skipped, as the directory does not list the CAA identities of the CA.",
+			slog.String("directory_url", client.DirectoryURL),
+		)
+		return nil
+	}
+
+	for _, domain := range domains {
+		if letsencryptUtilsOrder.IsIp(domain) {
+			continue
+		}
+		if err := letsencryptUtilsCaa.Check(ctx, domain, caaIdentities...); err != nil {
+			return &motmedelErrors.InputError{
+				Message: "The CAA check failed.",
+				Cause:   err,
+				Input:   domain,
+			}
+		}
+	}
+
+	return nil
+}
+
+// order orders a certificate for the DER-encoded CSR with the configured order options.
+func (orderConfig *OrderConfig) order(
+	ctx context.Context,
+	client *acme.Client,
+	domains []string,
+	csr []byte,
+	solvers map[string]letsencryptUtilsSolver.Solver,
+) ([][]byte, error) {
+	challengeTypes, err := orderConfig.ChallengeTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	options := []letsencryptUtilsOrder.Option{
+		letsencryptUtilsOrder.WithChallengePreference(challengeTypes...),
+		letsencryptUtilsOrder.WithProfile(orderConfig.ProfileFor(domains)),
+		letsencryptUtilsOrder.WithStateFile(orderConfig.StatePath),
+		letsencryptUtilsOrder.WithDeactivateAuthorizations(orderConfig.DeactivateAuthz),
+		letsencryptUtilsOrder.WithOrderUrl(orderConfig.OrderUrl),
+		letsencryptUtilsOrder.WithPollInterval(orderConfig.PollInterval),
+		letsencryptUtilsOrder.WithPollTimeout(orderConfig.PollTimeout),
+	}
+	for challengeType, solver := range solvers {
+		options = append(options, letsencryptUtilsOrder.WithSolver(challengeType, solver))
+	}
+
+	derChain, err := letsencryptUtilsOrder.Order(ctx, client, domains, csr, options...)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when ordering the certificate.", Cause: err}
+	}
+
+	return derChain, nil
+}
+
+// verifyIssued checks that the issued certificate covers the domains and, if requested, has the OCSP Must-Staple
+// extension.
+func verifyIssued(chainPemData []byte, domains []string, mustStaple bool) error {
+	if err := letsencryptUtilsCertificate.VerifyCertificateDomains(chainPemData, domains); err != nil {
+		return &motmedelErrors.CauseError{
+			Message: "The issued certificate does not cover all of the requested domains.",
+			Cause:   err,
+		}
+	}
+
+	if mustStaple {
+		if err := letsencryptUtilsCertificate.VerifyMustStaple(chainPemData); err != nil {
+			return &motmedelErrors.CauseError{
+				Message: "The issued certificate lacks the requested OCSP Must-Staple extension.",
+				Cause:   err,
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package cli_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"flag"
+	letsencryptUtilsCli "github.com/altshiftab/letsencrypt_utils/internal/cli"
+	letsencryptUtilsAccount "github.com/altshiftab/letsencrypt_utils/pkg/account"
+	letsencryptUtilsAcmetest "github.com/altshiftab/letsencrypt_utils/pkg/acmetest"
+	letsencryptUtilsKey "github.com/altshiftab/letsencrypt_utils/pkg/key"
+	letsencryptUtilsSolver "github.com/altshiftab/letsencrypt_utils/pkg/solver"
+	"golang.org/x/crypto/acme"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// nopSolver presents nothing, as the test server considers all challenges fulfilled.
+type nopSolver struct{}
+
+func (nopSolver) Present(context.Context, string, string, string) error { return nil }
+
+func (nopSolver) CleanUp(context.Context, string, string, string) error { return nil }
+
+func newOrderClient(t *testing.T) *acme.Client {
+	t.Helper()
+
+	server, err := letsencryptUtilsAcmetest.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	t.Cleanup(server.Close)
+
+	accountCredentials, err := letsencryptUtilsAccount.RegisterAccount(
+		context.Background(),
+		"user@example.org",
+		letsencryptUtilsAccount.WithDirectoryUrl(server.DirectoryUrl()),
+	)
+	if err != nil {
+		t.Fatalf("RegisterAccount: %v", err)
+	}
+
+	client, err := accountCredentials.Client(server.DirectoryUrl())
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+
+	return client
+}
+
+// obtainWithReusedKey obtains a certificate with -reuse-key, the key path holding the key if it is not nil.
+func obtainWithReusedKey(t *testing.T, key crypto.Signer) (*letsencryptUtilsCli.Certificate, error) {
+	t.Helper()
+
+	keyPath := filepath.Join(t.TempDir(), "privkey.pem")
+	if key != nil {
+		keyPemData, err := letsencryptUtilsKey.MarshalPem(key)
+		if err != nil {
+			t.Fatalf("MarshalPem: %v", err)
+		}
+		if err := os.WriteFile(keyPath, keyPemData, 0600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	orderConfig := letsencryptUtilsCli.AddOrderFlags(flagSet)
+	orderConfig.ReuseKey = true
+
+	return orderConfig.ObtainWithSolvers(
+		context.Background(),
+		newOrderClient(t),
+		[]string{"example.org"},
+		keyPath,
+		map[string]letsencryptUtilsSolver.Solver{letsencryptUtilsSolver.ChallengeTypeHttp01: nopSolver{}},
+	)
+}
+
+func TestObtainReusedKeyKeepsItsType(t *testing.T) {
+	// An RSA key is not what would be generated, but it is reused as it is.
+	key, err := letsencryptUtilsKey.Generate(&letsencryptUtilsKey.Spec{Type: letsencryptUtilsKey.TypeRsa})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	certificate, err := obtainWithReusedKey(t, key)
+	if err != nil {
+		t.Fatalf("ObtainWithSolvers: %v", err)
+	}
+
+	reusedKey, ok := certificate.Key.(*rsa.PrivateKey)
+	if !ok || !reusedKey.Equal(key) {
+		t.Errorf("the certificate key is a %T other than the reused key", certificate.Key)
+	}
+}
+
+func TestObtainReusedKeyMissing(t *testing.T) {
+	certificate, err := obtainWithReusedKey(t, nil)
+	if err != nil {
+		t.Fatalf("ObtainWithSolvers: %v", err)
+	}
+
+	spec, err := letsencryptUtilsKey.SpecOf(certificate.Key)
+	if err != nil {
+		t.Fatalf("SpecOf: %v", err)
+	}
+	if _, ok := certificate.Key.(*ecdsa.PrivateKey); !ok || spec.Curve != letsencryptUtilsKey.CurveP256 {
+		t.Errorf("the generated key is %+v, want a P-256 ECDSA key", *spec)
+	}
+}
+
+func TestObtainReusedKeyUnsuitable(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	if _, err := obtainWithReusedKey(t, key); err == nil {
+		t.Error("ObtainWithSolvers succeeded with an Ed25519 key")
+	}
+}
+
+func TestObtainCheckCaaWithoutCaaIdentities(t *testing.T) {
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	orderConfig := letsencryptUtilsCli.AddOrderFlags(flagSet)
+	orderConfig.CheckCaa = true
+
+	// The test server lists no CAA identities, so the check is skipped rather than made against another CA.
+	_, err := orderConfig.ObtainWithSolvers(
+		context.Background(),
+		newOrderClient(t),
+		[]string{"example.org"},
+		"",
+		map[string]letsencryptUtilsSolver.Solver{letsencryptUtilsSolver.ChallengeTypeHttp01: nopSolver{}},
+	)
+	if err != nil {
+		t.Fatalf("ObtainWithSolvers: %v", err)
+	}
+}
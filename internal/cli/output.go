@@ -0,0 +1,328 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsFileutil "github.com/altshiftab/letsencrypt_utils/internal/fileutil"
+	letsencryptUtilsKey "github.com/altshiftab/letsencrypt_utils/pkg/key"
+	letsencryptUtilsOrder "github.com/altshiftab/letsencrypt_utils/pkg/order"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	FormatPem = "pem"
+	FormatDer = "der"
+)
+
+// DefaultKeyOutPath is the default path where the certificate private key file is written.
+const DefaultKeyOutPath = "certificate_key.pem"
+
+// The names of the files of a per-domain output directory, following the layout many tools expect.
+const (
+	DirectoryCertFileName      = "cert.pem"
+	DirectoryChainFileName     = "chain.pem"
+	DirectoryFullchainFileName = "fullchain.pem"
+	DirectoryKeyFileName       = "privkey.pem"
+)
+
+type CertificateOutputConfig struct {
+	CertOutPath      string
+	ChainOutPath     string
+	FullchainOutPath string
+	KeyOutPath       string
+	// Format is the encoding of the written leaf certificate and key, FormatPem if empty.
+	Format string
+
+	// directory is the per-domain output directory set by InDirectory, which is created by Write.
+	directory string
+}
+
+// AddCertificateOutputFlags registers the -cert-out, -chain-out, -fullchain-out, -key-out, and -format flags.
+func AddCertificateOutputFlags(flagSet *flag.FlagSet) *CertificateOutputConfig {
+	outputConfig := &CertificateOutputConfig{}
+	flagSet.StringVar(
+		&outputConfig.CertOutPath,
+		"cert-out",
+		"",
+		"The path where the leaf certificate is to be written.",
+	)
+	flagSet.StringVar(
+		&outputConfig.ChainOutPath,
+		"chain-out",
+		"",
+		"The path where the intermediate certificates are to be written.",
+	)
+	flagSet.StringVar(
+		&outputConfig.FullchainOutPath,
+		"fullchain-out",
+		"",
+		"The path where the leaf certificate followed by the intermediate certificates is to be written.",
+	)
+	flagSet.StringVar(
+		&outputConfig.KeyOutPath,
+		"key-out",
+		DefaultKeyOutPath,
+		"The path where the certificate private key file is to be written.",
+	)
+	flagSet.StringVar(
+		&outputConfig.Format,
+		"format",
+		FormatPem,
+		"The encoding of the leaf certificate and the private key (pem or der). The der encoding cannot hold a "+
+			"chain, so it requires -cert-out and rules out -chain-out and -fullchain-out; it also requires -key-out.",
+	)
+	return outputConfig
+}
+
+// Validate checks the output format, so that an invalid configuration is reported before a certificate is ordered.
+func (outputConfig *CertificateOutputConfig) Validate() error {
+	switch outputConfig.Format {
+	case "", FormatPem:
+		return nil
+	case FormatDer:
+		// DER has no delimiters, so several certificates cannot be concatenated unambiguously.
+		if outputConfig.ChainOutPath != "" || outputConfig.FullchainOutPath != "" {
+			return &motmedelErrors.CauseError{
+				Message: "The der format cannot be used with -chain-out or -fullchain-out, as DER cannot hold a chain.",
+				Cause:   ErrInvalidInput,
+			}
+		}
+		if outputConfig.CertOutPath == "" {
+			return &motmedelErrors.CauseError{
+				Message: "The der format requires -cert-out, as the default output is a chain, which DER cannot hold.",
+				Cause:   ErrInvalidInput,
+			}
+		}
+		// The default key path names a PEM file, which a DER key is not to be written to.
+		if outputConfig.KeyOutPath == DefaultKeyOutPath {
+			return &motmedelErrors.InputError{
+				Message: "The der format requires -key-out, as the default key path names a PEM file.",
+				Cause:   ErrInvalidInput,
+				Input:   outputConfig.KeyOutPath,
+			}
+		}
+		return nil
+	default:
+		return &motmedelErrors.InputError{
+			Message: "The output format is unsupported.",
+			Cause:   ErrInvalidInput,
+			Input:   outputConfig.Format,
+		}
+	}
+}
+
+// InDirectory returns the output configuration writing the certificate and its private key to the per-domain directory
+// of the primary domain, the first one, within the output directory: <dir>/<domain>/cert.pem, chain.pem,
+// fullchain.pem, and privkey.pem. The directory is created by Write, if it does not exist, so that nothing is left
+// behind if no certificate is obtained. The configuration is to have no paths of its own, and the pem format.
+func (outputConfig *CertificateOutputConfig) InDirectory(
+	outputDir string,
+	domains []string,
+) (*CertificateOutputConfig, error) {
+	if outputConfig.CertOutPath != "" || outputConfig.ChainOutPath != "" || outputConfig.FullchainOutPath != "" ||
+		(outputConfig.KeyOutPath != "" && outputConfig.KeyOutPath != DefaultKeyOutPath) {
+		return nil, &motmedelErrors.CauseError{
+			Message: "The output directory cannot be used with -cert-out, -chain-out, -fullchain-out, or -key-out.",
+			Cause:   ErrInvalidInput,
+		}
+	}
+	if outputConfig.Format != "" && outputConfig.Format != FormatPem {
+		return nil, &motmedelErrors.InputError{
+			Message: "The output directory holds PEM files, so it cannot be used with another format.",
+			Cause:   ErrInvalidInput,
+			Input:   outputConfig.Format,
+		}
+	}
+	if len(domains) == 0 {
+		return nil, &motmedelErrors.CauseError{Message: "No domains were provided.", Cause: ErrInvalidInput}
+	}
+
+	asciiDomain, err := letsencryptUtilsOrder.ToAscii(domains[0])
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "The domain is invalid.",
+			Cause:   errors.Join(ErrInvalidInput, err),
+			Input:   domains[0],
+		}
+	}
+	directory := filepath.Join(outputDir, DomainDirectoryName(asciiDomain))
+
+	return &CertificateOutputConfig{
+		CertOutPath:      filepath.Join(directory, DirectoryCertFileName),
+		ChainOutPath:     filepath.Join(directory, DirectoryChainFileName),
+		FullchainOutPath: filepath.Join(directory, DirectoryFullchainFileName),
+		KeyOutPath:       filepath.Join(directory, DirectoryKeyFileName),
+		directory:        directory,
+		Format:           FormatPem,
+	}, nil
+}
+
+// DomainDirectoryName returns the name of the per-domain output directory of the domain, which is safe as a file
+// name: a wildcard domain becomes e.g. wildcard.example.com, and characters other than lowercase letters, digits,
+// dots, dashes, and underscores, e.g. the colons of an IPv6 address, become underscores.
+func DomainDirectoryName(domain string) string {
+	domain = strings.ToLower(domain)
+	if labels, ok := strings.CutPrefix(domain, "*."); ok {
+		domain = "wildcard." + labels
+	}
+
+	name := strings.Map(
+		func(character rune) rune {
+			switch {
+			case character >= 'a' && character <= 'z', character >= '0' && character <= '9':
+				return character
+			case character == '.', character == '-', character == '_':
+				return character
+			default:
+				return '_'
+			}
+		},
+		domain,
+	)
+
+	// A name of only dots would refer to the output directory or its parent.
+	if strings.Trim(name, ".") == "" {
+		return strings.Repeat("_", max(len(name), 1))
+	}
+	return name
+}
+
+// CertificatePath returns the path at which the certificate is written: the full chain path if written, and otherwise
+// the leaf certificate path.
+func (outputConfig *CertificateOutputConfig) CertificatePath(fallbackPath string) string {
+	switch {
+	case outputConfig.FullchainOutPath != "":
+		return outputConfig.FullchainOutPath
+	case outputConfig.CertOutPath != "":
+		return outputConfig.CertOutPath
+	case outputConfig.ChainOutPath != "":
+		return ""
+	default:
+		return fallbackPath
+	}
+}
+
+// Write writes the certificate and its private key, if any, to the configured paths in the configured format. If none
+// of the certificate paths are set, the full chain is written to the fallback path. All files are staged before any
+// of them is replaced, and if one cannot be replaced, those already replaced are restored, so that a failure does not
+// leave a new certificate next to an old key, or the other way around. Only a failure to restore a file, which is
+// reported along with the original error, can leave the files mismatched.
+func (outputConfig *CertificateOutputConfig) Write(certificate *Certificate, fallbackPath string) error {
+	if certificate == nil {
+		return &motmedelErrors.CauseError{Message: "The certificate is nil."}
+	}
+	if len(certificate.DerChain) == 0 {
+		return &motmedelErrors.CauseError{Message: "The certificate chain is empty."}
+	}
+	if err := outputConfig.Validate(); err != nil {
+		return err
+	}
+	der := outputConfig.Format == FormatDer
+
+	fullchainOutPath := outputConfig.FullchainOutPath
+	if outputConfig.CertOutPath == "" && outputConfig.ChainOutPath == "" && fullchainOutPath == "" {
+		fullchainOutPath = fallbackPath
+	}
+
+	type outputFile struct {
+		path        string
+		data        []byte
+		perm        os.FileMode
+		description string
+	}
+	var outputFiles []outputFile
+
+	// A certificate ordered for a pre-generated CSR has no key to be written.
+	if certificate.KeyPem != nil {
+		keyData := certificate.KeyPem
+		if der {
+			var err error
+			keyData, err = letsencryptUtilsKey.MarshalDer(certificate.Key)
+			if err != nil {
+				return &motmedelErrors.CauseError{
+					Message: "An error occurred when marshalling the certificate key data.",
+					Cause:   err,
+				}
+			}
+		}
+		outputFiles = append(outputFiles, outputFile{outputConfig.KeyOutPath, keyData, 0600, "certificate key"})
+	}
+
+	if outputConfig.CertOutPath != "" {
+		leafData := certificate.DerChain[0]
+		if !der {
+			leafData = letsencryptUtilsOrder.EncodeChainPem(certificate.DerChain[:1])
+		}
+		outputFiles = append(outputFiles, outputFile{outputConfig.CertOutPath, leafData, 0644, "leaf certificate"})
+	}
+
+	if outputConfig.ChainOutPath != "" {
+		if len(certificate.DerChain) < 2 {
+			return &motmedelErrors.InputError{
+				Message: "The certificate chain has no intermediate certificates to be written.",
+				Input:   outputConfig.ChainOutPath,
+			}
+		}
+		chainPem := letsencryptUtilsOrder.EncodeChainPem(certificate.DerChain[1:])
+		outputFiles = append(outputFiles, outputFile{outputConfig.ChainOutPath, chainPem, 0644, "intermediate certificate"})
+	}
+
+	if fullchainOutPath != "" {
+		outputFiles = append(outputFiles, outputFile{fullchainOutPath, certificate.ChainPem, 0644, "certificate chain"})
+	}
+
+	if outputConfig.directory != "" {
+		if err := os.MkdirAll(outputConfig.directory, 0755); err != nil {
+			return &motmedelErrors.InputError{
+				Message: "An error occurred when creating the output directory.",
+				Cause:   err,
+				Input:   outputConfig.directory,
+			}
+		}
+	}
+
+	stagedFiles := make([]*letsencryptUtilsFileutil.StagedFile, 0, len(outputFiles))
+	defer func() {
+		for _, stagedFile := range stagedFiles {
+			stagedFile.Discard()
+		}
+	}()
+
+	for _, file := range outputFiles {
+		stagedFile, err := letsencryptUtilsFileutil.StageFile(file.path, file.data, file.perm)
+		if err != nil {
+			return &motmedelErrors.CauseError{
+				Message: "An error occurred when writing the " + file.description + " data to disk.",
+				Cause:   err,
+			}
+		}
+		stagedFiles = append(stagedFiles, stagedFile)
+	}
+
+	// Each file is backed up before it is replaced, so that the files already replaced can be restored if a later
+	// one cannot be.
+	snapshots := make([]*letsencryptUtilsFileutil.Snapshot, 0, len(stagedFiles))
+	for index, stagedFile := range stagedFiles {
+		snapshot, err := letsencryptUtilsFileutil.TakeSnapshot(stagedFile.Path)
+		if err == nil {
+			err = stagedFile.Commit()
+		}
+		if err != nil {
+			errs := []error{err}
+			for _, replacedSnapshot := range slices.Backward(snapshots) {
+				errs = append(errs, replacedSnapshot.Restore())
+			}
+			return &motmedelErrors.CauseError{
+				Message: "An error occurred when writing the " + outputFiles[index].description + " data to disk.",
+				Cause:   errors.Join(errs...),
+			}
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return nil
+}
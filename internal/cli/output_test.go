@@ -0,0 +1,165 @@
+package cli_test
+
+import (
+	"bytes"
+	"errors"
+	letsencryptUtilsCli "github.com/altshiftab/letsencrypt_utils/internal/cli"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestCertificate() *letsencryptUtilsCli.Certificate {
+	return &letsencryptUtilsCli.Certificate{
+		DerChain: [][]byte{[]byte("leaf"), []byte("intermediate")},
+		ChainPem: []byte("new chain"),
+		KeyPem:   []byte("new key"),
+	}
+}
+
+func TestCertificateOutputConfigWrite(t *testing.T) {
+	directory := t.TempDir()
+	outputConfig := &letsencryptUtilsCli.CertificateOutputConfig{
+		CertOutPath:      filepath.Join(directory, "cert.pem"),
+		ChainOutPath:     filepath.Join(directory, "chain.pem"),
+		FullchainOutPath: filepath.Join(directory, "fullchain.pem"),
+		KeyOutPath:       filepath.Join(directory, "privkey.pem"),
+	}
+
+	if err := outputConfig.Write(newTestCertificate(), ""); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	wantData := map[string]string{outputConfig.FullchainOutPath: "new chain", outputConfig.KeyOutPath: "new key"}
+	for path, want := range wantData {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		if string(data) != want {
+			t.Errorf("%s = %q, want %q", filepath.Base(path), data, want)
+		}
+	}
+	for _, path := range []string{outputConfig.CertOutPath, outputConfig.ChainOutPath} {
+		if data, err := os.ReadFile(path); err != nil || !bytes.HasPrefix(data, []byte("-----BEGIN CERTIFICATE-----")) {
+			t.Errorf("%s = %q, %v, want a PEM certificate", filepath.Base(path), data, err)
+		}
+	}
+}
+
+func TestCertificateOutputConfigWriteKeyFailure(t *testing.T) {
+	directory := t.TempDir()
+	outputConfig := &letsencryptUtilsCli.CertificateOutputConfig{
+		FullchainOutPath: filepath.Join(directory, "fullchain.pem"),
+		KeyOutPath:       filepath.Join(directory, "missing", "privkey.pem"),
+	}
+	if err := os.WriteFile(outputConfig.FullchainOutPath, []byte("old chain"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if err := outputConfig.Write(newTestCertificate(), ""); err == nil {
+		t.Fatal("Write succeeded")
+	}
+
+	// The certificate is to be left as it was, rather than replaced next to a missing key.
+	data, err := os.ReadFile(outputConfig.FullchainOutPath)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(data) != "old chain" {
+		t.Errorf("fullchain.pem = %q, want the old chain", data)
+	}
+
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("the directory has %d entries, want only fullchain.pem", len(entries))
+	}
+}
+
+func TestCertificateOutputConfigWriteRollsBack(t *testing.T) {
+	directory := t.TempDir()
+	outputConfig := &letsencryptUtilsCli.CertificateOutputConfig{
+		CertOutPath:      filepath.Join(directory, "cert.pem"),
+		FullchainOutPath: filepath.Join(directory, "fullchain.pem"),
+		KeyOutPath:       filepath.Join(directory, "privkey.pem"),
+	}
+	if err := os.WriteFile(outputConfig.KeyOutPath, []byte("old key"), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	// The full chain, replaced last, cannot be replaced, as a directory is in its place.
+	if err := os.Mkdir(outputConfig.FullchainOutPath, 0755); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+
+	if err := outputConfig.Write(newTestCertificate(), ""); err == nil {
+		t.Fatal("Write succeeded")
+	}
+
+	// The key is to be restored, and the certificate, which did not exist, removed again.
+	data, err := os.ReadFile(outputConfig.KeyOutPath)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(data) != "old key" {
+		t.Errorf("privkey.pem = %q, want the old key", data)
+	}
+	if _, err := os.Stat(outputConfig.CertOutPath); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("cert.pem was left behind: %v", err)
+	}
+}
+
+func TestCertificateOutputConfigValidateDerKeyOut(t *testing.T) {
+	testCases := []struct {
+		name       string
+		keyOutPath string
+		wantErr    bool
+	}{
+		{name: "default key path", keyOutPath: letsencryptUtilsCli.DefaultKeyOutPath, wantErr: true},
+		{name: "explicit key path", keyOutPath: "certificate_key.der"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			outputConfig := &letsencryptUtilsCli.CertificateOutputConfig{
+				CertOutPath: "cert.der",
+				KeyOutPath:  testCase.keyOutPath,
+				Format:      letsencryptUtilsCli.FormatDer,
+			}
+
+			err := outputConfig.Validate()
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("Validate error = %v, want an error: %t", err, testCase.wantErr)
+			}
+			if err != nil && letsencryptUtilsCli.ExitCode(err) != letsencryptUtilsCli.ExitCodeInvalidInput {
+				t.Errorf("ExitCode = %d, want %d", letsencryptUtilsCli.ExitCode(err), letsencryptUtilsCli.ExitCodeInvalidInput)
+			}
+		})
+	}
+}
+
+func TestCertificateOutputConfigInDirectory(t *testing.T) {
+	outputDir := t.TempDir()
+	outputConfig, err := (&letsencryptUtilsCli.CertificateOutputConfig{}).InDirectory(outputDir, []string{"example.org"})
+	if err != nil {
+		t.Fatalf("InDirectory: %v", err)
+	}
+
+	// The directory is only created once there is a certificate to be written.
+	directory := filepath.Join(outputDir, "example.org")
+	if _, err := os.Stat(directory); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("the directory was created before writing: %v", err)
+	}
+
+	if err := outputConfig.Write(newTestCertificate(), ""); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	for _, name := range []string{"cert.pem", "chain.pem", "fullchain.pem", "privkey.pem"} {
+		if _, err := os.Stat(filepath.Join(directory, name)); err != nil {
+			t.Errorf("Stat %s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"flag"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"os"
+	"strings"
+)
+
+// PassphraseEnvironmentVariable is the environment variable from which the account credentials passphrase is read if
+// no passphrase file is provided.
+const PassphraseEnvironmentVariable = "LETSENCRYPT_PASSPHRASE"
+
+// ReadSecretFile reads a secret, such as a passphrase or a password, from the file at the path, trimming the trailing
+// newline. An empty secret is rejected.
+func ReadSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", &motmedelErrors.InputError{
+			Message: "An error occurred when reading the secret file.",
+			Cause:   err,
+			Input:   path,
+		}
+	}
+
+	secret := strings.TrimRight(string(data), "\r\n")
+	if secret == "" {
+		return "", &motmedelErrors.InputError{Message: "The secret file is empty.", Input: path}
+	}
+
+	return secret, nil
+}
+
+// readSecret reads a secret from the file at the path if set, and otherwise from the environment variable. An empty
+// string is returned if neither is set, whereas a set but empty environment variable is rejected.
+func readSecret(path string, environmentVariable string) (string, error) {
+	if path != "" {
+		return ReadSecretFile(path)
+	}
+
+	secret, ok := os.LookupEnv(environmentVariable)
+	if !ok {
+		return "", nil
+	}
+	if secret == "" {
+		return "", &motmedelErrors.InputError{
+			Message: "The secret environment variable is empty.",
+			Input:   environmentVariable,
+		}
+	}
+
+	return secret, nil
+}
+
+type PassphraseConfig struct {
+	Path string
+}
+
+// AddPassphraseFlags registers the -passphrase-file flag.
+func AddPassphraseFlags(flagSet *flag.FlagSet) *PassphraseConfig {
+	passphraseConfig := &PassphraseConfig{}
+	flagSet.StringVar(
+		&passphraseConfig.Path,
+		"passphrase-file",
+		"",
+		"The path of a file containing the passphrase of the account credentials file. Takes precedence over the "+
+			PassphraseEnvironmentVariable+" environment variable.",
+	)
+	return passphraseConfig
+}
+
+// Passphrase returns the passphrase from the passphrase file or the environment variable, or nil if neither is set.
+func (passphraseConfig *PassphraseConfig) Passphrase() ([]byte, error) {
+	passphrase, err := readSecret(passphraseConfig.Path, PassphraseEnvironmentVariable)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when reading the passphrase.", Cause: err}
+	}
+	if passphrase == "" {
+		return nil, nil
+	}
+	return []byte(passphrase), nil
+}
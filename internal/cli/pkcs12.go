@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"crypto/x509"
+	"flag"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsFileutil "github.com/altshiftab/letsencrypt_utils/internal/fileutil"
+	letsencryptUtilsCertificate "github.com/altshiftab/letsencrypt_utils/pkg/certificate"
+)
+
+// Pkcs12PasswordEnvironmentVariable is the environment variable from which the PKCS#12 password is read if neither
+// the -pkcs12-password nor the -pkcs12-password-file flag is set.
+const Pkcs12PasswordEnvironmentVariable = "LETSENCRYPT_PKCS12_PASSWORD"
+
+type Pkcs12Config struct {
+	OutPath      string
+	Password     string
+	PasswordPath string
+}
+
+// AddPkcs12Flags registers the -pkcs12-out, -pkcs12-password, and -pkcs12-password-file flags.
+func AddPkcs12Flags(flagSet *flag.FlagSet) *Pkcs12Config {
+	pkcs12Config := &Pkcs12Config{}
+	flagSet.StringVar(
+		&pkcs12Config.OutPath,
+		"pkcs12-out",
+		"",
+		"The path where a PKCS#12 file bundling the certificate chain and the private key is to be written.",
+	)
+	flagSet.StringVar(
+		&pkcs12Config.Password,
+		"pkcs12-password",
+		"",
+		"The password protecting the PKCS#12 file. Prefer -pkcs12-password-file, as flag values are visible in "+
+			"process listings.",
+	)
+	flagSet.StringVar(
+		&pkcs12Config.PasswordPath,
+		"pkcs12-password-file",
+		"",
+		"The path of a file containing the password protecting the PKCS#12 file. Takes precedence over the "+
+			Pkcs12PasswordEnvironmentVariable+" environment variable.",
+	)
+	return pkcs12Config
+}
+
+func (pkcs12Config *Pkcs12Config) password() (string, error) {
+	if pkcs12Config.Password != "" && pkcs12Config.PasswordPath != "" {
+		return "", &motmedelErrors.CauseError{
+			Message: "The -pkcs12-password and -pkcs12-password-file flags are mutually exclusive.",
+		}
+	}
+
+	password := pkcs12Config.Password
+	if password == "" {
+		var err error
+		password, err = readSecret(pkcs12Config.PasswordPath, Pkcs12PasswordEnvironmentVariable)
+		if err != nil {
+			return "", &motmedelErrors.CauseError{
+				Message: "An error occurred when reading the PKCS#12 password.",
+				Cause:   err,
+			}
+		}
+	}
+
+	if password == "" {
+		return "", &motmedelErrors.CauseError{
+			Message: "A PKCS#12 password must be provided with -pkcs12-password-file, -pkcs12-password, or the " +
+				Pkcs12PasswordEnvironmentVariable + " environment variable.",
+		}
+	}
+
+	return password, nil
+}
+
+// Validate checks the PKCS#12 flags, so that an invalid configuration is reported before a certificate is ordered.
+func (pkcs12Config *Pkcs12Config) Validate() error {
+	if pkcs12Config.OutPath == "" {
+		return nil
+	}
+	_, err := pkcs12Config.password()
+	return err
+}
+
+// Write writes the certificate as a PKCS#12 file, if a PKCS#12 output path is set.
+func (pkcs12Config *Pkcs12Config) Write(certificate *Certificate) error {
+	if pkcs12Config.OutPath == "" {
+		return nil
+	}
+
+	if certificate == nil {
+		return &motmedelErrors.CauseError{Message: "The certificate is nil."}
+	}
+	if certificate.Key == nil {
+		return &motmedelErrors.CauseError{Message: "The certificate has no private key to be bundled."}
+	}
+
+	password, err := pkcs12Config.password()
+	if err != nil {
+		return err
+	}
+
+	var chain []*x509.Certificate
+	for _, derData := range certificate.DerChain {
+		chainCertificate, err := x509.ParseCertificate(derData)
+		if err != nil {
+			return &motmedelErrors.CauseError{Message: "An error occurred when parsing a certificate.", Cause: err}
+		}
+		chain = append(chain, chainCertificate)
+	}
+
+	pfxData, err := letsencryptUtilsCertificate.EncodePkcs12(certificate.Key, chain, password)
+	if err != nil {
+		return err
+	}
+
+	if err := letsencryptUtilsFileutil.WriteFileAtomic(pkcs12Config.OutPath, pfxData, 0600); err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when writing the PKCS#12 data to disk.",
+			Cause:   err,
+			Input:   pkcs12Config.OutPath,
+		}
+	}
+
+	return nil
+}
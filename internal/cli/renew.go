@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	motmedelLog "github.com/Motmedel/utils_go/pkg/log"
+	letsencryptUtilsAri "github.com/altshiftab/letsencrypt_utils/pkg/ari"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// RenewAt returns when the certificate is to be renewed: at a point in the renewal window suggested by the CA, if the
+// directory provides renewal information, and otherwise the static renew-before duration ahead of expiry. A nil HTTP
+// client means the default client.
+func RenewAt(
+	ctx context.Context,
+	httpClient *http.Client,
+	directoryUrl string,
+	leaf *x509.Certificate,
+	renewBefore time.Duration,
+	logger *slog.Logger,
+) time.Time {
+	renewAt := leaf.NotAfter.Add(-renewBefore)
+
+	renewalInfo, err := letsencryptUtilsAri.Fetch(ctx, httpClient, directoryUrl, leaf)
+	switch {
+	case err == nil:
+		renewAt = renewalInfo.SelectTime()
+		logger.Debug(
+			"Using the renewal window suggested by the CA.",
+			slog.Time("window_start", renewalInfo.SuggestedWindow.Start),
+			slog.Time("window_end", renewalInfo.SuggestedWindow.End),
+			slog.String("explanation_url", renewalInfo.ExplanationUrl),
+		)
+	case errors.Is(err, letsencryptUtilsAri.ErrUnsupported):
+		logger.Debug("The directory does not advertise renewal information; using the static threshold.")
+	default:
+		motmedelLog.LogWarning(
+			"An error occurred when fetching the renewal information; using the static threshold.",
+			err,
+			logger,
+		)
+	}
+
+	return renewAt
+}
@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// SignalContext returns a context that is cancelled on SIGINT or SIGTERM, so that a command stops cleanly: in-flight
+// requests are abandoned, while clean-ups, such as removing challenge responses, still run. A second signal exits
+// immediately. The returned function stops listening for the signals.
+func SignalContext(logger *slog.Logger) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signalChannel := make(chan os.Signal, 2)
+	signal.Notify(signalChannel, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case receivedSignal := <-signalChannel:
+			logger.Info(
+				"Stopping; a second signal exits immediately.",
+				slog.String("signal", receivedSignal.String()),
+			)
+			cancel()
+		case <-ctx.Done():
+			return
+		}
+
+		<-signalChannel
+		logger.Warn("Exiting immediately; clean-ups may not have completed.")
+		os.Exit(ExitCodeInterrupted)
+	}()
+
+	return ctx, func() {
+		signal.Stop(signalChannel)
+		cancel()
+	}
+}
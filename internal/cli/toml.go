@@ -0,0 +1,275 @@
+package cli
+
+import (
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tomlParser parses the subset of TOML used by configuration files: key/value pairs of strings, integers, floats,
+// booleans, and arrays of them, with comments. Tables and multi-line strings are unsupported, as the keys are flag
+// names.
+type tomlParser struct {
+	data     string
+	position int
+	line     int
+}
+
+// parseToml parses the TOML data into a map of keys to values, which are strings, int64s, float64s, bools, or arrays
+// of them.
+func parseToml(data []byte) (map[string]any, error) {
+	parser := &tomlParser{data: string(data), line: 1}
+	values := make(map[string]any)
+
+	for {
+		parser.skipBlank(true)
+		if parser.done() {
+			return values, nil
+		}
+
+		if parser.peek() == '[' {
+			return nil, parser.error("Tables are unsupported; the keys are to be flag names at the top level.")
+		}
+
+		key, err := parser.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := values[key]; ok {
+			return nil, parser.error("The key is defined more than once.")
+		}
+
+		parser.skipBlank(false)
+		if parser.done() || parser.peek() != '=' {
+			return nil, parser.error("An equals sign is expected after the key.")
+		}
+		parser.position++
+		parser.skipBlank(false)
+
+		value, err := parser.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values[key] = value
+
+		parser.skipBlank(false)
+		if !parser.done() && parser.peek() != '\n' {
+			return nil, parser.error("A new line is expected after the value.")
+		}
+	}
+}
+
+func (parser *tomlParser) done() bool {
+	return parser.position >= len(parser.data)
+}
+
+func (parser *tomlParser) peek() byte {
+	return parser.data[parser.position]
+}
+
+func (parser *tomlParser) error(message string) error {
+	return &motmedelErrors.InputError{Message: message, Input: parser.line}
+}
+
+// skipBlank skips spaces, tabs, and comments, as well as new lines if requested.
+func (parser *tomlParser) skipBlank(newLines bool) {
+	for !parser.done() {
+		switch parser.peek() {
+		case ' ', '\t', '\r':
+			parser.position++
+		case '\n':
+			if !newLines {
+				return
+			}
+			parser.position++
+			parser.line++
+		case '#':
+			for !parser.done() && parser.peek() != '\n' {
+				parser.position++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (parser *tomlParser) parseKey() (string, error) {
+	switch parser.peek() {
+	case '"':
+		return parser.parseBasicString()
+	case '\'':
+		return parser.parseLiteralString()
+	}
+
+	start := parser.position
+	for !parser.done() {
+		character := parser.peek()
+		if character != '-' && character != '_' && !isAsciiLetterOrDigit(character) {
+			break
+		}
+		parser.position++
+	}
+	if parser.position == start {
+		return "", parser.error("A key is expected.")
+	}
+	return parser.data[start:parser.position], nil
+}
+
+func (parser *tomlParser) parseValue() (any, error) {
+	if parser.done() {
+		return nil, parser.error("A value is expected.")
+	}
+
+	switch parser.peek() {
+	case '"':
+		return parser.parseBasicString()
+	case '\'':
+		return parser.parseLiteralString()
+	case '[':
+		return parser.parseArray()
+	}
+
+	start := parser.position
+	for !parser.done() {
+		character := parser.peek()
+		if character != '+' && character != '-' && character != '_' && character != '.' &&
+			!isAsciiLetterOrDigit(character) {
+			break
+		}
+		parser.position++
+	}
+	token := parser.data[start:parser.position]
+
+	switch token {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "":
+		return nil, parser.error("A value is expected.")
+	}
+
+	number := strings.ReplaceAll(token, "_", "")
+	if integer, err := strconv.ParseInt(number, 10, 64); err == nil {
+		return integer, nil
+	}
+	if float, err := strconv.ParseFloat(number, 64); err == nil {
+		return float, nil
+	}
+	return nil, parser.error("The value is neither a string, a number, a boolean, nor an array.")
+}
+
+func (parser *tomlParser) parseArray() ([]any, error) {
+	parser.position++
+
+	elements := []any{}
+	for {
+		parser.skipBlank(true)
+		if parser.done() {
+			return nil, parser.error("The array is not terminated.")
+		}
+		if parser.peek() == ']' {
+			parser.position++
+			return elements, nil
+		}
+
+		element, err := parser.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+
+		parser.skipBlank(true)
+		if parser.done() {
+			return nil, parser.error("The array is not terminated.")
+		}
+		switch parser.peek() {
+		case ',':
+			parser.position++
+		case ']':
+		default:
+			return nil, parser.error("A comma or the end of the array is expected.")
+		}
+	}
+}
+
+func (parser *tomlParser) parseLiteralString() (string, error) {
+	if strings.HasPrefix(parser.data[parser.position:], "'''") {
+		return "", parser.error("Multi-line strings are unsupported.")
+	}
+	parser.position++
+
+	end := strings.IndexAny(parser.data[parser.position:], "'\n")
+	if end < 0 || parser.data[parser.position+end] != '\'' {
+		return "", parser.error("The string is not terminated.")
+	}
+
+	value := parser.data[parser.position : parser.position+end]
+	parser.position += end + 1
+	return value, nil
+}
+
+func (parser *tomlParser) parseBasicString() (string, error) {
+	if strings.HasPrefix(parser.data[parser.position:], `"""`) {
+		return "", parser.error("Multi-line strings are unsupported.")
+	}
+	parser.position++
+
+	var builder strings.Builder
+	for {
+		if parser.done() || parser.peek() == '\n' {
+			return "", parser.error("The string is not terminated.")
+		}
+
+		character := parser.peek()
+		parser.position++
+		switch character {
+		case '"':
+			return builder.String(), nil
+		case '\\':
+			if parser.done() {
+				return "", parser.error("The string is not terminated.")
+			}
+			escape := parser.peek()
+			parser.position++
+			switch escape {
+			case '"', '\\':
+				builder.WriteByte(escape)
+			case 'b':
+				builder.WriteByte('\b')
+			case 't':
+				builder.WriteByte('\t')
+			case 'n':
+				builder.WriteByte('\n')
+			case 'f':
+				builder.WriteByte('\f')
+			case 'r':
+				builder.WriteByte('\r')
+			case 'u', 'U':
+				length := 4
+				if escape == 'U' {
+					length = 8
+				}
+				if parser.position+length > len(parser.data) {
+					return "", parser.error("The Unicode escape is truncated.")
+				}
+				codePoint, err := strconv.ParseUint(parser.data[parser.position:parser.position+length], 16, 32)
+				if err != nil || !utf8.ValidRune(rune(codePoint)) {
+					return "", parser.error("The Unicode escape is invalid.")
+				}
+				builder.WriteRune(rune(codePoint))
+				parser.position += length
+			default:
+				return "", parser.error("The escape sequence is invalid.")
+			}
+		default:
+			builder.WriteByte(character)
+		}
+	}
+}
+
+func isAsciiLetterOrDigit(character byte) bool {
+	return (character >= 'a' && character <= 'z') || (character >= 'A' && character <= 'Z') ||
+		(character >= '0' && character <= '9')
+}
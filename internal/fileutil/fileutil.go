@@ -0,0 +1,217 @@
+package fileutil
+
+import (
+	"errors"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// WriteFileAtomic writes the data to a temporary file in the directory of the path and renames it into place, so
+// that readers never observe a partially written file. The temporary file is created with the final permission bits.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	stagedFile, err := StageFile(path, data, perm)
+	if err != nil {
+		return err
+	}
+	return stagedFile.Commit()
+}
+
+// StagedFile is data written to a temporary file in the directory of a path, not yet renamed into place.
+type StagedFile struct {
+	Path          string
+	temporaryPath string
+}
+
+// StageFile writes the data to a temporary file in the directory of the path, to be renamed into place with Commit or
+// removed with Discard. Staging several files before committing any of them keeps a failure to write one from
+// leaving the others replaced. The temporary file is created with the final permission bits.
+func StageFile(path string, data []byte, perm os.FileMode) (*StagedFile, error) {
+	directory, name := filepath.Split(path)
+	if directory == "" {
+		directory = "."
+	}
+
+	temporaryFile, err := os.CreateTemp(directory, "."+name+".tmp-*")
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when creating a temporary file.",
+			Cause:   err,
+			Input:   directory,
+		}
+	}
+	temporaryPath := temporaryFile.Name()
+
+	staged := false
+	defer func() {
+		if !staged {
+			_ = temporaryFile.Close()
+			_ = os.Remove(temporaryPath)
+		}
+	}()
+
+	// os.CreateTemp uses mode 0600, which is to be adjusted before any data is written.
+	if err := temporaryFile.Chmod(perm); err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when setting the permissions of the temporary file.",
+			Cause:   err,
+			Input:   temporaryPath,
+		}
+	}
+
+	if _, err := temporaryFile.Write(data); err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when writing the temporary file.",
+			Cause:   err,
+			Input:   temporaryPath,
+		}
+	}
+
+	if err := temporaryFile.Sync(); err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when syncing the temporary file.",
+			Cause:   err,
+			Input:   temporaryPath,
+		}
+	}
+
+	if err := temporaryFile.Close(); err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when closing the temporary file.",
+			Cause:   err,
+			Input:   temporaryPath,
+		}
+	}
+	staged = true
+
+	return &StagedFile{Path: path, temporaryPath: temporaryPath}, nil
+}
+
+// Commit renames the staged file into place. The temporary file is removed if the rename fails.
+func (stagedFile *StagedFile) Commit() error {
+	if err := os.Rename(stagedFile.temporaryPath, stagedFile.Path); err != nil {
+		stagedFile.Discard()
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when renaming the temporary file into place.",
+			Cause:   err,
+			Input:   []any{stagedFile.temporaryPath, stagedFile.Path},
+		}
+	}
+	return nil
+}
+
+// Discard removes the temporary file of the staged file, if it has not been committed.
+func (stagedFile *StagedFile) Discard() {
+	_ = os.Remove(stagedFile.temporaryPath)
+}
+
+// Snapshot is the content of a file at a path, or its absence, to be restored after the file is replaced.
+type Snapshot struct {
+	Path   string
+	data   []byte
+	perm   os.FileMode
+	exists bool
+}
+
+// TakeSnapshot reads the file at the path, if any, so that it can be restored with Restore.
+func TakeSnapshot(path string) (*Snapshot, error) {
+	fileInfo, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Snapshot{Path: path}, nil
+	}
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when checking the file to be replaced.",
+			Cause:   err,
+			Input:   path,
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when reading the file to be replaced.",
+			Cause:   err,
+			Input:   path,
+		}
+	}
+
+	return &Snapshot{Path: path, data: data, perm: fileInfo.Mode().Perm(), exists: true}, nil
+}
+
+// Restore puts the file back as it was when the snapshot was taken: its content is written atomically, or the file is
+// removed if there was none.
+func (snapshot *Snapshot) Restore() error {
+	if snapshot.exists {
+		return WriteFileAtomic(snapshot.Path, snapshot.data, snapshot.perm)
+	}
+
+	if err := os.Remove(snapshot.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when removing the file.",
+			Cause:   err,
+			Input:   snapshot.Path,
+		}
+	}
+	return nil
+}
+
+// CheckWritable checks that a file can be written at the path with WriteFileAtomic, by creating and removing a
+// temporary file in its directory.
+func CheckWritable(path string) error {
+	directory, name := filepath.Split(path)
+	if directory == "" {
+		directory = "."
+	}
+
+	temporaryFile, err := os.CreateTemp(directory, "."+name+".tmp-*")
+	if err != nil {
+		return &motmedelErrors.InputError{
+			Message: "The directory of the path is not writable.",
+			Cause:   err,
+			Input:   path,
+		}
+	}
+	_ = temporaryFile.Close()
+
+	if err := os.Remove(temporaryFile.Name()); err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when removing the temporary file.",
+			Cause:   err,
+			Input:   temporaryFile.Name(),
+		}
+	}
+
+	return nil
+}
+
+// BackupPath returns the path of the backup of the path with the index, 1 being the most recent.
+func BackupPath(path string, index int) string {
+	return path + "." + strconv.Itoa(index)
+}
+
+// RotateBackups keeps the data as the most recent of up to count backups of the path, <path>.1 through
+// <path>.<count>, shifting the existing backups one step and discarding the oldest. Each step is an atomic rename or
+// write, and the path itself is not touched, so that an interrupted rotation never leaves the file missing. No
+// backups are kept if the count is not positive.
+func RotateBackups(path string, data []byte, perm os.FileMode, count int) error {
+	if count <= 0 {
+		return nil
+	}
+
+	for index := count - 1; index >= 1; index-- {
+		sourcePath := BackupPath(path, index)
+		targetPath := BackupPath(path, index+1)
+		if err := os.Rename(sourcePath, targetPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return &motmedelErrors.InputError{
+				Message: "An error occurred when rotating a backup.",
+				Cause:   err,
+				Input:   []any{sourcePath, targetPath},
+			}
+		}
+	}
+
+	return WriteFileAtomic(BackupPath(path, 1), data, perm)
+}
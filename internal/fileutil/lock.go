@@ -0,0 +1,100 @@
+package fileutil
+
+import (
+	"errors"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// lockPollInterval is the interval at which a held lock is retried.
+const lockPollInterval = 50 * time.Millisecond
+
+// ErrLockTimeout is returned when a lock is not acquired within the timeout.
+var ErrLockTimeout = errors.New("the lock was not acquired within the timeout")
+
+// Lock is an advisory lock on a path, held via a separate lock file, since a file replaced with WriteFileAtomic is a
+// new file that would not carry a lock taken on the old one.
+type Lock struct {
+	file *os.File
+}
+
+// LockPath returns the path of the lock file of the path.
+func LockPath(path string) string {
+	return path + ".lock"
+}
+
+// AcquireLock acquires an advisory lock on the path, exclusive or shared, retrying until the timeout elapses.
+//
+// For an exclusive lock, the lock file is created if it does not exist, and is left in place when the lock is
+// released. A shared lock, as taken by readers, neither creates the lock file nor requires write access to it: should
+// it not exist or not be accessible, the returned lock is not held. Readers need no more, as files are replaced
+// atomically; the lock only ensures that a reader waits for a writer that holds it.
+func AcquireLock(path string, exclusive bool, timeout time.Duration) (*Lock, error) {
+	lockPath := LockPath(path)
+
+	var file *os.File
+	var err error
+	if exclusive {
+		file, err = os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0600)
+	} else {
+		file, err = os.Open(lockPath)
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+			return &Lock{}, nil
+		}
+	}
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when opening the lock file.",
+			Cause:   err,
+			Input:   lockPath,
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		acquired, err := tryLock(file, exclusive)
+		if err != nil {
+			_ = file.Close()
+			return nil, &motmedelErrors.InputError{
+				Message: "An error occurred when locking the lock file.",
+				Cause:   err,
+				Input:   lockPath,
+			}
+		}
+		if acquired {
+			return &Lock{file: file}, nil
+		}
+
+		if !time.Now().Before(deadline) {
+			_ = file.Close()
+			return nil, &motmedelErrors.InputError{
+				Message: "The file is locked by another process.",
+				Cause:   ErrLockTimeout,
+				Input:   []any{lockPath, timeout},
+			}
+		}
+		time.Sleep(min(lockPollInterval, time.Until(deadline)))
+	}
+}
+
+// Release releases the lock.
+func (lock *Lock) Release() error {
+	if lock == nil || lock.file == nil {
+		return nil
+	}
+
+	unlockErr := unlock(lock.file)
+	closeErr := lock.file.Close()
+	lock.file = nil
+
+	if unlockErr != nil {
+		return &motmedelErrors.CauseError{Message: "An error occurred when unlocking the lock file.", Cause: unlockErr}
+	}
+	if closeErr != nil {
+		return &motmedelErrors.CauseError{Message: "An error occurred when closing the lock file.", Cause: closeErr}
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+//go:build !(darwin || dragonfly || freebsd || illumos || linux || netbsd || openbsd || windows)
+
+package fileutil
+
+import "os"
+
+// Advisory locking is not supported on this platform, so locks are always acquired.
+
+func tryLock(file *os.File, exclusive bool) (bool, error) {
+	return true, nil
+}
+
+func unlock(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,239 @@
+// Package jws signs and posts ACME requests that the acme package has no support for, such as orders with a profile.
+package jws
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"golang.org/x/crypto/acme"
+	"math/big"
+	"net/http"
+)
+
+const (
+	contentType      = "application/jose+json"
+	nonceHeader      = "Replay-Nonce"
+	badNonceProblem  = "urn:ietf:params:acme:error:badNonce"
+	maxNonceAttempts = 2
+)
+
+// algorithm returns the JWS algorithm and hash for the key.
+func algorithm(key crypto.Signer) (string, crypto.Hash, error) {
+	switch publicKey := key.Public().(type) {
+	case *rsa.PublicKey:
+		return "RS256", crypto.SHA256, nil
+	case *ecdsa.PublicKey:
+		switch publicKey.Curve.Params().BitSize {
+		case 256:
+			return "ES256", crypto.SHA256, nil
+		case 384:
+			return "ES384", crypto.SHA384, nil
+		case 521:
+			return "ES512", crypto.SHA512, nil
+		}
+	}
+	return "", 0, &motmedelErrors.CauseError{Message: "The key type is unsupported for signing ACME requests."}
+}
+
+func digest(hash crypto.Hash, data []byte) []byte {
+	switch hash {
+	case crypto.SHA384:
+		sum := sha512.Sum384(data)
+		return sum[:]
+	case crypto.SHA512:
+		sum := sha512.Sum512(data)
+		return sum[:]
+	default:
+		sum := sha256.Sum256(data)
+		return sum[:]
+	}
+}
+
+// Sign produces a flattened JWS of the payload, identifying the key by its account URL.
+func Sign(key crypto.Signer, keyId string, nonce string, url string, payload []byte) ([]byte, error) {
+	if key == nil {
+		return nil, &motmedelErrors.CauseError{Message: "The key is nil."}
+	}
+
+	alg, hash, err := algorithm(key)
+	if err != nil {
+		return nil, err
+	}
+
+	protectedData, err := json.Marshal(
+		struct {
+			Alg   string `json:"alg"`
+			Kid   string `json:"kid"`
+			Nonce string `json:"nonce"`
+			Url   string `json:"url"`
+		}{Alg: alg, Kid: keyId, Nonce: nonce, Url: url},
+	)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when marshalling the JWS header.", Cause: err}
+	}
+
+	protected := base64.RawURLEncoding.EncodeToString(protectedData)
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
+
+	signature, err := key.Sign(rand.Reader, digest(hash, []byte(protected+"."+encodedPayload)), hash)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when signing the JWS.", Cause: err}
+	}
+
+	// ECDSA signatures are to be encoded as the fixed-size concatenation of r and s rather than as ASN.1.
+	if publicKey, ok := key.Public().(*ecdsa.PublicKey); ok {
+		var ecdsaSignature struct{ R, S *big.Int }
+		if _, err := asn1.Unmarshal(signature, &ecdsaSignature); err != nil {
+			return nil, &motmedelErrors.CauseError{
+				Message: "An error occurred when decoding the ECDSA signature.",
+				Cause:   err,
+			}
+		}
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		signature = make([]byte, 2*size)
+		ecdsaSignature.R.FillBytes(signature[:size])
+		ecdsaSignature.S.FillBytes(signature[size:])
+	}
+
+	jwsData, err := json.Marshal(
+		struct {
+			Protected string `json:"protected"`
+			Payload   string `json:"payload"`
+			Signature string `json:"signature"`
+		}{Protected: protected, Payload: encodedPayload, Signature: base64.RawURLEncoding.EncodeToString(signature)},
+	)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when marshalling the JWS.", Cause: err}
+	}
+
+	return jwsData, nil
+}
+
+func httpClient(client *acme.Client) *http.Client {
+	if client.HTTPClient != nil {
+		return client.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func fetchNonce(ctx context.Context, client *acme.Client, nonceUrl string) (string, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodHead, nonceUrl, nil)
+	if err != nil {
+		return "", &motmedelErrors.InputError{
+			Message: "An error occurred when creating the HTTP request.",
+			Cause:   err,
+			Input:   nonceUrl,
+		}
+	}
+
+	response, err := httpClient(client).Do(request)
+	if err != nil {
+		return "", &motmedelErrors.InputError{
+			Message: "An error occurred when fetching a nonce.",
+			Cause:   err,
+			Input:   nonceUrl,
+		}
+	}
+	defer response.Body.Close()
+
+	nonce := response.Header.Get(nonceHeader)
+	if nonce == "" {
+		return "", &motmedelErrors.InputError{Message: "The nonce response has no nonce.", Input: nonceUrl}
+	}
+
+	return nonce, nil
+}
+
+// responseError converts an unsuccessful response into an *acme.Error, decoding its problem document if present.
+func responseError(response *http.Response) error {
+	acmeError := &acme.Error{StatusCode: response.StatusCode, Header: response.Header}
+	_ = json.NewDecoder(response.Body).Decode(
+		&struct {
+			Type   *string `json:"type"`
+			Detail *string `json:"detail"`
+		}{Type: &acmeError.ProblemType, Detail: &acmeError.Detail},
+	)
+	return acmeError
+}
+
+// Post signs the payload with the account key of the client and posts it to the URL. A response with a status code
+// outside of the 2xx range is returned as an *acme.Error. The caller is responsible for closing the response body.
+func Post(ctx context.Context, client *acme.Client, url string, payload any) (*http.Response, error) {
+	if client == nil {
+		return nil, &motmedelErrors.CauseError{Message: "The ACME client is nil."}
+	}
+
+	payloadData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when marshalling the payload.", Cause: err}
+	}
+
+	directory, err := client.Discover(ctx)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when discovering the directory.", Cause: err}
+	}
+
+	keyId := string(client.KID)
+	if keyId == "" {
+		account, err := client.GetReg(ctx, "")
+		if err != nil {
+			return nil, &motmedelErrors.CauseError{Message: "An error occurred when looking up the account.", Cause: err}
+		}
+		keyId = account.URI
+	}
+
+	for attempt := 1; ; attempt++ {
+		nonce, err := fetchNonce(ctx, client, directory.NonceURL)
+		if err != nil {
+			return nil, err
+		}
+
+		jwsData, err := Sign(client.Key, keyId, nonce, url, payloadData)
+		if err != nil {
+			return nil, err
+		}
+
+		request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jwsData))
+		if err != nil {
+			return nil, &motmedelErrors.InputError{
+				Message: "An error occurred when creating the HTTP request.",
+				Cause:   err,
+				Input:   url,
+			}
+		}
+		request.Header.Set("Content-Type", contentType)
+
+		response, err := httpClient(client).Do(request)
+		if err != nil {
+			return nil, &motmedelErrors.InputError{
+				Message: "An error occurred when posting the ACME request.",
+				Cause:   err,
+				Input:   url,
+			}
+		}
+
+		if response.StatusCode >= 200 && response.StatusCode < 300 {
+			return response, nil
+		}
+
+		err = responseError(response)
+		response.Body.Close()
+
+		var acmeError *acme.Error
+		if errors.As(err, &acmeError) && acmeError.ProblemType == badNonceProblem && attempt < maxNonceAttempts {
+			continue
+		}
+
+		return nil, err
+	}
+}
@@ -0,0 +1,51 @@
+package systemd
+
+import (
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"net"
+	"os"
+)
+
+const NotifySocketEnvironmentVariable = "NOTIFY_SOCKET"
+
+// States understood by systemd, see sd_notify(3).
+const (
+	StateReady    = "READY=1"
+	StateStopping = "STOPPING=1"
+	StateWatchdog = "WATCHDOG=1"
+)
+
+// Notify sends the state to the service manager via the socket named by the NOTIFY_SOCKET environment variable. It is
+// a no-op if the variable is not set, i.e. when not run as a systemd service of type notify, and reports whether the
+// state was sent.
+func Notify(state string) (bool, error) {
+	socketPath := os.Getenv(NotifySocketEnvironmentVariable)
+	if socketPath == "" {
+		return false, nil
+	}
+
+	// A leading "@" denotes a socket in the abstract namespace.
+	if socketPath[0] == '@' {
+		socketPath = "\x00" + socketPath[1:]
+	}
+
+	connection, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	if err != nil {
+		return false, &motmedelErrors.InputError{
+			Message: "An error occurred when connecting to the notify socket.",
+			Cause:   err,
+			Input:   socketPath,
+		}
+	}
+	defer connection.Close()
+
+	if _, err := connection.Write([]byte(state)); err != nil {
+		return false, &motmedelErrors.InputError{
+			Message: "An error occurred when writing to the notify socket.",
+			Cause:   err,
+			Input:   state,
+		}
+	}
+
+	return true, nil
+}
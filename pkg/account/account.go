@@ -0,0 +1,503 @@
+package account
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	motmedelLog "github.com/Motmedel/utils_go/pkg/log"
+	letsencryptUtilsDirectory "github.com/altshiftab/letsencrypt_utils/pkg/directory"
+	letsencryptUtilsHttpclient "github.com/altshiftab/letsencrypt_utils/pkg/httpclient"
+	letsencryptUtilsKey "github.com/altshiftab/letsencrypt_utils/pkg/key"
+	letsencryptUtilsRedact "github.com/altshiftab/letsencrypt_utils/pkg/redact"
+	letsencryptUtilsRetry "github.com/altshiftab/letsencrypt_utils/pkg/retry"
+	letsencryptUtilsTypes "github.com/altshiftab/letsencrypt_utils/pkg/types"
+	"golang.org/x/crypto/acme"
+	"io"
+	"log/slog"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+type Config struct {
+	Staging bool
+	// DirectoryUrl, if set, is the URL of the ACME directory to use, overriding Staging.
+	DirectoryUrl string
+	KeySpec      letsencryptUtilsKey.Spec
+	// Key, if set, is an existing account key to register with, rather than generating one per KeySpec.
+	Key                    crypto.Signer
+	ExternalAccountBinding *acme.ExternalAccountBinding
+	// MaxAttempts is the maximum number of registration attempts, retrying on transient failures.
+	MaxAttempts int
+	// AdditionalEmails are contact email addresses in addition to the one passed to RegisterAccount.
+	AdditionalEmails []string
+	// AcceptTos indicates that the user agrees to the terms of service of the CA.
+	AcceptTos bool
+	// Context, if set, is the context of the requests to the CA, taking precedence over the context argument.
+	Context context.Context
+	// LowercaseEmails indicates that the contact email addresses are lowercased in whole, rather than only in their
+	// domains.
+	LowercaseEmails bool
+	// Rand, if set, is the source of randomness for generating the account key, rather than crypto/rand.
+	Rand io.Reader
+}
+
+type Option func(*Config)
+
+// WithStaging selects the Let's Encrypt staging environment rather than production.
+func WithStaging(staging bool) Option {
+	return func(config *Config) {
+		config.Staging = staging
+	}
+}
+
+// WithKeyType selects the algorithm of the generated account key.
+func WithKeyType(keyType letsencryptUtilsKey.Type) Option {
+	return func(config *Config) {
+		config.KeySpec.Type = keyType
+	}
+}
+
+// WithCurve selects the curve of the generated account key when the ECDSA key type is selected.
+func WithCurve(curve letsencryptUtilsKey.Curve) Option {
+	return func(config *Config) {
+		config.KeySpec.Curve = curve
+	}
+}
+
+// WithRsaBits sets the size of the generated account key when the RSA key type is selected.
+func WithRsaBits(rsaBits int) Option {
+	return func(config *Config) {
+		config.KeySpec.RsaBits = rsaBits
+	}
+}
+
+// WithKey registers the account with an existing key rather than a generated one. It takes precedence over the key
+// type options.
+func WithKey(key crypto.Signer) Option {
+	return func(config *Config) {
+		config.Key = key
+	}
+}
+
+// WithEAB binds the registered account to an account with the CA, using the key identifier and decoded HMAC key
+// provided by the CA.
+func WithEAB(keyId string, hmacKey []byte) Option {
+	return func(config *Config) {
+		config.ExternalAccountBinding = &acme.ExternalAccountBinding{KID: keyId, Key: hmacKey}
+	}
+}
+
+// WithContacts adds contact email addresses in addition to the primary one.
+func WithContacts(emails ...string) Option {
+	return func(config *Config) {
+		config.AdditionalEmails = append(config.AdditionalEmails, emails...)
+	}
+}
+
+// WithRetries sets the maximum number of registration attempts, retrying on transient failures.
+func WithRetries(maxAttempts int) Option {
+	return func(config *Config) {
+		config.MaxAttempts = maxAttempts
+	}
+}
+
+// WithAcceptTos indicates whether the user agrees to the terms of service of the CA. A CA that has terms of service
+// refuses registrations that do not agree to them.
+func WithAcceptTos(acceptTos bool) Option {
+	return func(config *Config) {
+		config.AcceptTos = acceptTos
+	}
+}
+
+// WithDirectoryUrl selects an arbitrary ACME directory rather than Let's Encrypt's.
+func WithDirectoryUrl(directoryUrl string) Option {
+	return func(config *Config) {
+		config.DirectoryUrl = directoryUrl
+	}
+}
+
+// WithContext sets the context of the requests to the CA, taking precedence over the context passed to
+// RegisterAccount, Registration.Register, or FindAccount.
+func WithContext(ctx context.Context) Option {
+	return func(config *Config) {
+		config.Context = ctx
+	}
+}
+
+// WithLowercaseEmails selects whether the contact email addresses are lowercased in whole, rather than only in their
+// domains, before being sent to the CA.
+func WithLowercaseEmails(lowercaseEmails bool) Option {
+	return func(config *Config) {
+		config.LowercaseEmails = lowercaseEmails
+	}
+}
+
+// WithRand sets the source of randomness for generating the account key. It is meant for tests that need
+// reproducible keys; otherwise crypto/rand is used.
+func WithRand(random io.Reader) Option {
+	return func(config *Config) {
+		config.Rand = random
+	}
+}
+
+func (config *Config) resolveDirectoryUrl() (string, error) {
+	if config.DirectoryUrl == "" {
+		return letsencryptUtilsDirectory.Url(config.Staging), nil
+	}
+
+	if err := letsencryptUtilsDirectory.ValidateUrl(config.DirectoryUrl); err != nil {
+		return "", err
+	}
+
+	return config.DirectoryUrl, nil
+}
+
+// NormalizeEmail returns the canonical form of the email address: the domain is lowercased, as domain names are
+// case-insensitive, and so is the local part if lowercaseLocalPart is set, although mail servers may treat it as
+// case-sensitive. No Unicode or IDN normalization is applied, so an internationalized domain is kept in the form
+// provided.
+func NormalizeEmail(email string, lowercaseLocalPart bool) string {
+	index := strings.LastIndex(email, "@")
+	if index < 0 {
+		return email
+	}
+
+	localPart, domain := email[:index], email[index+1:]
+	if lowercaseLocalPart {
+		localPart = strings.ToLower(localPart)
+	}
+	return localPart + "@" + strings.ToLower(domain)
+}
+
+// contactUris validates the email addresses and returns the "mailto:" contact URIs of their canonical forms.
+func contactUris(emails []string, lowercaseLocalPart bool) ([]string, error) {
+	var contacts []string
+	for _, email := range emails {
+		if email == "" {
+			return nil, &motmedelErrors.InputError{Message: "The email address is empty.", Cause: ErrEmptyEmail}
+		}
+		// Best-effort email address validation.
+		address, err := mail.ParseAddress(email)
+		if err != nil {
+			return nil, &motmedelErrors.InputError{
+				Message: "The email address is invalid.",
+				Cause:   errors.Join(ErrInvalidEmail, err),
+				Input:   letsencryptUtilsRedact.Email(email),
+			}
+		}
+		contacts = append(contacts, "mailto:"+NormalizeEmail(address.Address, lowercaseLocalPart))
+	}
+	return contacts, nil
+}
+
+// primaryEmail returns the email address of the first "mailto:" contact, if any.
+func primaryEmail(contacts []string) string {
+	for _, contact := range contacts {
+		if email, ok := strings.CutPrefix(contact, "mailto:"); ok {
+			return email
+		}
+	}
+	return ""
+}
+
+// Registration is a prepared account registration, for which all local steps have been performed.
+type Registration struct {
+	Key    crypto.Signer
+	KeyPem []byte
+	// Contacts are the contact URIs sent to the CA, holding the canonical forms of the email addresses.
+	Contacts []string
+	// Email is the primary email address as provided, for display.
+	Email                  string
+	DirectoryUrl           string
+	ExternalAccountBinding *acme.ExternalAccountBinding
+	MaxAttempts            int
+	AcceptTos              bool
+	// KeyGenerationDuration is how long producing the account key took.
+	KeyGenerationDuration time.Duration
+
+	context context.Context
+}
+
+// CheckKeyType checks that ACME requests can be signed with account keys of the type, which excludes Ed25519.
+func CheckKeyType(keyType letsencryptUtilsKey.Type) error {
+	if keyType == letsencryptUtilsKey.TypeEd25519 {
+		return &motmedelErrors.InputError{
+			Message: "Ed25519 account keys are unsupported, as the acme package cannot sign requests with them; use " +
+				"an ECDSA or RSA key.",
+			Cause: ErrUnsupportedAccountKey,
+			Input: keyType,
+		}
+	}
+	return nil
+}
+
+// PrepareRegistration performs the local steps of a registration: it validates the email addresses and the external
+// account binding, generates an account key unless one is provided with WithKey, and resolves the directory URL.
+func PrepareRegistration(email string, opts ...Option) (*Registration, error) {
+	config := &Config{MaxAttempts: letsencryptUtilsRetry.DefaultMaxAttempts}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(config)
+		}
+	}
+
+	if email == "" {
+		return nil, &motmedelErrors.InputError{Message: "The email address is empty.", Cause: ErrEmptyEmail}
+	}
+
+	contactAddresses, err := contactUris(
+		append([]string{email}, config.AdditionalEmails...),
+		config.LowercaseEmails,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if externalAccountBinding := config.ExternalAccountBinding; externalAccountBinding != nil {
+		if externalAccountBinding.KID == "" || len(externalAccountBinding.Key) == 0 {
+			return nil, &motmedelErrors.InputError{
+				Message: "Both the key identifier and the HMAC key of the external account binding must be set.",
+				Input:   externalAccountBinding.KID,
+			}
+		}
+	}
+
+	// Produce an account key.
+
+	keyType := config.KeySpec.Type
+	if config.Key != nil {
+		keySpec, err := letsencryptUtilsKey.SpecOf(config.Key)
+		if err != nil {
+			return nil, &motmedelErrors.CauseError{
+				Message: "An error occurred when determining the account key type.",
+				Cause:   err,
+			}
+		}
+		keyType = keySpec.Type
+	}
+	if err := CheckKeyType(keyType); err != nil {
+		return nil, err
+	}
+
+	keyGenerationStart := time.Now()
+	key := config.Key
+	if key == nil {
+		var err error
+		key, err = letsencryptUtilsKey.GenerateWithRand(&config.KeySpec, config.Rand)
+		if err != nil {
+			return nil, &motmedelErrors.CauseError{
+				Message: "An error occurred when generating an account key.",
+				Cause:   err,
+			}
+		}
+	}
+
+	keyPemData, err := letsencryptUtilsKey.MarshalPem(key)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{
+			Message: "An error occurred when marshalling the account key data.",
+			Cause:   err,
+		}
+	}
+	keyGenerationDuration := time.Since(keyGenerationStart)
+
+	directoryUrl, err := config.resolveDirectoryUrl()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Registration{
+		Key:                    key,
+		KeyPem:                 keyPemData,
+		Contacts:               contactAddresses,
+		Email:                  email,
+		DirectoryUrl:           directoryUrl,
+		ExternalAccountBinding: config.ExternalAccountBinding,
+		MaxAttempts:            config.MaxAttempts,
+		AcceptTos:              config.AcceptTos,
+		KeyGenerationDuration:  keyGenerationDuration,
+		context:                config.Context,
+	}, nil
+}
+
+// Register registers the prepared account with the CA and returns the resulting credentials. ErrAccountExists is
+// returned if an account is already registered for the key, unless the account was registered by an earlier attempt
+// whose response was lost, in which case the credentials are of that account.
+func (registration *Registration) Register(ctx context.Context) (*letsencryptUtilsTypes.AccountCredentials, error) {
+	if registration.context != nil {
+		ctx = registration.context
+	}
+
+	client := &acme.Client{
+		Key:          registration.Key,
+		DirectoryURL: registration.DirectoryUrl,
+		HTTPClient:   letsencryptUtilsHttpclient.ClientFromContext(ctx),
+		RetryBackoff: letsencryptUtilsRetry.ClientBackoff,
+	}
+	registerStart := time.Now()
+	var account *acme.Account
+	var previousErr error
+	recovered := false
+	err := letsencryptUtilsRetry.Do(ctx, registration.MaxAttempts, func(ctx context.Context) error {
+		var err error
+		account, err = client.Register(
+			ctx,
+			&acme.Account{
+				Contact:                registration.Contacts,
+				ExternalAccountBinding: registration.ExternalAccountBinding,
+			},
+			func(string) bool { return registration.AcceptTos },
+		)
+		// After a transient failure, the previous attempt may have succeeded even though its response was lost, and
+		// the account is then the one it registered. On the first attempt, the account was registered before, and
+		// is reported as existing.
+		if errors.Is(err, ErrAccountExists) && letsencryptUtilsRetry.IsRetryable(previousErr) {
+			account, err = client.GetReg(ctx, "")
+			recovered = err == nil
+		}
+		previousErr = err
+		return err
+	})
+	registerDuration := time.Since(registerStart)
+
+	motmedelLog.GetLoggerFromCtxWithDefault(ctx, nil).DebugContext(
+		ctx,
+		"Account registration timings.",
+		slog.Int64("key_gen_ms", registration.KeyGenerationDuration.Milliseconds()),
+		slog.Int64("register_ms", registerDuration.Milliseconds()),
+	)
+
+	if errors.Is(err, ErrAccountExists) {
+		return nil, &motmedelErrors.InputError{
+			Message: "An account is already registered for the key.",
+			Cause:   err,
+			Input:   registration.DirectoryUrl,
+		}
+	}
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when registering the account.",
+			Cause:   err,
+			Input:   []any{letsencryptUtilsRedact.Contacts(registration.Contacts), registration.DirectoryUrl},
+		}
+	}
+	if account == nil {
+		return nil, &motmedelErrors.CauseError{Message: "The account is nil."}
+	}
+
+	accountUri := account.URI
+	if accountUri == "" {
+		return nil, &motmedelErrors.CauseError{Message: "The account URI is empty."}
+	}
+
+	contacts := registration.Contacts
+	email := registration.Email
+	if recovered {
+		contacts = account.Contact
+		email = primaryEmail(account.Contact)
+	}
+
+	return &letsencryptUtilsTypes.AccountCredentials{
+		Uri:          accountUri,
+		Key:          string(registration.KeyPem),
+		Contacts:     contacts,
+		Email:        email,
+		CreatedAt:    time.Now().UTC(),
+		DirectoryUrl: registration.DirectoryUrl,
+		Environment:  letsencryptUtilsDirectory.Environment(registration.DirectoryUrl),
+	}, nil
+}
+
+// RegisterAccount generates an account key, unless one is provided with WithKey, registers an account with it, and
+// returns the resulting credentials. Without options, a P-256 ECDSA key is generated and the account is registered
+// with the Let's Encrypt production environment.
+func RegisterAccount(
+	ctx context.Context,
+	email string,
+	opts ...Option,
+) (*letsencryptUtilsTypes.AccountCredentials, error) {
+	registration, err := PrepareRegistration(email, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return registration.Register(ctx)
+}
+
+// FindAccount looks up the account associated with an existing key without creating a new account, and returns the
+// resulting credentials.
+func FindAccount(
+	ctx context.Context,
+	key crypto.Signer,
+	opts ...Option,
+) (*letsencryptUtilsTypes.AccountCredentials, error) {
+	if key == nil {
+		return nil, &motmedelErrors.CauseError{Message: "The key is nil."}
+	}
+
+	config := &Config{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(config)
+		}
+	}
+
+	keyPemData, err := letsencryptUtilsKey.MarshalPem(key)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{
+			Message: "An error occurred when marshalling the account key data.",
+			Cause:   err,
+		}
+	}
+
+	directoryUrl, err := config.resolveDirectoryUrl()
+	if err != nil {
+		return nil, err
+	}
+	if config.Context != nil {
+		ctx = config.Context
+	}
+
+	client := &acme.Client{
+		Key:          key,
+		DirectoryURL: directoryUrl,
+		HTTPClient:   letsencryptUtilsHttpclient.ClientFromContext(ctx),
+		RetryBackoff: letsencryptUtilsRetry.ClientBackoff,
+	}
+
+	// NOTE: The lookup is performed with the "onlyReturnExisting" semantics, so no account is created.
+	account, err := client.GetReg(ctx, "")
+	if err != nil {
+		if errors.Is(err, ErrNoAccount) {
+			return nil, &motmedelErrors.InputError{
+				Message: "No account exists for the key.",
+				Cause:   err,
+				Input:   directoryUrl,
+			}
+		}
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when looking up the account.",
+			Cause:   err,
+			Input:   directoryUrl,
+		}
+	}
+	if account == nil {
+		return nil, &motmedelErrors.CauseError{Message: "The account is nil."}
+	}
+
+	accountUri := account.URI
+	if accountUri == "" {
+		return nil, &motmedelErrors.CauseError{Message: "The account URI is empty."}
+	}
+
+	return &letsencryptUtilsTypes.AccountCredentials{
+		Uri:          accountUri,
+		Key:          string(keyPemData),
+		Contacts:     account.Contact,
+		Email:        primaryEmail(account.Contact),
+		DirectoryUrl: directoryUrl,
+		Environment:  letsencryptUtilsDirectory.Environment(directoryUrl),
+	}, nil
+}
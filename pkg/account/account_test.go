@@ -0,0 +1,404 @@
+package account_test
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	letsencryptUtilsAccount "github.com/altshiftab/letsencrypt_utils/pkg/account"
+	letsencryptUtilsAcmetest "github.com/altshiftab/letsencrypt_utils/pkg/acmetest"
+	letsencryptUtilsKey "github.com/altshiftab/letsencrypt_utils/pkg/key"
+	letsencryptUtilsTypes "github.com/altshiftab/letsencrypt_utils/pkg/types"
+	"golang.org/x/crypto/acme"
+	"math/rand"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+const termsOfService = "https://ca.example/terms"
+
+func newServer(t *testing.T, options ...letsencryptUtilsAcmetest.Option) *letsencryptUtilsAcmetest.Server {
+	t.Helper()
+
+	server, err := letsencryptUtilsAcmetest.NewServer(options...)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestRegisterAccount(t *testing.T) {
+	server := newServer(t, letsencryptUtilsAcmetest.WithTermsOfService(termsOfService))
+
+	accountCredentials, err := letsencryptUtilsAccount.RegisterAccount(
+		context.Background(),
+		"User@Example.ORG",
+		letsencryptUtilsAccount.WithDirectoryUrl(server.DirectoryUrl()),
+		letsencryptUtilsAccount.WithAcceptTos(true),
+		letsencryptUtilsAccount.WithContacts("ops@example.org"),
+	)
+	if err != nil {
+		t.Fatalf("RegisterAccount: %v", err)
+	}
+
+	if accountCredentials.DirectoryUrl != server.DirectoryUrl() {
+		t.Errorf("DirectoryUrl = %q, want %q", accountCredentials.DirectoryUrl, server.DirectoryUrl())
+	}
+
+	account := server.Account(accountCredentials.Uri)
+	if account == nil {
+		t.Fatalf("the server has no account %q", accountCredentials.Uri)
+	}
+	wantContacts := []string{"mailto:User@example.org", "mailto:ops@example.org"}
+	if !slices.Equal(account.Contacts, wantContacts) {
+		t.Errorf("Contacts = %q, want %q", account.Contacts, wantContacts)
+	}
+	if !account.TermsOfServiceAgreed {
+		t.Error("the terms of service were not agreed to")
+	}
+
+	signer, err := accountCredentials.Signer()
+	if err != nil {
+		t.Fatalf("Signer: %v", err)
+	}
+	foundCredentials, err := letsencryptUtilsAccount.FindAccount(
+		context.Background(),
+		signer,
+		letsencryptUtilsAccount.WithDirectoryUrl(server.DirectoryUrl()),
+	)
+	if err != nil {
+		t.Fatalf("FindAccount: %v", err)
+	}
+	if foundCredentials.Uri != accountCredentials.Uri {
+		t.Errorf("FindAccount URI = %q, want %q", foundCredentials.Uri, accountCredentials.Uri)
+	}
+}
+
+func TestRegisterAccountRetriesServerErrors(t *testing.T) {
+	for _, maxAttempts := range []int{1, 2} {
+		t.Run(strconv.Itoa(maxAttempts), func(t *testing.T) {
+			var attempts atomic.Int32
+			server := newServer(
+				t,
+				letsencryptUtilsAcmetest.WithFaultInjector(func(request *http.Request) *letsencryptUtilsAcmetest.Fault {
+					if request.URL.Path != "/new-account" {
+						return nil
+					}
+					attempts.Add(1)
+					return &letsencryptUtilsAcmetest.Fault{
+						StatusCode:  http.StatusServiceUnavailable,
+						ProblemType: "serverInternal",
+						Detail:      "The service is unavailable.",
+					}
+				}),
+			)
+
+			_, err := letsencryptUtilsAccount.RegisterAccount(
+				context.Background(),
+				"user@example.org",
+				letsencryptUtilsAccount.WithDirectoryUrl(server.DirectoryUrl()),
+				letsencryptUtilsAccount.WithRetries(maxAttempts),
+			)
+			var acmeError *acme.Error
+			if !errors.As(err, &acmeError) || acmeError.StatusCode != http.StatusServiceUnavailable {
+				t.Fatalf("RegisterAccount error = %v, want a 503 ACME error", err)
+			}
+			if got := int(attempts.Load()); got != maxAttempts {
+				t.Errorf("attempts = %d, want %d", got, maxAttempts)
+			}
+		})
+	}
+}
+
+func TestPrepareRegistrationRejectsEd25519(t *testing.T) {
+	ed25519Key, err := letsencryptUtilsKey.Generate(&letsencryptUtilsKey.Spec{Type: letsencryptUtilsKey.TypeEd25519})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	for name, option := range map[string]letsencryptUtilsAccount.Option{
+		"key type": letsencryptUtilsAccount.WithKeyType(letsencryptUtilsKey.TypeEd25519),
+		"key":      letsencryptUtilsAccount.WithKey(ed25519Key),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := letsencryptUtilsAccount.PrepareRegistration("user@example.org", option)
+			if !errors.Is(err, letsencryptUtilsAccount.ErrUnsupportedAccountKey) {
+				t.Errorf("PrepareRegistration error = %v, want ErrUnsupportedAccountKey", err)
+			}
+		})
+	}
+}
+
+func TestRegisterAccountWithoutAcceptingTos(t *testing.T) {
+	server := newServer(t, letsencryptUtilsAcmetest.WithTermsOfService(termsOfService))
+
+	_, err := letsencryptUtilsAccount.RegisterAccount(
+		context.Background(),
+		"user@example.org",
+		letsencryptUtilsAccount.WithDirectoryUrl(server.DirectoryUrl()),
+	)
+	if err == nil {
+		t.Fatal("RegisterAccount succeeded without accepting the terms of service")
+	}
+}
+
+func TestRegisterAccountExistingForKey(t *testing.T) {
+	server := newServer(t)
+
+	register := func() (string, error) {
+		accountCredentials, err := letsencryptUtilsAccount.RegisterAccount(
+			context.Background(),
+			"user@example.org",
+			letsencryptUtilsAccount.WithDirectoryUrl(server.DirectoryUrl()),
+			letsencryptUtilsAccount.WithRand(rand.New(rand.NewSource(1))),
+		)
+		if err != nil {
+			return "", err
+		}
+		return accountCredentials.Uri, nil
+	}
+
+	if _, err := register(); err != nil {
+		t.Fatalf("RegisterAccount: %v", err)
+	}
+
+	// The same key is generated again, and its account is reported as existing rather than returned.
+	if _, err := register(); !errors.Is(err, letsencryptUtilsAccount.ErrAccountExists) {
+		t.Errorf("RegisterAccount error = %v, want ErrAccountExists", err)
+	}
+}
+
+func TestRegisterAccountRecoversLostResponse(t *testing.T) {
+	var failNewAccount atomic.Bool
+	server := newServer(
+		t,
+		letsencryptUtilsAcmetest.WithFaultInjector(func(request *http.Request) *letsencryptUtilsAcmetest.Fault {
+			if request.URL.Path != "/new-account" || !failNewAccount.CompareAndSwap(true, false) {
+				return nil
+			}
+			return &letsencryptUtilsAcmetest.Fault{
+				StatusCode:  http.StatusServiceUnavailable,
+				ProblemType: "serverInternal",
+				Detail:      "The service is unavailable.",
+			}
+		}),
+	)
+
+	register := func(email string) (*letsencryptUtilsTypes.AccountCredentials, error) {
+		return letsencryptUtilsAccount.RegisterAccount(
+			context.Background(),
+			email,
+			letsencryptUtilsAccount.WithDirectoryUrl(server.DirectoryUrl()),
+			letsencryptUtilsAccount.WithRand(rand.New(rand.NewSource(2))),
+		)
+	}
+
+	// The account registered first stands in for one registered by an attempt whose response was lost.
+	firstCredentials, err := register("first@example.org")
+	if err != nil {
+		t.Fatalf("RegisterAccount: %v", err)
+	}
+
+	failNewAccount.Store(true)
+	accountCredentials, err := register("second@example.org")
+	if err != nil {
+		t.Fatalf("RegisterAccount: %v", err)
+	}
+	if accountCredentials.Uri != firstCredentials.Uri {
+		t.Errorf("Uri = %q, want %q", accountCredentials.Uri, firstCredentials.Uri)
+	}
+	// The credentials describe the account as it is, not as it was requested.
+	if accountCredentials.Email != "first@example.org" {
+		t.Errorf("Email = %q, want %q", accountCredentials.Email, "first@example.org")
+	}
+	if wantContacts := []string{"mailto:first@example.org"}; !slices.Equal(accountCredentials.Contacts, wantContacts) {
+		t.Errorf("Contacts = %q, want %q", accountCredentials.Contacts, wantContacts)
+	}
+}
+
+func TestRegisterAccountWithExternalAccountBinding(t *testing.T) {
+	hmacKey := []byte("0123456789abcdef0123456789abcdef")
+	server := newServer(t, letsencryptUtilsAcmetest.WithExternalAccountBinding("kid-1", hmacKey))
+
+	_, err := letsencryptUtilsAccount.RegisterAccount(
+		context.Background(),
+		"user@example.org",
+		letsencryptUtilsAccount.WithDirectoryUrl(server.DirectoryUrl()),
+	)
+	if err == nil {
+		t.Fatal("RegisterAccount succeeded without an external account binding")
+	}
+
+	_, err = letsencryptUtilsAccount.RegisterAccount(
+		context.Background(),
+		"user@example.org",
+		letsencryptUtilsAccount.WithDirectoryUrl(server.DirectoryUrl()),
+		letsencryptUtilsAccount.WithEAB("kid-1", hmacKey),
+	)
+	if err != nil {
+		t.Fatalf("RegisterAccount: %v", err)
+	}
+}
+
+func TestFindAccountWithoutAccount(t *testing.T) {
+	server := newServer(t)
+
+	signer, err := letsencryptUtilsKey.Generate(nil)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	_, err = letsencryptUtilsAccount.FindAccount(
+		context.Background(),
+		signer,
+		letsencryptUtilsAccount.WithDirectoryUrl(server.DirectoryUrl()),
+	)
+	if !errors.Is(err, letsencryptUtilsAccount.ErrNoAccount) {
+		t.Fatalf("FindAccount error = %v, want ErrNoAccount", err)
+	}
+}
+
+func TestRolloverKey(t *testing.T) {
+	server := newServer(t)
+	ctx := context.Background()
+
+	accountCredentials, err := letsencryptUtilsAccount.RegisterAccount(
+		ctx,
+		"user@example.org",
+		letsencryptUtilsAccount.WithDirectoryUrl(server.DirectoryUrl()),
+		letsencryptUtilsAccount.WithKeyType(letsencryptUtilsKey.TypeRsa),
+	)
+	if err != nil {
+		t.Fatalf("RegisterAccount: %v", err)
+	}
+
+	newCredentials, err := letsencryptUtilsAccount.RolloverKey(ctx, accountCredentials, server.DirectoryUrl())
+	if err != nil {
+		t.Fatalf("RolloverKey: %v", err)
+	}
+
+	if newCredentials.Uri != accountCredentials.Uri {
+		t.Errorf("URI = %q, want %q", newCredentials.Uri, accountCredentials.Uri)
+	}
+	if newCredentials.Key == accountCredentials.Key {
+		t.Error("the key was not replaced")
+	}
+
+	newSigner, err := newCredentials.Signer()
+	if err != nil {
+		t.Fatalf("Signer: %v", err)
+	}
+	spec, err := letsencryptUtilsKey.SpecOf(newSigner)
+	if err != nil {
+		t.Fatalf("SpecOf: %v", err)
+	}
+	if spec.Type != letsencryptUtilsKey.TypeRsa {
+		t.Errorf("key type = %q, want %q", spec.Type, letsencryptUtilsKey.TypeRsa)
+	}
+
+	oldSigner, err := accountCredentials.Signer()
+	if err != nil {
+		t.Fatalf("Signer: %v", err)
+	}
+	_, err = letsencryptUtilsAccount.FindAccount(
+		ctx,
+		oldSigner,
+		letsencryptUtilsAccount.WithDirectoryUrl(server.DirectoryUrl()),
+	)
+	if !errors.Is(err, letsencryptUtilsAccount.ErrNoAccount) {
+		t.Errorf("FindAccount with the old key error = %v, want ErrNoAccount", err)
+	}
+}
+
+func TestRolloverKeyUnverified(t *testing.T) {
+	// Once the key has been changed, fetching the account fails, as if the connection was lost.
+	var keyChanged atomic.Bool
+	server := newServer(
+		t,
+		letsencryptUtilsAcmetest.WithFaultInjector(func(request *http.Request) *letsencryptUtilsAcmetest.Fault {
+			if request.URL.Path == "/key-change" {
+				keyChanged.Store(true)
+				return nil
+			}
+			// The account is looked up by its key via new-account.
+			if keyChanged.Load() && request.URL.Path == "/new-account" {
+				return &letsencryptUtilsAcmetest.Fault{
+					StatusCode:  http.StatusForbidden,
+					ProblemType: "unauthorized",
+					Detail:      "The account could not be fetched.",
+				}
+			}
+			return nil
+		}),
+	)
+	ctx := context.Background()
+
+	accountCredentials, err := letsencryptUtilsAccount.RegisterAccount(
+		ctx,
+		"user@example.org",
+		letsencryptUtilsAccount.WithDirectoryUrl(server.DirectoryUrl()),
+	)
+	if err != nil {
+		t.Fatalf("RegisterAccount: %v", err)
+	}
+
+	newCredentials, err := letsencryptUtilsAccount.RolloverKey(ctx, accountCredentials, server.DirectoryUrl(), nil)
+	if !errors.Is(err, letsencryptUtilsAccount.ErrRolloverUnverified) {
+		t.Fatalf("RolloverKey error = %v, want ErrRolloverUnverified", err)
+	}
+	if newCredentials == nil {
+		t.Fatal("the new credentials were not returned")
+	}
+	if newCredentials.Key == accountCredentials.Key {
+		t.Fatal("the key was not replaced")
+	}
+
+	// The returned key is the one the CA now knows the account by.
+	newSigner, err := newCredentials.Signer()
+	if err != nil {
+		t.Fatalf("Signer: %v", err)
+	}
+	publicKey := server.Account(accountCredentials.Uri).Key
+	if !newSigner.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(publicKey) {
+		t.Error("the returned key is not the account key of the CA")
+	}
+}
+
+func TestUpdateContactsAndDeactivate(t *testing.T) {
+	server := newServer(t)
+	ctx := context.Background()
+
+	accountCredentials, err := letsencryptUtilsAccount.RegisterAccount(
+		ctx,
+		"user@example.org",
+		letsencryptUtilsAccount.WithDirectoryUrl(server.DirectoryUrl()),
+	)
+	if err != nil {
+		t.Fatalf("RegisterAccount: %v", err)
+	}
+
+	_, err = letsencryptUtilsAccount.UpdateContacts(
+		ctx,
+		accountCredentials,
+		server.DirectoryUrl(),
+		[]string{"new@Example.org"},
+	)
+	if err != nil {
+		t.Fatalf("UpdateContacts: %v", err)
+	}
+	wantContacts := []string{"mailto:new@example.org"}
+	if contacts := server.Account(accountCredentials.Uri).Contacts; !slices.Equal(contacts, wantContacts) {
+		t.Errorf("Contacts = %q, want %q", contacts, wantContacts)
+	}
+
+	if err := letsencryptUtilsAccount.Deactivate(ctx, accountCredentials, server.DirectoryUrl()); err != nil {
+		t.Fatalf("Deactivate: %v", err)
+	}
+	if status := server.Account(accountCredentials.Uri).Status; status != "deactivated" {
+		t.Errorf("Status = %q, want deactivated", status)
+	}
+}
@@ -0,0 +1,39 @@
+package account
+
+import (
+	"context"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsHttpclient "github.com/altshiftab/letsencrypt_utils/pkg/httpclient"
+	letsencryptUtilsRetry "github.com/altshiftab/letsencrypt_utils/pkg/retry"
+	letsencryptUtilsTypes "github.com/altshiftab/letsencrypt_utils/pkg/types"
+)
+
+// Deactivate permanently deactivates the account. This cannot be undone.
+func Deactivate(
+	ctx context.Context,
+	accountCredentials *letsencryptUtilsTypes.AccountCredentials,
+	directoryUrl string,
+) error {
+	if accountCredentials == nil {
+		return &motmedelErrors.CauseError{Message: "The account credentials are nil."}
+	}
+
+	client, err := accountCredentials.Client(directoryUrl)
+	if err != nil {
+		return &motmedelErrors.CauseError{Message: "An error occurred when creating the ACME client.", Cause: err}
+	}
+	client.HTTPClient = letsencryptUtilsHttpclient.ClientFromContext(ctx)
+
+	err = letsencryptUtilsRetry.Do(ctx, letsencryptUtilsRetry.DefaultMaxAttempts, func(ctx context.Context) error {
+		return client.DeactivateReg(ctx)
+	})
+	if err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when deactivating the account.",
+			Cause:   err,
+			Input:   []any{accountCredentials.Uri, directoryUrl},
+		}
+	}
+
+	return nil
+}
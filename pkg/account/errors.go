@@ -0,0 +1,26 @@
+package account
+
+import (
+	"errors"
+	letsencryptUtilsTypes "github.com/altshiftab/letsencrypt_utils/pkg/types"
+	"golang.org/x/crypto/acme"
+)
+
+var (
+	// ErrEmptyEmail is returned when no email address is provided for a registration.
+	ErrEmptyEmail = errors.New("the email address is empty")
+	// ErrInvalidEmail is returned when an email address provided for a registration cannot be parsed.
+	ErrInvalidEmail = errors.New("the email address is invalid")
+	// ErrAccountExists is reported by the CA when an account is already registered for the key.
+	ErrAccountExists = acme.ErrAccountAlreadyExists
+	// ErrNoAccount is reported by the CA when no account is registered for the key.
+	ErrNoAccount = acme.ErrNoAccount
+	// ErrCredentialsExist is returned when account credentials would overwrite existing ones.
+	ErrCredentialsExist = letsencryptUtilsTypes.ErrCredentialsExist
+	// ErrUnsupportedAccountKey is returned when the account key is of a type with which ACME requests cannot be signed.
+	// Ed25519 keys are such, as the acme package has no support for EdDSA signatures.
+	ErrUnsupportedAccountKey = errors.New("the account key type is unsupported")
+	// ErrRolloverUnverified is returned when the account key was rolled over, but the account could not be fetched
+	// with the new key. The rollover has taken effect regardless, so the new credentials must be kept.
+	ErrRolloverUnverified = errors.New("the key rollover could not be verified")
+)
@@ -0,0 +1,46 @@
+package account
+
+import "strings"
+
+// placeholderDomains are the domains reserved for documentation and testing by RFC 2606 and RFC 6761, which cannot
+// receive email. Their subdomains are matched as well.
+var placeholderDomains = []string{
+	"example.com",
+	"example.net",
+	"example.org",
+	"example",
+	"test",
+	"invalid",
+	"localhost",
+}
+
+// placeholderLocalParts are local parts typical of placeholder addresses copied from examples.
+var placeholderLocalParts = []string{"test"}
+
+// PlaceholderEmailReason reports why the email address looks like a placeholder rather than a real contact address,
+// or returns an empty string if it does not. An address is considered a placeholder if its domain is, or is a
+// subdomain of, one of the reserved domains "example.com", "example.net", "example.org", "example", "test",
+// "invalid", and "localhost", or if its local part is "test". The heuristic is only meant to catch copy-paste
+// mistakes.
+func PlaceholderEmailReason(email string) string {
+	index := strings.LastIndex(email, "@")
+	if index < 0 {
+		return ""
+	}
+	localPart, domain := strings.ToLower(email[:index]), strings.ToLower(strings.TrimSuffix(email[index+1:], "."))
+
+	for _, placeholderDomain := range placeholderDomains {
+		if domain == placeholderDomain || strings.HasSuffix(domain, "."+placeholderDomain) {
+			return "The domain \"" + placeholderDomain + "\" is reserved for documentation and testing and cannot " +
+				"receive email."
+		}
+	}
+
+	for _, placeholderLocalPart := range placeholderLocalParts {
+		if localPart == placeholderLocalPart {
+			return "The local part \"" + placeholderLocalPart + "\" is typical of placeholder addresses."
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,89 @@
+package account
+
+import (
+	"context"
+	"errors"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsHttpclient "github.com/altshiftab/letsencrypt_utils/pkg/httpclient"
+	letsencryptUtilsKey "github.com/altshiftab/letsencrypt_utils/pkg/key"
+	letsencryptUtilsTypes "github.com/altshiftab/letsencrypt_utils/pkg/types"
+)
+
+// RolloverKey replaces the account key with a newly generated key of the same type, verifies that the account can be
+// fetched with the new key, and returns credentials containing the new key. The provided credentials are not
+// modified. Of the options, only WithRand applies.
+//
+// Once the CA has accepted the new key, the old one no longer works. Should the verification then fail, the new
+// credentials are therefore returned along with an error wrapping ErrRolloverUnverified, and must be kept.
+func RolloverKey(
+	ctx context.Context,
+	accountCredentials *letsencryptUtilsTypes.AccountCredentials,
+	directoryUrl string,
+	options ...Option,
+) (*letsencryptUtilsTypes.AccountCredentials, error) {
+	if accountCredentials == nil {
+		return nil, &motmedelErrors.CauseError{Message: "The account credentials are nil."}
+	}
+
+	var config Config
+	for _, option := range options {
+		if option != nil {
+			option(&config)
+		}
+	}
+
+	client, err := accountCredentials.Client(directoryUrl)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when creating the ACME client.", Cause: err}
+	}
+	client.HTTPClient = letsencryptUtilsHttpclient.ClientFromContext(ctx)
+
+	keySpec, err := letsencryptUtilsKey.SpecOf(client.Key)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{
+			Message: "An error occurred when determining the account key type.",
+			Cause:   err,
+		}
+	}
+
+	newKey, err := letsencryptUtilsKey.GenerateWithRand(keySpec, config.Rand)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when generating an account key.", Cause: err}
+	}
+
+	newKeyPemData, err := letsencryptUtilsKey.MarshalPem(newKey)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{
+			Message: "An error occurred when marshalling the account key data.",
+			Cause:   err,
+		}
+	}
+
+	// NOTE: The client's key is replaced with the new key on success.
+	if err := client.AccountKeyRollover(ctx, newKey); err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when rolling over the account key.",
+			Cause:   err,
+			Input:   []any{accountCredentials.Uri, directoryUrl},
+		}
+	}
+
+	newAccountCredentials := *accountCredentials
+	newAccountCredentials.Key = string(newKeyPemData)
+
+	// Verify that the new key works.
+
+	account, err := client.GetReg(ctx, accountCredentials.Uri)
+	if err == nil && account == nil {
+		err = &motmedelErrors.CauseError{Message: "The account is nil."}
+	}
+	if err != nil {
+		return &newAccountCredentials, &motmedelErrors.InputError{
+			Message: "An error occurred when fetching the account with the new key.",
+			Cause:   errors.Join(ErrRolloverUnverified, err),
+			Input:   []any{accountCredentials.Uri, directoryUrl},
+		}
+	}
+
+	return &newAccountCredentials, nil
+}
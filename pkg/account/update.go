@@ -0,0 +1,59 @@
+package account
+
+import (
+	"context"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsHttpclient "github.com/altshiftab/letsencrypt_utils/pkg/httpclient"
+	letsencryptUtilsRedact "github.com/altshiftab/letsencrypt_utils/pkg/redact"
+	letsencryptUtilsRetry "github.com/altshiftab/letsencrypt_utils/pkg/retry"
+	letsencryptUtilsTypes "github.com/altshiftab/letsencrypt_utils/pkg/types"
+	"golang.org/x/crypto/acme"
+)
+
+// UpdateContacts replaces the contacts of the account with the email addresses, whose domains are lowercased, and
+// returns the updated account.
+// ErrEmptyEmail or ErrInvalidEmail is returned if an email address is unusable, in which case the CA is not contacted.
+func UpdateContacts(
+	ctx context.Context,
+	accountCredentials *letsencryptUtilsTypes.AccountCredentials,
+	directoryUrl string,
+	emails []string,
+) (*acme.Account, error) {
+	if accountCredentials == nil {
+		return nil, &motmedelErrors.CauseError{Message: "The account credentials are nil."}
+	}
+
+	if len(emails) == 0 {
+		return nil, &motmedelErrors.InputError{Message: "No email addresses were provided.", Cause: ErrEmptyEmail}
+	}
+
+	contacts, err := contactUris(emails, false)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := accountCredentials.Client(directoryUrl)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when creating the ACME client.", Cause: err}
+	}
+	client.HTTPClient = letsencryptUtilsHttpclient.ClientFromContext(ctx)
+
+	var account *acme.Account
+	err = letsencryptUtilsRetry.Do(ctx, letsencryptUtilsRetry.DefaultMaxAttempts, func(ctx context.Context) error {
+		var err error
+		account, err = client.UpdateReg(ctx, &acme.Account{Contact: contacts})
+		return err
+	})
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when updating the account.",
+			Cause:   err,
+			Input:   []any{letsencryptUtilsRedact.Contacts(contacts), directoryUrl},
+		}
+	}
+	if account == nil {
+		return nil, &motmedelErrors.CauseError{Message: "The account is nil."}
+	}
+
+	return account, nil
+}
@@ -0,0 +1,117 @@
+package acmetest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsCertificate "github.com/altshiftab/letsencrypt_utils/pkg/certificate"
+	"math/big"
+	"time"
+)
+
+// certificateAuthority is the self-signed CA that issues the certificates of the server.
+type certificateAuthority struct {
+	key         crypto.Signer
+	certificate *x509.Certificate
+}
+
+func randomSerialNumber() (*big.Int, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{
+			Message: "An error occurred when generating a serial number.",
+			Cause:   err,
+		}
+	}
+	return serialNumber, nil
+}
+
+func newCertificateAuthority() (*certificateAuthority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when generating the CA key.", Cause: err}
+	}
+
+	serialNumber, err := randomSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: "acmetest CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(10 * 365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	derData, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{
+			Message: "An error occurred when creating the CA certificate.",
+			Cause:   err,
+		}
+	}
+
+	certificate, err := x509.ParseCertificate(derData)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{
+			Message: "An error occurred when parsing the CA certificate.",
+			Cause:   err,
+		}
+	}
+
+	return &certificateAuthority{key: key, certificate: certificate}, nil
+}
+
+// issue issues a DER-encoded leaf certificate for the names and public key of the CSR.
+func (ca *certificateAuthority) issue(csr *x509.CertificateRequest, validity time.Duration) ([]byte, error) {
+	serialNumber, err := randomSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	keyUsage := x509.KeyUsageDigitalSignature
+	if _, ok := csr.PublicKey.(*rsa.PublicKey); ok {
+		keyUsage |= x509.KeyUsageKeyEncipherment
+	}
+
+	var commonName string
+	if len(csr.DNSNames) != 0 {
+		commonName = csr.DNSNames[0]
+	}
+
+	notBefore := time.Now().Add(-time.Minute)
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    notBefore,
+		NotAfter:     notBefore.Add(validity),
+		KeyUsage:     keyUsage,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     csr.DNSNames,
+		IPAddresses:  csr.IPAddresses,
+	}
+
+	// As with Let's Encrypt when it supported OCSP, a requested TLS feature extension (Must-Staple) is copied.
+	for _, extension := range csr.Extensions {
+		if extension.Id.Equal(letsencryptUtilsCertificate.TlsFeatureOid) {
+			template.ExtraExtensions = append(template.ExtraExtensions, extension)
+		}
+	}
+
+	derData, err := x509.CreateCertificate(rand.Reader, template, ca.certificate, csr.PublicKey, ca.key)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when issuing the certificate.", Cause: err}
+	}
+
+	return derData, nil
+}
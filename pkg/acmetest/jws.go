@@ -0,0 +1,216 @@
+package acmetest
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+)
+
+// flattenedJws is a JWS in the flattened JSON serialization, which is the form of all ACME requests.
+type flattenedJws struct {
+	Protected string `json:"protected"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+type protectedHeader struct {
+	Alg   string          `json:"alg"`
+	Jwk   json.RawMessage `json:"jwk,omitempty"`
+	Kid   string          `json:"kid,omitempty"`
+	Nonce string          `json:"nonce,omitempty"`
+	Url   string          `json:"url"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+var (
+	errMalformedJws       = errors.New("the JWS is malformed")
+	errUnsupportedKey     = errors.New("the key type is unsupported")
+	errUnsupportedAlg     = errors.New("the signature algorithm is unsupported")
+	errSignatureInvalid   = errors.New("the JWS signature is invalid")
+	errAlgorithmMismatch  = errors.New("the signature algorithm does not match the key")
+	errKeyCoordinateRange = errors.New("the key coordinates are out of range")
+)
+
+// decodeJws decodes a flattened JWS and its protected header, without verifying its signature.
+func decodeJws(data []byte) (*flattenedJws, *protectedHeader, []byte, error) {
+	var jws flattenedJws
+	if err := json.Unmarshal(data, &jws); err != nil {
+		return nil, nil, nil, errors.Join(errMalformedJws, err)
+	}
+
+	protectedData, err := base64.RawURLEncoding.DecodeString(jws.Protected)
+	if err != nil {
+		return nil, nil, nil, errors.Join(errMalformedJws, err)
+	}
+
+	var header protectedHeader
+	if err := json.Unmarshal(protectedData, &header); err != nil {
+		return nil, nil, nil, errors.Join(errMalformedJws, err)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(jws.Payload)
+	if err != nil {
+		return nil, nil, nil, errors.Join(errMalformedJws, err)
+	}
+
+	return &jws, &header, payload, nil
+}
+
+// parseJwk decodes an RSA or EC public key in JWK form.
+func parseJwk(data []byte) (crypto.PublicKey, error) {
+	var jwk jsonWebKey
+	if err := json.Unmarshal(data, &jwk); err != nil {
+		return nil, errors.Join(errMalformedJws, err)
+	}
+
+	decode := func(value string) (*big.Int, error) {
+		data, err := base64.RawURLEncoding.DecodeString(value)
+		if err != nil {
+			return nil, errors.Join(errMalformedJws, err)
+		}
+		return new(big.Int).SetBytes(data), nil
+	}
+
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decode(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errUnsupportedKey
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		var ecdhCurve ecdh.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+		case "P-384":
+			curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+		case "P-521":
+			curve, ecdhCurve = elliptic.P521(), ecdh.P521()
+		default:
+			return nil, errUnsupportedKey
+		}
+		x, err := decode(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		// Have the point validated by decoding it as an ECDH public key.
+		size := (curve.Params().BitSize + 7) / 8
+		if x.BitLen() > size*8 || y.BitLen() > size*8 {
+			return nil, errKeyCoordinateRange
+		}
+		point := make([]byte, 1+2*size)
+		point[0] = 4
+		x.FillBytes(point[1 : 1+size])
+		y.FillBytes(point[1+size:])
+		if _, err := ecdhCurve.NewPublicKey(point); err != nil {
+			return nil, errors.Join(errUnsupportedKey, err)
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, errUnsupportedKey
+	}
+}
+
+// verifyJws verifies the signature of the JWS with the public key.
+func verifyJws(jws *flattenedJws, header *protectedHeader, publicKey crypto.PublicKey) error {
+	signature, err := base64.RawURLEncoding.DecodeString(jws.Signature)
+	if err != nil {
+		return errors.Join(errMalformedJws, err)
+	}
+	signingInput := []byte(jws.Protected + "." + jws.Payload)
+
+	switch typedKey := publicKey.(type) {
+	case *rsa.PublicKey:
+		if header.Alg != "RS256" {
+			return errAlgorithmMismatch
+		}
+		digest := sha256.Sum256(signingInput)
+		if err := rsa.VerifyPKCS1v15(typedKey, crypto.SHA256, digest[:], signature); err != nil {
+			return errors.Join(errSignatureInvalid, err)
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		var digest []byte
+		switch header.Alg {
+		case "ES256":
+			sum := sha256.Sum256(signingInput)
+			digest = sum[:]
+		case "ES384":
+			sum := sha512.Sum384(signingInput)
+			digest = sum[:]
+		case "ES512":
+			sum := sha512.Sum512(signingInput)
+			digest = sum[:]
+		default:
+			return errUnsupportedAlg
+		}
+		bitSize := typedKey.Curve.Params().BitSize
+		if map[int]string{256: "ES256", 384: "ES384", 521: "ES512"}[bitSize] != header.Alg {
+			return errAlgorithmMismatch
+		}
+
+		size := (bitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errSignatureInvalid
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(typedKey, digest, r, s) {
+			return errSignatureInvalid
+		}
+		return nil
+	default:
+		return errUnsupportedKey
+	}
+}
+
+// verifyMacJws verifies the HS256 signature of an external account binding JWS with the HMAC key.
+func verifyMacJws(jws *flattenedJws, header *protectedHeader, hmacKey []byte) error {
+	if header.Alg != "HS256" {
+		return errUnsupportedAlg
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(jws.Signature)
+	if err != nil {
+		return errors.Join(errMalformedJws, err)
+	}
+
+	mac := hmac.New(sha256.New, hmacKey)
+	mac.Write([]byte(jws.Protected + "." + jws.Payload))
+	if !hmac.Equal(mac.Sum(nil), signature) {
+		return errSignatureInvalid
+	}
+	return nil
+}
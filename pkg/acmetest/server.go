@@ -0,0 +1,1390 @@
+// Package acmetest provides an in-process ACME server for tests.
+//
+// The server implements the subset of RFC 8555 that is used by this module: the directory, nonces, accounts (with
+// external account binding), key rollover, orders (with profiles), authorizations, challenges, finalization,
+// certificate retrieval, and revocation. Requests are authenticated as a real CA would, but challenges are validated
+// by a Validator rather than over the network, and certificates are issued by a throwaway CA.
+package acmetest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/acme"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultCertificateValidity = 90 * 24 * time.Hour
+
+	problemTypePrefix  = "urn:ietf:params:acme:error:"
+	nonceHeader        = "Replay-Nonce"
+	joseContentType    = "application/jose+json"
+	maxRequestBodySize = 1 << 20
+	orderLifetime      = 7 * 24 * time.Hour
+)
+
+const (
+	pathDirectory     = "/directory"
+	pathNewNonce      = "/new-nonce"
+	pathNewAccount    = "/new-account"
+	pathNewOrder      = "/new-order"
+	pathKeyChange     = "/key-change"
+	pathRevokeCert    = "/revoke-cert"
+	pathAccount       = "/account/"
+	pathOrder         = "/order/"
+	pathAuthorization = "/authorization/"
+	pathChallenge     = "/challenge/"
+	pathCertificate   = "/certificate/"
+	finalizeSuffix    = "/finalize"
+)
+
+// Validator decides whether a challenge has been fulfilled. The key authorization is in the form expected by the
+// challenge type, e.g. the HTTP response body for HTTP-01 and the TXT record value for DNS-01, as passed to solvers.
+// A nil error marks the challenge as valid. The validator is called with the server locked, so it must not make
+// requests to the server.
+type Validator func(challengeType string, identifier string, token string, keyAuthorization string) error
+
+type Config struct {
+	// TermsOfService, if set, is the terms of service URL advertised in the directory, which new accounts must agree to.
+	TermsOfService string
+	// Profiles maps the names of the certificate profiles offered by the server to their descriptions.
+	Profiles map[string]string
+	// ExternalAccountBindings maps key identifiers to HMAC keys. If any are set, new accounts must be bound to one.
+	ExternalAccountBindings map[string][]byte
+	// Validator, if set, validates challenges. Otherwise, all challenges are considered fulfilled.
+	Validator Validator
+	// CertificateValidity is the validity period of issued certificates.
+	CertificateValidity time.Duration
+	// FaultInjector, if set, decides whether requests fail with a fault rather than being handled.
+	FaultInjector FaultInjector
+}
+
+// Fault is an error response with which the server fails a request rather than handling it.
+type Fault struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// ProblemType is the ACME problem type, without the urn:ietf:params:acme:error: prefix.
+	ProblemType string
+	Detail      string
+	// RetryAfter, if non-zero, is sent in the Retry-After header, in whole seconds.
+	RetryAfter time.Duration
+}
+
+// FaultInjector decides whether a request fails with a fault, e.g. to simulate a rate limit or an outage of the CA.
+// A nil fault lets the request be handled. The injector is called before the request is authenticated, and without
+// the server locked.
+type FaultInjector func(request *http.Request) *Fault
+
+type Option func(*Config)
+
+// WithTermsOfService advertises a terms of service URL, which new accounts must agree to.
+func WithTermsOfService(termsOfService string) Option {
+	return func(config *Config) {
+		config.TermsOfService = termsOfService
+	}
+}
+
+// WithProfile offers a certificate profile.
+func WithProfile(name string, description string) Option {
+	return func(config *Config) {
+		if config.Profiles == nil {
+			config.Profiles = make(map[string]string)
+		}
+		config.Profiles[name] = description
+	}
+}
+
+// WithExternalAccountBinding accepts an external account binding key and requires new accounts to be bound to one.
+func WithExternalAccountBinding(keyId string, hmacKey []byte) Option {
+	return func(config *Config) {
+		if config.ExternalAccountBindings == nil {
+			config.ExternalAccountBindings = make(map[string][]byte)
+		}
+		config.ExternalAccountBindings[keyId] = hmacKey
+	}
+}
+
+// WithValidator validates challenges with the validator rather than considering all of them fulfilled.
+func WithValidator(validator Validator) Option {
+	return func(config *Config) {
+		config.Validator = validator
+	}
+}
+
+// WithFaultInjector fails the requests for which the injector returns a fault.
+func WithFaultInjector(injector FaultInjector) Option {
+	return func(config *Config) {
+		config.FaultInjector = injector
+	}
+}
+
+// WithCertificateValidity sets the validity period of issued certificates.
+func WithCertificateValidity(validity time.Duration) Option {
+	return func(config *Config) {
+		config.CertificateValidity = validity
+	}
+}
+
+// Account is a snapshot of an account registered with the server.
+type Account struct {
+	Uri                  string
+	Status               string
+	Contacts             []string
+	Key                  crypto.PublicKey
+	TermsOfServiceAgreed bool
+}
+
+type identifier struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type problem struct {
+	Type   string `json:"type"`
+	Detail string `json:"detail,omitempty"`
+	Status int    `json:"status,omitempty"`
+}
+
+type account struct {
+	Account
+	thumbprint string
+}
+
+type order struct {
+	id                string
+	accountUri        string
+	status            string
+	expires           time.Time
+	identifiers       []identifier
+	profile           string
+	authorizationIds  []string
+	certificateId     string
+	notBefore         string
+	notAfter          string
+	finalizationError *problem
+}
+
+type authorization struct {
+	id           string
+	accountUri   string
+	status       string
+	expires      time.Time
+	identifier   identifier
+	wildcard     bool
+	challengeIds []string
+}
+
+type challenge struct {
+	id              string
+	authorizationId string
+	challengeType   string
+	token           string
+	status          string
+	validated       time.Time
+	error           *problem
+}
+
+type certificate struct {
+	accountUri string
+	derChain   [][]byte
+	revoked    bool
+}
+
+// Server is an in-process ACME server backed by an httptest server.
+type Server struct {
+	config     *Config
+	ca         *certificateAuthority
+	httpServer *httptest.Server
+
+	mutex          sync.Mutex
+	nextId         int
+	nonces         map[string]struct{}
+	accounts       map[string]*account
+	keyAccounts    map[string]*account
+	orders         map[string]*order
+	authorizations map[string]*authorization
+	challenges     map[string]*challenge
+	certificates   map[string]*certificate
+}
+
+// NewServer starts an ACME server listening on a loopback address. The server is to be stopped with Close.
+func NewServer(options ...Option) (*Server, error) {
+	config := &Config{CertificateValidity: DefaultCertificateValidity}
+	for _, option := range options {
+		if option != nil {
+			option(config)
+		}
+	}
+
+	ca, err := newCertificateAuthority()
+	if err != nil {
+		return nil, err
+	}
+
+	server := &Server{
+		config:         config,
+		ca:             ca,
+		nonces:         make(map[string]struct{}),
+		accounts:       make(map[string]*account),
+		keyAccounts:    make(map[string]*account),
+		orders:         make(map[string]*order),
+		authorizations: make(map[string]*authorization),
+		challenges:     make(map[string]*challenge),
+		certificates:   make(map[string]*certificate),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+pathDirectory, server.handleDirectory)
+	mux.HandleFunc(pathNewNonce, server.handleNewNonce)
+	mux.HandleFunc("POST "+pathNewAccount, server.handleNewAccount)
+	mux.HandleFunc("POST "+pathKeyChange, server.handleKeyChange)
+	mux.HandleFunc("POST "+pathNewOrder, server.handleNewOrder)
+	mux.HandleFunc("POST "+pathRevokeCert, server.handleRevokeCert)
+	mux.HandleFunc("POST "+pathAccount+"{id}", server.handleAccount)
+	mux.HandleFunc("POST "+pathOrder+"{id}", server.handleOrder)
+	mux.HandleFunc("POST "+pathOrder+"{id}"+finalizeSuffix, server.handleFinalize)
+	mux.HandleFunc("POST "+pathAuthorization+"{id}", server.handleAuthorization)
+	mux.HandleFunc("POST "+pathChallenge+"{id}", server.handleChallenge)
+	mux.HandleFunc("POST "+pathCertificate+"{id}", server.handleCertificate)
+
+	server.httpServer = httptest.NewServer(
+		http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+			// Every response carries a fresh nonce, as with a real CA.
+			responseWriter.Header().Set(nonceHeader, server.newNonce())
+			responseWriter.Header().Set("Cache-Control", "no-store")
+
+			if injector := server.config.FaultInjector; injector != nil {
+				if fault := injector(request); fault != nil {
+					if fault.RetryAfter > 0 {
+						responseWriter.Header().Set(
+							"Retry-After",
+							strconv.FormatInt(int64(fault.RetryAfter/time.Second), 10),
+						)
+					}
+					writeProblem(responseWriter, fault.StatusCode, fault.ProblemType, fault.Detail)
+					return
+				}
+			}
+
+			mux.ServeHTTP(responseWriter, request)
+		}),
+	)
+
+	return server, nil
+}
+
+// Close shuts down the server.
+func (server *Server) Close() {
+	server.httpServer.Close()
+}
+
+// DirectoryUrl returns the URL of the directory resource of the server.
+func (server *Server) DirectoryUrl() string {
+	return server.url(pathDirectory)
+}
+
+// CaCertificate returns the certificate of the CA that issues the certificates of the server.
+func (server *Server) CaCertificate() *x509.Certificate {
+	return server.ca.certificate
+}
+
+// Account returns a snapshot of the account with the URI, or nil if there is no such account.
+func (server *Server) Account(uri string) *Account {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	account, ok := server.accounts[uri]
+	if !ok {
+		return nil
+	}
+
+	snapshot := account.Account
+	snapshot.Contacts = slices.Clone(account.Contacts)
+	return &snapshot
+}
+
+// IsRevoked reports whether the certificate has been issued by the server and revoked since.
+func (server *Server) IsRevoked(leaf *x509.Certificate) bool {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if leaf == nil {
+		return false
+	}
+	issued, ok := server.certificates[leaf.SerialNumber.String()]
+	return ok && issued.revoked
+}
+
+func (server *Server) url(path string) string {
+	return server.httpServer.URL + path
+}
+
+func (server *Server) newId() string {
+	server.nextId++
+	return strconv.Itoa(server.nextId)
+}
+
+func randomToken() string {
+	data := make([]byte, 32)
+	// crypto/rand.Read does not fail.
+	_, _ = rand.Read(data)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (server *Server) newNonce() string {
+	nonce := randomToken()
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.nonces[nonce] = struct{}{}
+
+	return nonce
+}
+
+func writeJson(responseWriter http.ResponseWriter, statusCode int, value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		http.Error(responseWriter, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	responseWriter.Header().Set("Content-Type", "application/json")
+	responseWriter.WriteHeader(statusCode)
+	_, _ = responseWriter.Write(data)
+}
+
+func writeProblem(responseWriter http.ResponseWriter, statusCode int, problemType string, detail string) {
+	data, _ := json.Marshal(problem{Type: problemTypePrefix + problemType, Detail: detail, Status: statusCode})
+	responseWriter.Header().Set("Content-Type", "application/problem+json")
+	responseWriter.WriteHeader(statusCode)
+	_, _ = responseWriter.Write(data)
+}
+
+func (server *Server) handleDirectory(responseWriter http.ResponseWriter, _ *http.Request) {
+	meta := map[string]any{}
+	if server.config.TermsOfService != "" {
+		meta["termsOfService"] = server.config.TermsOfService
+	}
+	if len(server.config.Profiles) != 0 {
+		meta["profiles"] = server.config.Profiles
+	}
+	if len(server.config.ExternalAccountBindings) != 0 {
+		meta["externalAccountRequired"] = true
+	}
+
+	writeJson(
+		responseWriter,
+		http.StatusOK,
+		map[string]any{
+			"newNonce":   server.url(pathNewNonce),
+			"newAccount": server.url(pathNewAccount),
+			"newOrder":   server.url(pathNewOrder),
+			"revokeCert": server.url(pathRevokeCert),
+			"keyChange":  server.url(pathKeyChange),
+			"meta":       meta,
+		},
+	)
+}
+
+func (server *Server) handleNewNonce(responseWriter http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodHead:
+		responseWriter.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		responseWriter.WriteHeader(http.StatusNoContent)
+	default:
+		responseWriter.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// signedRequest is an authenticated ACME request.
+type signedRequest struct {
+	header  *protectedHeader
+	payload []byte
+	key     crypto.PublicKey
+	// account is the account of the request, which is set for requests identified with a key ID, and for requests
+	// with a JWK if an account with the key exists.
+	account *account
+}
+
+type keyIdentification int
+
+const (
+	requireKeyId keyIdentification = iota
+	requireJwk
+	allowJwk
+)
+
+// authenticate decodes the JWS of the request and verifies its URL, nonce and signature. On failure, a problem is
+// written and nil is returned. The caller must hold the mutex.
+func (server *Server) authenticate(
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+	identification keyIdentification,
+) *signedRequest {
+	if contentType := request.Header.Get("Content-Type"); contentType != joseContentType {
+		writeProblem(responseWriter, http.StatusUnsupportedMediaType, "malformed", "The content type is not JOSE.")
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(request.Body, maxRequestBodySize))
+	if err != nil {
+		writeProblem(responseWriter, http.StatusBadRequest, "malformed", "The request body could not be read.")
+		return nil
+	}
+
+	jws, header, payload, err := decodeJws(body)
+	if err != nil {
+		writeProblem(responseWriter, http.StatusBadRequest, "malformed", err.Error())
+		return nil
+	}
+
+	if header.Url != server.url(request.URL.Path) {
+		writeProblem(responseWriter, http.StatusUnauthorized, "unauthorized", "The JWS URL does not match the request.")
+		return nil
+	}
+
+	if _, ok := server.nonces[header.Nonce]; !ok {
+		writeProblem(responseWriter, http.StatusBadRequest, "badNonce", "The JWS nonce is unknown or already used.")
+		return nil
+	}
+	delete(server.nonces, header.Nonce)
+
+	signed := &signedRequest{header: header, payload: payload}
+	switch {
+	case len(header.Jwk) != 0 && header.Kid == "":
+		if identification == requireKeyId {
+			writeProblem(responseWriter, http.StatusBadRequest, "malformed", "The request must be identified by a key ID.")
+			return nil
+		}
+		key, err := parseJwk(header.Jwk)
+		if err != nil {
+			writeProblem(responseWriter, http.StatusBadRequest, "badPublicKey", err.Error())
+			return nil
+		}
+		thumbprint, err := acme.JWKThumbprint(key)
+		if err != nil {
+			writeProblem(responseWriter, http.StatusBadRequest, "badPublicKey", err.Error())
+			return nil
+		}
+		signed.key = key
+		signed.account = server.keyAccounts[thumbprint]
+	case len(header.Jwk) == 0 && header.Kid != "":
+		if identification == requireJwk {
+			writeProblem(responseWriter, http.StatusBadRequest, "malformed", "The request must include a JWK.")
+			return nil
+		}
+		account, ok := server.accounts[header.Kid]
+		if !ok {
+			writeProblem(responseWriter, http.StatusBadRequest, "accountDoesNotExist", "The account does not exist.")
+			return nil
+		}
+		signed.key = account.Key
+		signed.account = account
+	default:
+		writeProblem(responseWriter, http.StatusBadRequest, "malformed", "Exactly one of jwk and kid must be set.")
+		return nil
+	}
+
+	if err := verifyJws(jws, header, signed.key); err != nil {
+		writeProblem(responseWriter, http.StatusBadRequest, "malformed", err.Error())
+		return nil
+	}
+
+	if header.Kid != "" && signed.account.Status != acme.StatusValid {
+		writeProblem(responseWriter, http.StatusUnauthorized, "unauthorized", "The account is not valid.")
+		return nil
+	}
+
+	return signed
+}
+
+// isPostAsGet reports whether the payload is that of a POST-as-GET request, i.e. empty.
+func isPostAsGet(payload []byte) bool {
+	return len(payload) == 0
+}
+
+func validateContacts(contacts []string) *problem {
+	for _, contact := range contacts {
+		address, ok := strings.CutPrefix(contact, "mailto:")
+		if !ok {
+			return &problem{Type: "unsupportedContact", Detail: fmt.Sprintf("The contact %q is not a mailto URI.", contact)}
+		}
+		localPart, domain, ok := strings.Cut(address, "@")
+		if !ok || localPart == "" || domain == "" || strings.ContainsAny(address, ",;") {
+			return &problem{Type: "invalidContact", Detail: fmt.Sprintf("The contact %q is invalid.", contact)}
+		}
+	}
+	return nil
+}
+
+func (server *Server) writeAccount(responseWriter http.ResponseWriter, statusCode int, account *account) {
+	responseWriter.Header().Set("Location", account.Uri)
+	writeJson(
+		responseWriter,
+		statusCode,
+		map[string]any{
+			"status":               account.Status,
+			"contact":              account.Contacts,
+			"termsOfServiceAgreed": account.TermsOfServiceAgreed,
+		},
+	)
+}
+
+// verifyExternalAccountBinding verifies that the external account binding JWS is signed with a known HMAC key, and
+// that it binds the account key.
+func (server *Server) verifyExternalAccountBinding(data json.RawMessage, accountKey crypto.PublicKey) *problem {
+	if len(data) == 0 {
+		return &problem{Type: "externalAccountRequired", Detail: "An external account binding is required."}
+	}
+
+	jws, header, payload, err := decodeJws(data)
+	if err != nil {
+		return &problem{Type: "malformed", Detail: err.Error()}
+	}
+
+	hmacKey, ok := server.config.ExternalAccountBindings[header.Kid]
+	if !ok {
+		return &problem{Type: "unauthorized", Detail: "The external account binding key ID is unknown."}
+	}
+	if header.Url != server.url(pathNewAccount) {
+		return &problem{Type: "malformed", Detail: "The external account binding URL is incorrect."}
+	}
+	if err := verifyMacJws(jws, header, hmacKey); err != nil {
+		return &problem{Type: "unauthorized", Detail: err.Error()}
+	}
+
+	boundKey, err := parseJwk(payload)
+	if err != nil {
+		return &problem{Type: "malformed", Detail: err.Error()}
+	}
+	boundThumbprint, err := acme.JWKThumbprint(boundKey)
+	if err != nil {
+		return &problem{Type: "malformed", Detail: err.Error()}
+	}
+	accountThumbprint, err := acme.JWKThumbprint(accountKey)
+	if err != nil || boundThumbprint != accountThumbprint {
+		return &problem{Type: "unauthorized", Detail: "The external account binding does not bind the account key."}
+	}
+
+	return nil
+}
+
+func (server *Server) handleNewAccount(responseWriter http.ResponseWriter, request *http.Request) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	signed := server.authenticate(responseWriter, request, requireJwk)
+	if signed == nil {
+		return
+	}
+
+	var newAccountRequest struct {
+		Contact                []string        `json:"contact"`
+		TermsOfServiceAgreed   bool            `json:"termsOfServiceAgreed"`
+		OnlyReturnExisting     bool            `json:"onlyReturnExisting"`
+		ExternalAccountBinding json.RawMessage `json:"externalAccountBinding"`
+	}
+	if err := json.Unmarshal(signed.payload, &newAccountRequest); err != nil {
+		writeProblem(responseWriter, http.StatusBadRequest, "malformed", "The payload could not be decoded.")
+		return
+	}
+
+	if existing := signed.account; existing != nil {
+		if existing.Status != acme.StatusValid {
+			writeProblem(responseWriter, http.StatusUnauthorized, "unauthorized", "The account is not valid.")
+			return
+		}
+		server.writeAccount(responseWriter, http.StatusOK, existing)
+		return
+	}
+
+	if newAccountRequest.OnlyReturnExisting {
+		writeProblem(responseWriter, http.StatusBadRequest, "accountDoesNotExist", "No account exists with the key.")
+		return
+	}
+
+	if server.config.TermsOfService != "" && !newAccountRequest.TermsOfServiceAgreed {
+		writeProblem(responseWriter, http.StatusBadRequest, "malformed", "The terms of service must be agreed to.")
+		return
+	}
+
+	if contactProblem := validateContacts(newAccountRequest.Contact); contactProblem != nil {
+		writeProblem(responseWriter, http.StatusBadRequest, contactProblem.Type, contactProblem.Detail)
+		return
+	}
+
+	if len(server.config.ExternalAccountBindings) != 0 {
+		bindingProblem := server.verifyExternalAccountBinding(newAccountRequest.ExternalAccountBinding, signed.key)
+		if bindingProblem != nil {
+			statusCode := http.StatusBadRequest
+			if bindingProblem.Type == "unauthorized" {
+				statusCode = http.StatusUnauthorized
+			}
+			writeProblem(responseWriter, statusCode, bindingProblem.Type, bindingProblem.Detail)
+			return
+		}
+	}
+
+	thumbprint, err := acme.JWKThumbprint(signed.key)
+	if err != nil {
+		writeProblem(responseWriter, http.StatusBadRequest, "badPublicKey", err.Error())
+		return
+	}
+
+	newAccount := &account{
+		Account: Account{
+			Uri:                  server.url(pathAccount + server.newId()),
+			Status:               acme.StatusValid,
+			Contacts:             newAccountRequest.Contact,
+			Key:                  signed.key,
+			TermsOfServiceAgreed: newAccountRequest.TermsOfServiceAgreed,
+		},
+		thumbprint: thumbprint,
+	}
+	server.accounts[newAccount.Uri] = newAccount
+	server.keyAccounts[thumbprint] = newAccount
+
+	server.writeAccount(responseWriter, http.StatusCreated, newAccount)
+}
+
+func (server *Server) handleAccount(responseWriter http.ResponseWriter, request *http.Request) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	signed := server.authenticate(responseWriter, request, requireKeyId)
+	if signed == nil {
+		return
+	}
+
+	if signed.account.Uri != server.url(request.URL.Path) {
+		writeProblem(responseWriter, http.StatusForbidden, "unauthorized", "The account belongs to another key.")
+		return
+	}
+
+	if !isPostAsGet(signed.payload) {
+		var updateRequest struct {
+			Contact *[]string `json:"contact"`
+			Status  string    `json:"status"`
+		}
+		if err := json.Unmarshal(signed.payload, &updateRequest); err != nil {
+			writeProblem(responseWriter, http.StatusBadRequest, "malformed", "The payload could not be decoded.")
+			return
+		}
+
+		switch updateRequest.Status {
+		case "", acme.StatusValid:
+		case acme.StatusDeactivated:
+			signed.account.Status = acme.StatusDeactivated
+		default:
+			writeProblem(responseWriter, http.StatusBadRequest, "malformed", "The account status cannot be set.")
+			return
+		}
+
+		if updateRequest.Contact != nil {
+			if contactProblem := validateContacts(*updateRequest.Contact); contactProblem != nil {
+				writeProblem(responseWriter, http.StatusBadRequest, contactProblem.Type, contactProblem.Detail)
+				return
+			}
+			signed.account.Contacts = *updateRequest.Contact
+		}
+	}
+
+	server.writeAccount(responseWriter, http.StatusOK, signed.account)
+}
+
+func (server *Server) handleKeyChange(responseWriter http.ResponseWriter, request *http.Request) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	signed := server.authenticate(responseWriter, request, requireKeyId)
+	if signed == nil {
+		return
+	}
+
+	innerJws, innerHeader, innerPayload, err := decodeJws(signed.payload)
+	if err != nil {
+		writeProblem(responseWriter, http.StatusBadRequest, "malformed", err.Error())
+		return
+	}
+	if len(innerHeader.Jwk) == 0 || innerHeader.Kid != "" {
+		writeProblem(responseWriter, http.StatusBadRequest, "malformed", "The inner JWS must include a JWK.")
+		return
+	}
+	if innerHeader.Url != signed.header.Url {
+		writeProblem(responseWriter, http.StatusBadRequest, "malformed", "The inner JWS URL does not match.")
+		return
+	}
+
+	newKey, err := parseJwk(innerHeader.Jwk)
+	if err != nil {
+		writeProblem(responseWriter, http.StatusBadRequest, "badPublicKey", err.Error())
+		return
+	}
+	if err := verifyJws(innerJws, innerHeader, newKey); err != nil {
+		writeProblem(responseWriter, http.StatusBadRequest, "malformed", err.Error())
+		return
+	}
+
+	var keyChangeRequest struct {
+		Account string          `json:"account"`
+		OldKey  json.RawMessage `json:"oldKey"`
+	}
+	if err := json.Unmarshal(innerPayload, &keyChangeRequest); err != nil {
+		writeProblem(responseWriter, http.StatusBadRequest, "malformed", "The inner payload could not be decoded.")
+		return
+	}
+	if keyChangeRequest.Account != signed.account.Uri {
+		writeProblem(responseWriter, http.StatusBadRequest, "malformed", "The inner payload account does not match.")
+		return
+	}
+
+	oldKey, err := parseJwk(keyChangeRequest.OldKey)
+	if err != nil {
+		writeProblem(responseWriter, http.StatusBadRequest, "malformed", err.Error())
+		return
+	}
+	if oldThumbprint, err := acme.JWKThumbprint(oldKey); err != nil || oldThumbprint != signed.account.thumbprint {
+		writeProblem(responseWriter, http.StatusBadRequest, "malformed", "The old key is not the account key.")
+		return
+	}
+
+	newThumbprint, err := acme.JWKThumbprint(newKey)
+	if err != nil {
+		writeProblem(responseWriter, http.StatusBadRequest, "badPublicKey", err.Error())
+		return
+	}
+	if existing, ok := server.keyAccounts[newThumbprint]; ok {
+		responseWriter.Header().Set("Location", existing.Uri)
+		writeProblem(responseWriter, http.StatusConflict, "malformed", "The new key is in use by an account.")
+		return
+	}
+
+	delete(server.keyAccounts, signed.account.thumbprint)
+	signed.account.Key = newKey
+	signed.account.thumbprint = newThumbprint
+	server.keyAccounts[newThumbprint] = signed.account
+
+	server.writeAccount(responseWriter, http.StatusOK, signed.account)
+}
+
+// normalizeIdentifier validates an order identifier and returns it in canonical form.
+func normalizeIdentifier(orderIdentifier identifier) (identifier, *problem) {
+	switch orderIdentifier.Type {
+	case "dns":
+		value := strings.ToLower(strings.TrimSuffix(orderIdentifier.Value, "."))
+		name := strings.TrimPrefix(value, "*.")
+		if name == "" || strings.Contains(name, "*") || net.ParseIP(name) != nil || !strings.Contains(name, ".") {
+			return identifier{}, &problem{
+				Type:   "rejectedIdentifier",
+				Detail: fmt.Sprintf("The DNS identifier %q is unacceptable.", orderIdentifier.Value),
+			}
+		}
+		return identifier{Type: "dns", Value: value}, nil
+	case "ip":
+		ip := net.ParseIP(orderIdentifier.Value)
+		if ip == nil {
+			return identifier{}, &problem{
+				Type:   "malformed",
+				Detail: fmt.Sprintf("The IP identifier %q is invalid.", orderIdentifier.Value),
+			}
+		}
+		return identifier{Type: "ip", Value: ip.String()}, nil
+	default:
+		return identifier{}, &problem{
+			Type:   "unsupportedIdentifier",
+			Detail: fmt.Sprintf("The identifier type %q is unsupported.", orderIdentifier.Type),
+		}
+	}
+}
+
+// challengeTypes returns the challenge types offered for an identifier.
+func challengeTypes(orderIdentifier identifier, wildcard bool) []string {
+	switch {
+	case wildcard:
+		return []string{"dns-01"}
+	case orderIdentifier.Type == "ip":
+		return []string{"http-01", "tls-alpn-01"}
+	default:
+		return []string{"http-01", "dns-01", "tls-alpn-01"}
+	}
+}
+
+func (server *Server) handleNewOrder(responseWriter http.ResponseWriter, request *http.Request) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	signed := server.authenticate(responseWriter, request, requireKeyId)
+	if signed == nil {
+		return
+	}
+
+	var newOrderRequest struct {
+		Identifiers []identifier `json:"identifiers"`
+		NotBefore   string       `json:"notBefore"`
+		NotAfter    string       `json:"notAfter"`
+		Profile     string       `json:"profile"`
+	}
+	if err := json.Unmarshal(signed.payload, &newOrderRequest); err != nil {
+		writeProblem(responseWriter, http.StatusBadRequest, "malformed", "The payload could not be decoded.")
+		return
+	}
+
+	if len(newOrderRequest.Identifiers) == 0 {
+		writeProblem(responseWriter, http.StatusBadRequest, "malformed", "The order has no identifiers.")
+		return
+	}
+
+	if profile := newOrderRequest.Profile; profile != "" {
+		if _, ok := server.config.Profiles[profile]; !ok {
+			writeProblem(
+				responseWriter,
+				http.StatusBadRequest,
+				"invalidProfile",
+				fmt.Sprintf("The profile %q is not offered.", profile),
+			)
+			return
+		}
+	}
+
+	var orderIdentifiers []identifier
+	for _, requestIdentifier := range newOrderRequest.Identifiers {
+		orderIdentifier, identifierProblem := normalizeIdentifier(requestIdentifier)
+		if identifierProblem != nil {
+			writeProblem(responseWriter, http.StatusBadRequest, identifierProblem.Type, identifierProblem.Detail)
+			return
+		}
+		if !slices.Contains(orderIdentifiers, orderIdentifier) {
+			orderIdentifiers = append(orderIdentifiers, orderIdentifier)
+		}
+	}
+
+	expires := time.Now().Add(orderLifetime).UTC()
+	newOrder := &order{
+		id:          server.newId(),
+		accountUri:  signed.account.Uri,
+		status:      acme.StatusPending,
+		expires:     expires,
+		identifiers: orderIdentifiers,
+		profile:     newOrderRequest.Profile,
+		notBefore:   newOrderRequest.NotBefore,
+		notAfter:    newOrderRequest.NotAfter,
+	}
+
+	for _, orderIdentifier := range orderIdentifiers {
+		authorizationIdentifier := orderIdentifier
+		wildcard := false
+		if orderIdentifier.Type == "dns" {
+			authorizationIdentifier.Value, wildcard = strings.CutPrefix(orderIdentifier.Value, "*.")
+		}
+
+		newAuthorization := &authorization{
+			id:         server.newId(),
+			accountUri: signed.account.Uri,
+			status:     acme.StatusPending,
+			expires:    expires,
+			identifier: authorizationIdentifier,
+			wildcard:   wildcard,
+		}
+		for _, challengeType := range challengeTypes(authorizationIdentifier, wildcard) {
+			newChallenge := &challenge{
+				id:              server.newId(),
+				authorizationId: newAuthorization.id,
+				challengeType:   challengeType,
+				token:           randomToken(),
+				status:          acme.StatusPending,
+			}
+			server.challenges[newChallenge.id] = newChallenge
+			newAuthorization.challengeIds = append(newAuthorization.challengeIds, newChallenge.id)
+		}
+		server.authorizations[newAuthorization.id] = newAuthorization
+		newOrder.authorizationIds = append(newOrder.authorizationIds, newAuthorization.id)
+	}
+	server.orders[newOrder.id] = newOrder
+
+	server.writeOrder(responseWriter, http.StatusCreated, newOrder)
+}
+
+// updateOrderStatus moves a pending order to ready once all its authorizations are valid, and to invalid once any of
+// them is not.
+func (server *Server) updateOrderStatus(pendingOrder *order) {
+	if pendingOrder.status != acme.StatusPending {
+		return
+	}
+
+	allValid := true
+	for _, authorizationId := range pendingOrder.authorizationIds {
+		switch server.authorizations[authorizationId].status {
+		case acme.StatusValid:
+		case acme.StatusPending:
+			allValid = false
+		default:
+			pendingOrder.status = acme.StatusInvalid
+			return
+		}
+	}
+	if allValid {
+		pendingOrder.status = acme.StatusReady
+	}
+}
+
+func (server *Server) writeOrder(responseWriter http.ResponseWriter, statusCode int, responseOrder *order) {
+	server.updateOrderStatus(responseOrder)
+
+	authorizationUrls := make([]string, 0, len(responseOrder.authorizationIds))
+	for _, authorizationId := range responseOrder.authorizationIds {
+		authorizationUrls = append(authorizationUrls, server.url(pathAuthorization+authorizationId))
+	}
+
+	orderUrl := server.url(pathOrder + responseOrder.id)
+	body := map[string]any{
+		"status":         responseOrder.status,
+		"expires":        responseOrder.expires.Format(time.RFC3339),
+		"identifiers":    responseOrder.identifiers,
+		"authorizations": authorizationUrls,
+		"finalize":       orderUrl + finalizeSuffix,
+	}
+	if responseOrder.profile != "" {
+		body["profile"] = responseOrder.profile
+	}
+	if responseOrder.notBefore != "" {
+		body["notBefore"] = responseOrder.notBefore
+	}
+	if responseOrder.notAfter != "" {
+		body["notAfter"] = responseOrder.notAfter
+	}
+	if responseOrder.certificateId != "" {
+		body["certificate"] = server.url(pathCertificate + responseOrder.certificateId)
+	}
+	if responseOrder.finalizationError != nil {
+		body["error"] = responseOrder.finalizationError
+	}
+
+	responseWriter.Header().Set("Location", orderUrl)
+	writeJson(responseWriter, statusCode, body)
+}
+
+// ownedOrder returns the order with the ID of the request path if it belongs to the account of the request. On
+// failure, a problem is written and nil is returned.
+func (server *Server) ownedOrder(
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+	signed *signedRequest,
+) *order {
+	requestedOrder, ok := server.orders[request.PathValue("id")]
+	if !ok {
+		writeProblem(responseWriter, http.StatusNotFound, "malformed", "The order does not exist.")
+		return nil
+	}
+	if requestedOrder.accountUri != signed.account.Uri {
+		writeProblem(responseWriter, http.StatusForbidden, "unauthorized", "The order belongs to another account.")
+		return nil
+	}
+	return requestedOrder
+}
+
+func (server *Server) handleOrder(responseWriter http.ResponseWriter, request *http.Request) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	signed := server.authenticate(responseWriter, request, requireKeyId)
+	if signed == nil {
+		return
+	}
+
+	requestedOrder := server.ownedOrder(responseWriter, request, signed)
+	if requestedOrder == nil {
+		return
+	}
+
+	if !isPostAsGet(signed.payload) {
+		writeProblem(responseWriter, http.StatusBadRequest, "malformed", "Orders can only be fetched.")
+		return
+	}
+
+	server.writeOrder(responseWriter, http.StatusOK, requestedOrder)
+}
+
+// csrIdentifiers returns the identifiers of the names in the CSR, sorted.
+func csrIdentifiers(csr *x509.CertificateRequest) []identifier {
+	var csrIdentifiers []identifier
+	add := func(csrIdentifier identifier) {
+		if !slices.Contains(csrIdentifiers, csrIdentifier) {
+			csrIdentifiers = append(csrIdentifiers, csrIdentifier)
+		}
+	}
+
+	for _, dnsName := range csr.DNSNames {
+		add(identifier{Type: "dns", Value: strings.ToLower(dnsName)})
+	}
+	for _, ipAddress := range csr.IPAddresses {
+		add(identifier{Type: "ip", Value: ipAddress.String()})
+	}
+	if commonName := csr.Subject.CommonName; commonName != "" {
+		if ip := net.ParseIP(commonName); ip != nil {
+			add(identifier{Type: "ip", Value: ip.String()})
+		} else {
+			add(identifier{Type: "dns", Value: strings.ToLower(commonName)})
+		}
+	}
+
+	slices.SortFunc(csrIdentifiers, compareIdentifiers)
+	return csrIdentifiers
+}
+
+func compareIdentifiers(a identifier, b identifier) int {
+	if typeComparison := strings.Compare(a.Type, b.Type); typeComparison != 0 {
+		return typeComparison
+	}
+	return strings.Compare(a.Value, b.Value)
+}
+
+func (server *Server) handleFinalize(responseWriter http.ResponseWriter, request *http.Request) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	signed := server.authenticate(responseWriter, request, requireKeyId)
+	if signed == nil {
+		return
+	}
+
+	requestedOrder := server.ownedOrder(responseWriter, request, signed)
+	if requestedOrder == nil {
+		return
+	}
+
+	server.updateOrderStatus(requestedOrder)
+	if requestedOrder.status != acme.StatusReady {
+		writeProblem(
+			responseWriter,
+			http.StatusForbidden,
+			"orderNotReady",
+			fmt.Sprintf("The order is %s rather than ready.", requestedOrder.status),
+		)
+		return
+	}
+
+	var finalizeRequest struct {
+		Csr string `json:"csr"`
+	}
+	if err := json.Unmarshal(signed.payload, &finalizeRequest); err != nil {
+		writeProblem(responseWriter, http.StatusBadRequest, "malformed", "The payload could not be decoded.")
+		return
+	}
+
+	csrData, err := base64.RawURLEncoding.DecodeString(finalizeRequest.Csr)
+	if err != nil {
+		writeProblem(responseWriter, http.StatusBadRequest, "badCSR", "The CSR could not be decoded.")
+		return
+	}
+	csr, err := x509.ParseCertificateRequest(csrData)
+	if err != nil {
+		writeProblem(responseWriter, http.StatusBadRequest, "badCSR", "The CSR could not be parsed.")
+		return
+	}
+	if err := csr.CheckSignature(); err != nil {
+		writeProblem(responseWriter, http.StatusBadRequest, "badCSR", "The CSR signature is invalid.")
+		return
+	}
+
+	if csrThumbprint, err := acme.JWKThumbprint(csr.PublicKey); err == nil && csrThumbprint == signed.account.thumbprint {
+		writeProblem(responseWriter, http.StatusBadRequest, "badCSR", "The CSR key is the account key.")
+		return
+	}
+
+	orderIdentifiers := slices.Clone(requestedOrder.identifiers)
+	slices.SortFunc(orderIdentifiers, compareIdentifiers)
+	if !slices.Equal(csrIdentifiers(csr), orderIdentifiers) {
+		writeProblem(responseWriter, http.StatusBadRequest, "badCSR", "The CSR names do not match the order.")
+		return
+	}
+
+	leafData, err := server.ca.issue(csr, server.config.CertificateValidity)
+	if err != nil {
+		requestedOrder.status = acme.StatusInvalid
+		requestedOrder.finalizationError = &problem{Type: problemTypePrefix + "serverInternal", Detail: err.Error()}
+		writeProblem(responseWriter, http.StatusInternalServerError, "serverInternal", err.Error())
+		return
+	}
+	leaf, err := x509.ParseCertificate(leafData)
+	if err != nil {
+		writeProblem(responseWriter, http.StatusInternalServerError, "serverInternal", err.Error())
+		return
+	}
+
+	certificateId := leaf.SerialNumber.String()
+	server.certificates[certificateId] = &certificate{
+		accountUri: signed.account.Uri,
+		derChain:   [][]byte{leafData, server.ca.certificate.Raw},
+	}
+	requestedOrder.certificateId = certificateId
+	requestedOrder.status = acme.StatusValid
+
+	server.writeOrder(responseWriter, http.StatusOK, requestedOrder)
+}
+
+func (server *Server) authorizationBody(responseAuthorization *authorization) map[string]any {
+	challenges := make([]map[string]any, 0, len(responseAuthorization.challengeIds))
+	for _, challengeId := range responseAuthorization.challengeIds {
+		challenges = append(challenges, server.challengeBody(server.challenges[challengeId]))
+	}
+
+	body := map[string]any{
+		"status":     responseAuthorization.status,
+		"expires":    responseAuthorization.expires.Format(time.RFC3339),
+		"identifier": responseAuthorization.identifier,
+		"challenges": challenges,
+	}
+	if responseAuthorization.wildcard {
+		body["wildcard"] = true
+	}
+	return body
+}
+
+func (server *Server) challengeBody(responseChallenge *challenge) map[string]any {
+	body := map[string]any{
+		"type":   responseChallenge.challengeType,
+		"url":    server.url(pathChallenge + responseChallenge.id),
+		"token":  responseChallenge.token,
+		"status": responseChallenge.status,
+	}
+	if !responseChallenge.validated.IsZero() {
+		body["validated"] = responseChallenge.validated.Format(time.RFC3339)
+	}
+	if responseChallenge.error != nil {
+		body["error"] = responseChallenge.error
+	}
+	return body
+}
+
+func (server *Server) handleAuthorization(responseWriter http.ResponseWriter, request *http.Request) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	signed := server.authenticate(responseWriter, request, requireKeyId)
+	if signed == nil {
+		return
+	}
+
+	requestedAuthorization, ok := server.authorizations[request.PathValue("id")]
+	if !ok {
+		writeProblem(responseWriter, http.StatusNotFound, "malformed", "The authorization does not exist.")
+		return
+	}
+	if requestedAuthorization.accountUri != signed.account.Uri {
+		writeProblem(
+			responseWriter,
+			http.StatusForbidden,
+			"unauthorized",
+			"The authorization belongs to another account.",
+		)
+		return
+	}
+
+	if !isPostAsGet(signed.payload) {
+		var updateRequest struct {
+			Status string `json:"status"`
+		}
+		if err := json.Unmarshal(signed.payload, &updateRequest); err != nil {
+			writeProblem(responseWriter, http.StatusBadRequest, "malformed", "The payload could not be decoded.")
+			return
+		}
+		if updateRequest.Status != acme.StatusDeactivated {
+			writeProblem(responseWriter, http.StatusBadRequest, "malformed", "The authorization status cannot be set.")
+			return
+		}
+		if requestedAuthorization.status != acme.StatusPending && requestedAuthorization.status != acme.StatusValid {
+			writeProblem(responseWriter, http.StatusBadRequest, "malformed", "The authorization cannot be deactivated.")
+			return
+		}
+		requestedAuthorization.status = acme.StatusDeactivated
+	}
+
+	writeJson(responseWriter, http.StatusOK, server.authorizationBody(requestedAuthorization))
+}
+
+// expectedKeyAuthorization returns the key authorization of the challenge in the form expected by its type.
+func expectedKeyAuthorization(challengeType string, token string, thumbprint string) string {
+	keyAuthorization := token + "." + thumbprint
+	if challengeType == "dns-01" {
+		digest := sha256.Sum256([]byte(keyAuthorization))
+		return base64.RawURLEncoding.EncodeToString(digest[:])
+	}
+	return keyAuthorization
+}
+
+func (server *Server) handleChallenge(responseWriter http.ResponseWriter, request *http.Request) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	signed := server.authenticate(responseWriter, request, requireKeyId)
+	if signed == nil {
+		return
+	}
+
+	requestedChallenge, ok := server.challenges[request.PathValue("id")]
+	if !ok {
+		writeProblem(responseWriter, http.StatusNotFound, "malformed", "The challenge does not exist.")
+		return
+	}
+	challengeAuthorization := server.authorizations[requestedChallenge.authorizationId]
+	if challengeAuthorization.accountUri != signed.account.Uri {
+		writeProblem(responseWriter, http.StatusForbidden, "unauthorized", "The challenge belongs to another account.")
+		return
+	}
+
+	responseWriter.Header().Add(
+		"Link",
+		fmt.Sprintf("<%s>;rel=\"up\"", server.url(pathAuthorization+challengeAuthorization.id)),
+	)
+
+	// A non-empty payload, which is to be an empty object, asks for the challenge to be validated.
+	if isPostAsGet(signed.payload) ||
+		requestedChallenge.status != acme.StatusPending ||
+		challengeAuthorization.status != acme.StatusPending {
+		writeJson(responseWriter, http.StatusOK, server.challengeBody(requestedChallenge))
+		return
+	}
+
+	var validationErr error
+	if validator := server.config.Validator; validator != nil {
+		validationErr = validator(
+			requestedChallenge.challengeType,
+			challengeAuthorization.identifier.Value,
+			requestedChallenge.token,
+			expectedKeyAuthorization(
+				requestedChallenge.challengeType,
+				requestedChallenge.token,
+				signed.account.thumbprint,
+			),
+		)
+	}
+
+	if validationErr != nil {
+		requestedChallenge.status = acme.StatusInvalid
+		requestedChallenge.error = &problem{
+			Type:   problemTypePrefix + "incorrectResponse",
+			Detail: validationErr.Error(),
+			Status: http.StatusForbidden,
+		}
+		challengeAuthorization.status = acme.StatusInvalid
+	} else {
+		requestedChallenge.status = acme.StatusValid
+		requestedChallenge.validated = time.Now().UTC()
+		challengeAuthorization.status = acme.StatusValid
+	}
+
+	writeJson(responseWriter, http.StatusOK, server.challengeBody(requestedChallenge))
+}
+
+func (server *Server) handleCertificate(responseWriter http.ResponseWriter, request *http.Request) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	signed := server.authenticate(responseWriter, request, requireKeyId)
+	if signed == nil {
+		return
+	}
+
+	issued, ok := server.certificates[request.PathValue("id")]
+	if !ok {
+		writeProblem(responseWriter, http.StatusNotFound, "malformed", "The certificate does not exist.")
+		return
+	}
+	if issued.accountUri != signed.account.Uri {
+		writeProblem(responseWriter, http.StatusForbidden, "unauthorized", "The certificate belongs to another account.")
+		return
+	}
+
+	var chainPem []byte
+	for _, derData := range issued.derChain {
+		chainPem = append(chainPem, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derData})...)
+	}
+
+	responseWriter.Header().Set("Content-Type", "application/pem-certificate-chain")
+	responseWriter.WriteHeader(http.StatusOK)
+	_, _ = responseWriter.Write(chainPem)
+}
+
+func (server *Server) handleRevokeCert(responseWriter http.ResponseWriter, request *http.Request) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	signed := server.authenticate(responseWriter, request, allowJwk)
+	if signed == nil {
+		return
+	}
+
+	var revokeRequest struct {
+		Certificate string `json:"certificate"`
+		Reason      int    `json:"reason"`
+	}
+	if err := json.Unmarshal(signed.payload, &revokeRequest); err != nil {
+		writeProblem(responseWriter, http.StatusBadRequest, "malformed", "The payload could not be decoded.")
+		return
+	}
+
+	// Reason code 7 is unused, and only the reasons up to aACompromise (10) are defined.
+	if revokeRequest.Reason < 0 || revokeRequest.Reason > 10 || revokeRequest.Reason == 7 {
+		writeProblem(responseWriter, http.StatusBadRequest, "badRevocationReason", "The revocation reason is invalid.")
+		return
+	}
+
+	leafData, err := base64.RawURLEncoding.DecodeString(revokeRequest.Certificate)
+	if err != nil {
+		writeProblem(responseWriter, http.StatusBadRequest, "malformed", "The certificate could not be decoded.")
+		return
+	}
+	leaf, err := x509.ParseCertificate(leafData)
+	if err != nil {
+		writeProblem(responseWriter, http.StatusBadRequest, "malformed", "The certificate could not be parsed.")
+		return
+	}
+
+	issued, ok := server.certificates[leaf.SerialNumber.String()]
+	if !ok || !slices.Equal(issued.derChain[0], leafData) {
+		writeProblem(responseWriter, http.StatusNotFound, "malformed", "The certificate was not issued by the server.")
+		return
+	}
+
+	// The request is to be signed either by the account that obtained the certificate, or by the certificate key.
+	authorized := false
+	if signed.header.Kid != "" {
+		authorized = issued.accountUri == signed.account.Uri
+	} else {
+		requestThumbprint, requestErr := acme.JWKThumbprint(signed.key)
+		leafThumbprint, leafErr := acme.JWKThumbprint(leaf.PublicKey)
+		authorized = errors.Join(requestErr, leafErr) == nil && requestThumbprint == leafThumbprint
+	}
+	if !authorized {
+		writeProblem(
+			responseWriter,
+			http.StatusForbidden,
+			"unauthorized",
+			"The request is not signed by the account or certificate key.",
+		)
+		return
+	}
+
+	if issued.revoked {
+		writeProblem(responseWriter, http.StatusBadRequest, "alreadyRevoked", "The certificate is already revoked.")
+		return
+	}
+	issued.revoked = true
+
+	responseWriter.WriteHeader(http.StatusOK)
+}
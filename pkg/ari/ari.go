@@ -0,0 +1,152 @@
+package ari
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsDirectory "github.com/altshiftab/letsencrypt_utils/pkg/directory"
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyteAsn1 "golang.org/x/crypto/cryptobyte/asn1"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnsupported is returned when the directory does not advertise the renewalInfo resource.
+var ErrUnsupported = errors.New("the directory does not advertise renewal information")
+
+// Window is a suggested renewal window.
+type Window struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// RenewalInfo is the renewal information of a certificate, as suggested by the CA.
+type RenewalInfo struct {
+	SuggestedWindow Window `json:"suggestedWindow"`
+	ExplanationUrl  string `json:"explanationURL,omitempty"`
+
+	// RetryAfter is the duration after which the renewal information should be fetched again, if specified.
+	RetryAfter time.Duration `json:"-"`
+}
+
+// SelectTime returns a uniformly random point in the suggested window, as recommended by RFC 9773.
+func (info *RenewalInfo) SelectTime() time.Time {
+	window := info.SuggestedWindow
+	span := window.End.Sub(window.Start)
+	if span <= 0 {
+		return window.Start
+	}
+	return window.Start.Add(rand.N(span))
+}
+
+// CertId computes the ARI certificate identifier: the base64url-encoded authority key identifier and serial number,
+// joined by a period.
+func CertId(certificate *x509.Certificate) (string, error) {
+	if certificate == nil {
+		return "", &motmedelErrors.CauseError{Message: "The certificate is nil."}
+	}
+
+	if len(certificate.AuthorityKeyId) == 0 {
+		return "", &motmedelErrors.InputError{
+			Message: "The certificate has no authority key identifier.",
+			Input:   certificate.SerialNumber.String(),
+		}
+	}
+
+	// The serial number is to be encoded as the content octets of its DER encoding, i.e. with any leading zero octet.
+	var tbs, serial cryptobyte.String
+	input := cryptobyte.String(certificate.RawTBSCertificate)
+	if !input.ReadASN1(&tbs, cryptobyteAsn1.SEQUENCE) ||
+		!tbs.SkipOptionalASN1(cryptobyteAsn1.Tag(0).Constructed().ContextSpecific()) ||
+		!tbs.ReadASN1(&serial, cryptobyteAsn1.INTEGER) {
+		return "", &motmedelErrors.InputError{
+			Message: "An error occurred when reading the certificate serial number.",
+			Input:   certificate.SerialNumber.String(),
+		}
+	}
+
+	return base64.RawURLEncoding.EncodeToString(certificate.AuthorityKeyId) + "." +
+		base64.RawURLEncoding.EncodeToString(serial), nil
+}
+
+// Fetch retrieves the renewal information of the certificate from the renewalInfo resource of the directory.
+// ErrUnsupported is returned if the directory does not advertise the resource.
+func Fetch(
+	ctx context.Context,
+	httpClient *http.Client,
+	directoryUrl string,
+	certificate *x509.Certificate,
+) (*RenewalInfo, error) {
+	certId, err := CertId(certificate)
+	if err != nil {
+		return nil, err
+	}
+
+	directory, err := letsencryptUtilsDirectory.Fetch(ctx, httpClient, directoryUrl)
+	if err != nil {
+		return nil, err
+	}
+	if directory.RenewalInfo == "" {
+		return nil, ErrUnsupported
+	}
+
+	renewalInfoUrl := strings.TrimSuffix(directory.RenewalInfo, "/") + "/" + certId
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, renewalInfoUrl, nil)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when creating the HTTP request.",
+			Cause:   err,
+			Input:   renewalInfoUrl,
+		}
+	}
+
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when fetching the renewal information.",
+			Cause:   err,
+			Input:   renewalInfoUrl,
+		}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, &motmedelErrors.InputError{
+			Message: "The renewal information resource returned an unexpected status code.",
+			Input:   []any{renewalInfoUrl, response.StatusCode},
+		}
+	}
+
+	var renewalInfo RenewalInfo
+	if err := json.NewDecoder(response.Body).Decode(&renewalInfo); err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when decoding the renewal information.",
+			Cause:   err,
+			Input:   renewalInfoUrl,
+		}
+	}
+
+	if renewalInfo.SuggestedWindow.Start.IsZero() || renewalInfo.SuggestedWindow.End.IsZero() {
+		return nil, &motmedelErrors.InputError{
+			Message: "The renewal information has no suggested window.",
+			Input:   renewalInfoUrl,
+		}
+	}
+
+	if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		renewalInfo.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	return &renewalInfo, nil
+}
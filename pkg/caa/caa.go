@@ -0,0 +1,135 @@
+package caa
+
+import (
+	"context"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/miekg/dns"
+	"net"
+	"strings"
+)
+
+const (
+	// LetsEncryptIssuerDomain is the issuer domain name identifying Let's Encrypt in CAA records.
+	LetsEncryptIssuerDomain = "letsencrypt.org"
+
+	TagIssue     = "issue"
+	TagIssueWild = "issuewild"
+
+	resolvConfPath  = "/etc/resolv.conf"
+	fallbackAddress = "8.8.8.8:53"
+	wildcardPrefix  = "*."
+)
+
+// resolverAddress returns the address of the first nameserver of the system resolver configuration.
+func resolverAddress() string {
+	config, err := dns.ClientConfigFromFile(resolvConfPath)
+	if err != nil || len(config.Servers) == 0 {
+		return fallbackAddress
+	}
+	return net.JoinHostPort(config.Servers[0], config.Port)
+}
+
+func lookupCaa(ctx context.Context, address string, name string) ([]*dns.CAA, error) {
+	message := new(dns.Msg)
+	message.SetQuestion(dns.Fqdn(name), dns.TypeCAA)
+	message.RecursionDesired = true
+
+	response, _, err := new(dns.Client).ExchangeContext(ctx, message, address)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when querying the CAA records.",
+			Cause:   err,
+			Input:   name,
+		}
+	}
+
+	switch response.Rcode {
+	case dns.RcodeSuccess, dns.RcodeNameError:
+	default:
+		return nil, &motmedelErrors.InputError{
+			Message: "The CAA query failed.",
+			Input:   []any{name, dns.RcodeToString[response.Rcode]},
+		}
+	}
+
+	var records []*dns.CAA
+	for _, answer := range response.Answer {
+		if record, ok := answer.(*dns.CAA); ok {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// Lookup finds the relevant CAA record set of the domain by climbing the DNS tree, as per RFC 8659: the records of
+// the closest name, starting with the domain itself, that has any. The name owning the records is also returned.
+func Lookup(ctx context.Context, domain string) ([]*dns.CAA, string, error) {
+	address := resolverAddress()
+
+	name := strings.TrimSuffix(strings.TrimPrefix(domain, wildcardPrefix), ".")
+	for name != "" {
+		records, err := lookupCaa(ctx, address, name)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(records) != 0 {
+			return records, name, nil
+		}
+
+		_, parent, found := strings.Cut(name, ".")
+		if !found {
+			break
+		}
+		name = parent
+	}
+
+	return nil, "", nil
+}
+
+// issuerDomain returns the issuer domain name of a CAA property value, i.e. the value without its parameters.
+func issuerDomain(value string) string {
+	domain, _, _ := strings.Cut(value, ";")
+	return strings.TrimSpace(domain)
+}
+
+// Check verifies that the CAA records of the domain permit the CA identified by any of the issuer domain names to issue
+// a certificate for it. Wildcard domains are checked against the issuewild records, if any, and otherwise the issue
+// records. A domain without relevant records permits any CA.
+func Check(ctx context.Context, domain string, caIssuerDomains ...string) error {
+	records, owner, err := Lookup(ctx, domain)
+	if err != nil {
+		return err
+	}
+
+	relevantTag := TagIssue
+	if strings.HasPrefix(domain, wildcardPrefix) {
+		for _, record := range records {
+			if strings.EqualFold(record.Tag, TagIssueWild) {
+				relevantTag = TagIssueWild
+				break
+			}
+		}
+	}
+
+	var values []string
+	for _, record := range records {
+		if !strings.EqualFold(record.Tag, relevantTag) {
+			continue
+		}
+		for _, caIssuerDomain := range caIssuerDomains {
+			if strings.EqualFold(issuerDomain(record.Value), caIssuerDomain) {
+				return nil
+			}
+		}
+		values = append(values, record.Value)
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	return &motmedelErrors.InputError{
+		Message: "The CAA records forbid issuance by the CA.",
+		Input:   []any{domain, owner, relevantTag, values, caIssuerDomains},
+	}
+}
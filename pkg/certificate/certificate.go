@@ -0,0 +1,64 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+)
+
+const BlockType = "CERTIFICATE"
+
+// ParsePemChain parses all certificate PEM blocks in the data, in order. Blocks of other types are skipped.
+func ParsePemChain(data []byte) ([]*x509.Certificate, error) {
+	var certificates []*x509.Certificate
+
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != BlockType {
+			continue
+		}
+
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, &motmedelErrors.InputError{
+				Message: "An error occurred when parsing a certificate.",
+				Cause:   err,
+				Input:   len(certificates),
+			}
+		}
+		certificates = append(certificates, certificate)
+	}
+
+	if len(certificates) == 0 {
+		return nil, &motmedelErrors.CauseError{Message: "No certificate PEM blocks were found."}
+	}
+
+	return certificates, nil
+}
+
+// ParsePemLeaf parses the first certificate PEM block in the data, which in a chain file is the leaf certificate.
+func ParsePemLeaf(data []byte) (*x509.Certificate, error) {
+	certificates, err := ParsePemChain(data)
+	if err != nil {
+		return nil, err
+	}
+	return certificates[0], nil
+}
+
+// ParseLeaf parses the leaf certificate of PEM data, as ParsePemLeaf does, or a single DER-encoded certificate.
+func ParseLeaf(data []byte) (*x509.Certificate, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		return ParsePemLeaf(data)
+	}
+
+	certificate, err := x509.ParseCertificate(data)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when parsing the DER certificate.", Cause: err}
+	}
+	return certificate, nil
+}
@@ -0,0 +1,44 @@
+package certificate
+
+import (
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"net"
+	"slices"
+	"strings"
+)
+
+// VerifyCertificateDomains checks that each of the domains, including wildcard domains and IP addresses, appears in
+// the SANs of the leaf certificate in the PEM data. Domain names are compared case-insensitively, and wildcard
+// domains must appear as wildcard SANs.
+func VerifyCertificateDomains(certPEM []byte, domains []string) error {
+	leaf, err := ParsePemLeaf(certPEM)
+	if err != nil {
+		return &motmedelErrors.CauseError{Message: "An error occurred when parsing the leaf certificate.", Cause: err}
+	}
+
+	var missing []string
+	for _, domain := range domains {
+		if ipAddress := net.ParseIP(domain); ipAddress != nil {
+			if !slices.ContainsFunc(leaf.IPAddresses, ipAddress.Equal) {
+				missing = append(missing, domain)
+			}
+			continue
+		}
+
+		normalizedDomain := strings.ToLower(strings.TrimSuffix(domain, "."))
+		if !slices.ContainsFunc(leaf.DNSNames, func(dnsName string) bool {
+			return strings.ToLower(dnsName) == normalizedDomain
+		}) {
+			missing = append(missing, domain)
+		}
+	}
+
+	if len(missing) != 0 {
+		return &motmedelErrors.InputError{
+			Message: "The certificate does not cover all of the domains.",
+			Input:   missing,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package certificate
+
+import (
+	"crypto"
+	"errors"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsKey "github.com/altshiftab/letsencrypt_utils/pkg/key"
+)
+
+// ErrKeyMismatch is returned when the public key of a certificate does not correspond to the key it is checked against.
+var ErrKeyMismatch = errors.New("the certificate does not match the key")
+
+type publicKeyEqualer interface {
+	Equal(crypto.PublicKey) bool
+}
+
+// VerifyKeyPair checks that the public key of the leaf certificate in the PEM data corresponds to the PEM-encoded
+// private key. EC, RSA, and Ed25519 keys are supported.
+func VerifyKeyPair(certPEM []byte, keyPEM []byte) error {
+	key, err := letsencryptUtilsKey.ParsePem(keyPEM)
+	if err != nil {
+		return &motmedelErrors.CauseError{Message: "An error occurred when parsing the private key.", Cause: err}
+	}
+
+	return VerifyPublicKey(certPEM, key.Public())
+}
+
+// VerifyPublicKey checks that the public key of the leaf certificate in the PEM data is the public key, e.g. that of
+// the CSR the certificate was ordered with.
+func VerifyPublicKey(certPEM []byte, key crypto.PublicKey) error {
+	leaf, err := ParsePemLeaf(certPEM)
+	if err != nil {
+		return &motmedelErrors.CauseError{Message: "An error occurred when parsing the leaf certificate.", Cause: err}
+	}
+
+	publicKey, ok := key.(publicKeyEqualer)
+	if !ok {
+		return &motmedelErrors.CauseError{Message: "The public key cannot be compared."}
+	}
+
+	if !publicKey.Equal(leaf.PublicKey) {
+		return &motmedelErrors.InputError{
+			Message: "The certificate does not match the key.",
+			Cause:   ErrKeyMismatch,
+			Input:   leaf.SerialNumber.Text(16),
+		}
+	}
+
+	return nil
+}
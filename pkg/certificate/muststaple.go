@@ -0,0 +1,52 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"slices"
+)
+
+// TlsFeatureOid identifies the TLS feature extension (RFC 7633).
+var TlsFeatureOid = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 24}
+
+// statusRequestFeature is the TLS extension number of status_request, i.e. OCSP stapling.
+const statusRequestFeature = 5
+
+// MustStapleExtension returns the TLS feature extension requiring the status_request feature, known as OCSP
+// Must-Staple, for inclusion in a CSR.
+func MustStapleExtension() pkix.Extension {
+	// The marshalling of a slice of integers does not fail.
+	value, _ := asn1.Marshal([]int{statusRequestFeature})
+	return pkix.Extension{Id: TlsFeatureOid, Value: value}
+}
+
+// HasMustStaple reports whether the certificate has a TLS feature extension requiring the status_request feature.
+func HasMustStaple(certificate *x509.Certificate) bool {
+	for _, extension := range certificate.Extensions {
+		if !extension.Id.Equal(TlsFeatureOid) {
+			continue
+		}
+		var features []int
+		if rest, err := asn1.Unmarshal(extension.Value, &features); err != nil || len(rest) != 0 {
+			return false
+		}
+		return slices.Contains(features, statusRequestFeature)
+	}
+	return false
+}
+
+// VerifyMustStaple checks that the leaf certificate in the PEM data has the OCSP Must-Staple extension.
+func VerifyMustStaple(certPEM []byte) error {
+	leaf, err := ParsePemLeaf(certPEM)
+	if err != nil {
+		return &motmedelErrors.CauseError{Message: "An error occurred when parsing the leaf certificate.", Cause: err}
+	}
+
+	if !HasMustStaple(leaf) {
+		return &motmedelErrors.CauseError{Message: "The certificate does not have the OCSP Must-Staple extension."}
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package certificate
+
+import (
+	"crypto"
+	"crypto/x509"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// EncodePkcs12 bundles the private key and the certificate chain, leaf first, into a password-protected PKCS#12
+// file. The certificates following the leaf are included as CA certificates.
+func EncodePkcs12(key crypto.Signer, chain []*x509.Certificate, password string) ([]byte, error) {
+	if key == nil {
+		return nil, &motmedelErrors.CauseError{Message: "The private key is nil."}
+	}
+	if len(chain) == 0 {
+		return nil, &motmedelErrors.CauseError{Message: "The certificate chain is empty."}
+	}
+
+	pfxData, err := pkcs12.Modern.Encode(key, chain[0], chain[1:], password)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when encoding the PKCS#12 data.", Cause: err}
+	}
+
+	return pfxData, nil
+}
@@ -0,0 +1,96 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"golang.org/x/crypto/cryptobyte"
+	"time"
+)
+
+// SctListOid identifies the embedded Signed Certificate Timestamp list extension (RFC 6962).
+var SctListOid = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+
+// sctLogIdSize is the size of the log ID of an SCT, the SHA-256 hash of the public key of the log.
+const sctLogIdSize = 32
+
+// SignedCertificateTimestamp is an SCT, a promise by a Certificate Transparency log to include the certificate.
+type SignedCertificateTimestamp struct {
+	Version int `json:"version"`
+	// LogId is the base64-encoded ID of the log, as listed in the log lists of the browsers.
+	LogId     string    `json:"log_id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// SignedCertificateTimestamps decodes the SCTs embedded in the certificate. No SCTs, and no error, are returned if the
+// certificate has no SCT list extension, in which case the SCTs may be delivered via OCSP or TLS instead.
+func SignedCertificateTimestamps(certificate *x509.Certificate) ([]*SignedCertificateTimestamp, error) {
+	for _, extension := range certificate.Extensions {
+		if !extension.Id.Equal(SctListOid) {
+			continue
+		}
+
+		var listData []byte
+		if rest, err := asn1.Unmarshal(extension.Value, &listData); err != nil || len(rest) != 0 {
+			return nil, &motmedelErrors.InputError{
+				Message: "The SCT list extension is not an octet string.",
+				Cause:   err,
+				Input:   certificate.SerialNumber.Text(16),
+			}
+		}
+
+		signedCertificateTimestamps, err := parseSctList(listData)
+		if err != nil {
+			return nil, &motmedelErrors.InputError{
+				Message: "An error occurred when parsing the SCT list.",
+				Cause:   err,
+				Input:   certificate.SerialNumber.Text(16),
+			}
+		}
+		return signedCertificateTimestamps, nil
+	}
+	return nil, nil
+}
+
+// parseSctList parses the TLS encoding of a SignedCertificateTimestampList, in which each SCT is a
+// length-prefixed SerializedSCT.
+func parseSctList(data []byte) ([]*SignedCertificateTimestamp, error) {
+	var list cryptobyte.String
+	input := cryptobyte.String(data)
+	if !input.ReadUint16LengthPrefixed(&list) || !input.Empty() {
+		return nil, &motmedelErrors.CauseError{Message: "The SCT list is malformed."}
+	}
+
+	var signedCertificateTimestamps []*SignedCertificateTimestamp
+	for !list.Empty() {
+		var serialized, logId, extensions, signature cryptobyte.String
+		var version, hashAlgorithm, signatureAlgorithm uint8
+		var timestamp uint64
+		if !list.ReadUint16LengthPrefixed(&serialized) ||
+			!serialized.ReadUint8(&version) ||
+			!serialized.ReadBytes((*[]byte)(&logId), sctLogIdSize) ||
+			!serialized.ReadUint64(&timestamp) ||
+			!serialized.ReadUint16LengthPrefixed(&extensions) ||
+			!serialized.ReadUint8(&hashAlgorithm) ||
+			!serialized.ReadUint8(&signatureAlgorithm) ||
+			!serialized.ReadUint16LengthPrefixed(&signature) ||
+			!serialized.Empty() {
+			return nil, &motmedelErrors.InputError{
+				Message: "An SCT is malformed.",
+				Input:   len(signedCertificateTimestamps),
+			}
+		}
+
+		signedCertificateTimestamps = append(
+			signedCertificateTimestamps,
+			&SignedCertificateTimestamp{
+				// The version is encoded as v1(0).
+				Version:   int(version) + 1,
+				LogId:     base64.StdEncoding.EncodeToString(logId),
+				Timestamp: time.UnixMilli(int64(timestamp)).UTC(),
+			},
+		)
+	}
+	return signedCertificateTimestamps, nil
+}
@@ -0,0 +1,71 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"strconv"
+	"time"
+)
+
+// Summary is a human-oriented description of a certificate.
+type Summary struct {
+	Subject       string    `json:"subject"`
+	DnsNames      []string  `json:"dns_names,omitempty"`
+	IpAddresses   []string  `json:"ip_addresses,omitempty"`
+	Issuer        string    `json:"issuer"`
+	SerialNumber  string    `json:"serial_number"`
+	NotBefore     time.Time `json:"not_before"`
+	NotAfter      time.Time `json:"not_after"`
+	DaysRemaining int       `json:"days_remaining"`
+	KeyAlgorithm  string    `json:"key_algorithm"`
+	IsCa          bool      `json:"is_ca"`
+}
+
+// KeyAlgorithm describes the public key algorithm of the certificate, including the curve or the key size.
+func KeyAlgorithm(certificate *x509.Certificate) string {
+	switch publicKey := certificate.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		return "ECDSA " + publicKey.Curve.Params().Name
+	case *rsa.PublicKey:
+		return "RSA " + strconv.Itoa(publicKey.N.BitLen())
+	case ed25519.PublicKey:
+		return "Ed25519"
+	default:
+		return certificate.PublicKeyAlgorithm.String()
+	}
+}
+
+// Summarize describes the certificate, with the days remaining counted from now.
+func Summarize(certificate *x509.Certificate, now time.Time) *Summary {
+	summary := &Summary{
+		Subject:       certificate.Subject.String(),
+		DnsNames:      certificate.DNSNames,
+		Issuer:        certificate.Issuer.String(),
+		SerialNumber:  certificate.SerialNumber.Text(16),
+		NotBefore:     certificate.NotBefore.UTC(),
+		NotAfter:      certificate.NotAfter.UTC(),
+		DaysRemaining: int(certificate.NotAfter.Sub(now).Hours() / 24),
+		KeyAlgorithm:  KeyAlgorithm(certificate),
+		IsCa:          certificate.IsCA,
+	}
+	for _, ipAddress := range certificate.IPAddresses {
+		summary.IpAddresses = append(summary.IpAddresses, ipAddress.String())
+	}
+	return summary
+}
+
+// IsFullChain reports whether the certificates form a chain of a leaf followed by at least one issuer, each
+// certificate being issued by the one following it.
+func IsFullChain(certificates []*x509.Certificate) bool {
+	if len(certificates) < 2 {
+		return false
+	}
+	for i := 0; i < len(certificates)-1; i++ {
+		if certificates[i].CheckSignatureFrom(certificates[i+1]) != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,146 @@
+package directory
+
+import (
+	"context"
+	"encoding/json"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"golang.org/x/crypto/acme"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+)
+
+const (
+	LetsEncryptUrl        = acme.LetsEncryptURL
+	LetsEncryptStagingUrl = "https://acme-staging-v02.api.letsencrypt.org/directory"
+)
+
+const (
+	EnvironmentProduction = "production"
+	EnvironmentStaging    = "staging"
+)
+
+// Environment returns the Let's Encrypt environment of the directory URL, or an empty string if it is not a Let's
+// Encrypt directory.
+func Environment(directoryUrl string) string {
+	switch directoryUrl {
+	case LetsEncryptUrl:
+		return EnvironmentProduction
+	case LetsEncryptStagingUrl:
+		return EnvironmentStaging
+	default:
+		return ""
+	}
+}
+
+// Url returns the Let's Encrypt directory URL of either the staging or the production environment.
+func Url(staging bool) string {
+	if staging {
+		return LetsEncryptStagingUrl
+	}
+	return LetsEncryptUrl
+}
+
+// ValidateUrl checks that the directory URL is an absolute HTTPS URL. Plain HTTP is only accepted for localhost, for
+// testing against a local ACME server.
+func ValidateUrl(directoryUrl string) error {
+	parsedUrl, err := url.Parse(directoryUrl)
+	if err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when parsing the directory URL.",
+			Cause:   err,
+			Input:   directoryUrl,
+		}
+	}
+
+	if parsedUrl.Host == "" {
+		return &motmedelErrors.InputError{Message: "The directory URL has no host.", Input: directoryUrl}
+	}
+
+	switch parsedUrl.Scheme {
+	case "https":
+		return nil
+	case "http":
+		hostname := parsedUrl.Hostname()
+		if hostname == "localhost" {
+			return nil
+		}
+		if ip := net.ParseIP(hostname); ip != nil && ip.IsLoopback() {
+			return nil
+		}
+		return &motmedelErrors.InputError{
+			Message: "The directory URL must use https unless the host is localhost.",
+			Input:   directoryUrl,
+		}
+	default:
+		return &motmedelErrors.InputError{Message: "The directory URL scheme is unsupported.", Input: directoryUrl}
+	}
+}
+
+// Directory is the subset of an ACME directory resource that is not exposed by the acme package.
+type Directory struct {
+	RenewalInfo string `json:"renewalInfo,omitempty"`
+	Meta        Meta   `json:"meta"`
+}
+
+// Meta is the subset of the directory metadata that is not exposed by the acme package.
+type Meta struct {
+	// TermsOfService is the URL of the current terms of service of the CA.
+	TermsOfService string `json:"termsOfService,omitempty"`
+	// Profiles maps the names of the certificate profiles offered by the CA to their descriptions.
+	Profiles map[string]string `json:"profiles,omitempty"`
+	// CaaIdentities are the issuer domain names that identify the CA in CAA records.
+	CaaIdentities []string `json:"caaIdentities,omitempty"`
+}
+
+// ProfileNames returns the names of the offered certificate profiles, sorted.
+func (directory *Directory) ProfileNames() []string {
+	return slices.Sorted(maps.Keys(directory.Meta.Profiles))
+}
+
+// Fetch retrieves and decodes the directory resource at the directory URL.
+func Fetch(ctx context.Context, httpClient *http.Client, directoryUrl string) (*Directory, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, directoryUrl, nil)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when creating the HTTP request.",
+			Cause:   err,
+			Input:   directoryUrl,
+		}
+	}
+
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when fetching the directory.",
+			Cause:   err,
+			Input:   directoryUrl,
+		}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, &motmedelErrors.InputError{
+			Message: "The directory returned an unexpected status code.",
+			Cause:   ErrUnexpectedStatus,
+			Input:   []any{directoryUrl, response.StatusCode},
+		}
+	}
+
+	var directory Directory
+	if err := json.NewDecoder(response.Body).Decode(&directory); err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when decoding the directory.",
+			Cause:   err,
+			Input:   directoryUrl,
+		}
+	}
+
+	return &directory, nil
+}
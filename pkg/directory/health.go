@@ -0,0 +1,116 @@
+package directory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"net/http"
+	"time"
+)
+
+// DefaultMaxClockSkew is the default maximum difference between the local clock and that of the ACME server.
+const DefaultMaxClockSkew = 30 * time.Second
+
+var (
+	// ErrUnexpectedStatus is returned when the directory responds with a status code other than 200.
+	ErrUnexpectedStatus = errors.New("the directory returned an unexpected status code")
+	// ErrClockSkew is returned when the local clock differs too much from that of the ACME server.
+	ErrClockSkew = errors.New("the clock skew exceeds the maximum")
+)
+
+// Health is the result of probing an ACME directory.
+type Health struct {
+	DirectoryUrl  string
+	StatusCode    int
+	RoundTripTime time.Duration
+	// ServerTime is the time of the Date header of the response, which is zero if the header is missing.
+	ServerTime time.Time
+	// ClockSkew is the local time minus the server time, estimated at the middle of the round trip. The Date header
+	// has a resolution of a second, which bounds the precision.
+	ClockSkew time.Duration
+}
+
+// Probe retrieves the directory resource at the directory URL, measuring the round-trip time and the clock skew
+// relative to the server, and checks that the response is an ACME directory.
+func Probe(ctx context.Context, httpClient *http.Client, directoryUrl string) (*Health, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, directoryUrl, nil)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when creating the HTTP request.",
+			Cause:   err,
+			Input:   directoryUrl,
+		}
+	}
+
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	start := time.Now()
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when fetching the directory.",
+			Cause:   err,
+			Input:   directoryUrl,
+		}
+	}
+	defer response.Body.Close()
+	roundTripTime := time.Since(start)
+
+	health := &Health{DirectoryUrl: directoryUrl, StatusCode: response.StatusCode, RoundTripTime: roundTripTime}
+
+	if date := response.Header.Get("Date"); date != "" {
+		if serverTime, err := http.ParseTime(date); err == nil {
+			health.ServerTime = serverTime
+			health.ClockSkew = start.Add(roundTripTime / 2).Sub(serverTime)
+		}
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return health, &motmedelErrors.InputError{
+			Message: "The directory returned an unexpected status code.",
+			Cause:   ErrUnexpectedStatus,
+			Input:   []any{directoryUrl, response.StatusCode},
+		}
+	}
+
+	var directory struct {
+		NewNonce   string `json:"newNonce"`
+		NewAccount string `json:"newAccount"`
+		NewOrder   string `json:"newOrder"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&directory); err != nil {
+		return health, &motmedelErrors.InputError{
+			Message: "An error occurred when decoding the directory.",
+			Cause:   err,
+			Input:   directoryUrl,
+		}
+	}
+	if directory.NewNonce == "" || directory.NewAccount == "" || directory.NewOrder == "" {
+		return health, &motmedelErrors.InputError{
+			Message: "The response is not an ACME directory.",
+			Input:   directoryUrl,
+		}
+	}
+
+	return health, nil
+}
+
+// CheckClockSkew checks that the clock skew is within the maximum. A response without a Date header is not checked.
+func (health *Health) CheckClockSkew(maxClockSkew time.Duration) error {
+	if health.ServerTime.IsZero() {
+		return nil
+	}
+
+	if health.ClockSkew > maxClockSkew || health.ClockSkew < -maxClockSkew {
+		return &motmedelErrors.InputError{
+			Message: "The local clock differs too much from that of the ACME server.",
+			Cause:   ErrClockSkew,
+			Input:   []any{health.ClockSkew.String(), maxClockSkew.String()},
+		}
+	}
+
+	return nil
+}
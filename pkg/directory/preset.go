@@ -0,0 +1,60 @@
+package directory
+
+import (
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+)
+
+const (
+	BuypassUrl        = "https://api.buypass.com/acme/directory"
+	BuypassStagingUrl = "https://api.test4.buypass.no/acme/directory"
+	ZeroSslUrl        = "https://acme.zerossl.com/v2/DV90"
+)
+
+// Preset is a well-known ACME directory, selectable by name.
+type Preset struct {
+	Name string
+	Url  string
+	// RequiresEab indicates that the CA only registers accounts with an external account binding.
+	RequiresEab bool
+}
+
+// Presets are the well-known ACME directories.
+var Presets = []Preset{
+	{Name: "letsencrypt", Url: LetsEncryptUrl},
+	{Name: "letsencrypt-staging", Url: LetsEncryptStagingUrl},
+	{Name: "buypass", Url: BuypassUrl},
+	{Name: "buypass-staging", Url: BuypassStagingUrl},
+	{Name: "zerossl", Url: ZeroSslUrl, RequiresEab: true},
+}
+
+// PresetNames returns the names of the presets, in order.
+func PresetNames() []string {
+	names := make([]string, 0, len(Presets))
+	for _, preset := range Presets {
+		names = append(names, preset.Name)
+	}
+	return names
+}
+
+// LookupPreset returns the preset with the name.
+func LookupPreset(name string) (*Preset, error) {
+	for index := range Presets {
+		if Presets[index].Name == name {
+			return &Presets[index], nil
+		}
+	}
+	return nil, &motmedelErrors.InputError{
+		Message: "The CA preset is unknown.",
+		Input:   []any{name, PresetNames()},
+	}
+}
+
+// PresetOf returns the preset whose directory URL is the directory URL, or nil if there is none.
+func PresetOf(directoryUrl string) *Preset {
+	for index := range Presets {
+		if Presets[index].Url == directoryUrl {
+			return &Presets[index]
+		}
+	}
+	return nil
+}
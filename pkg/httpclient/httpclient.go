@@ -0,0 +1,45 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+type contextKey struct{}
+
+// ContextWithClient returns a context carrying the HTTP client with which the requests to the ACME server are to be
+// made, e.g. one with a proxy configured. The library functions that create ACME clients use it.
+func ContextWithClient(ctx context.Context, httpClient *http.Client) context.Context {
+	return context.WithValue(ctx, contextKey{}, httpClient)
+}
+
+// ClientFromContext returns the HTTP client carried by the context, or nil, meaning the default client.
+func ClientFromContext(ctx context.Context) *http.Client {
+	if ctx == nil {
+		return nil
+	}
+	httpClient, _ := ctx.Value(contextKey{}).(*http.Client)
+	return httpClient
+}
+
+// Proxy returns the proxy function of the transport of the HTTP client carried by the context, or the one honoring the
+// HTTP_PROXY, HTTPS_PROXY, and NO_PROXY environment variables if there is none, so that other requests, e.g. the
+// challenge self-checks, can be routed like the requests to the ACME server. Wrapping transports, e.g. the logging
+// one, are unwrapped.
+func Proxy(ctx context.Context) func(*http.Request) (*url.URL, error) {
+	if httpClient := ClientFromContext(ctx); httpClient != nil {
+		roundTripper := httpClient.Transport
+		for {
+			wrapper, ok := roundTripper.(interface{ Unwrap() http.RoundTripper })
+			if !ok {
+				break
+			}
+			roundTripper = wrapper.Unwrap()
+		}
+		if transport, ok := roundTripper.(*http.Transport); ok {
+			return transport.Proxy
+		}
+	}
+	return http.ProxyFromEnvironment
+}
@@ -0,0 +1,172 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	letsencryptUtilsRedact "github.com/altshiftab/letsencrypt_utils/pkg/redact"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// redactedValue replaces the sensitive values of the logged bodies.
+const redactedValue = "[redacted]"
+
+// loggingTransport logs the requests and responses of the wrapped transport.
+type loggingTransport struct {
+	transport http.RoundTripper
+	logger    *slog.Logger
+}
+
+// NewLoggingTransport returns a transport that logs each request made with the transport and its response at the
+// debug level, with the method, URL, status, and body, for diagnosing the exchanges with an ACME server. The JWS
+// payloads are decoded, and the signatures and keys in the bodies are redacted, as are the email addresses of the
+// contacts unless redaction is turned off.
+func NewLoggingTransport(transport http.RoundTripper, logger *slog.Logger) http.RoundTripper {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &loggingTransport{transport: transport, logger: logger}
+}
+
+// Unwrap returns the wrapped transport.
+func (loggingTransport *loggingTransport) Unwrap() http.RoundTripper {
+	return loggingTransport.transport
+}
+
+func (loggingTransport *loggingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	requestAttributes := []any{slog.String("method", request.Method), slog.String("url", request.URL.String())}
+
+	// The body is read from a copy, as a transport must not consume the request.
+	if request.Body != nil && request.GetBody != nil {
+		if body, err := request.GetBody(); err == nil {
+			requestBody, _ := io.ReadAll(body)
+			_ = body.Close()
+			requestAttributes = append(requestAttributes, bodyAttribute(requestBody))
+		}
+	}
+	loggingTransport.logger.Debug("ACME request.", requestAttributes...)
+
+	start := time.Now()
+	response, err := loggingTransport.transport.RoundTrip(request)
+	if err != nil {
+		loggingTransport.logger.Debug(
+			"ACME request failed.",
+			slog.String("method", request.Method),
+			slog.String("url", request.URL.String()),
+			slog.Any("error", err),
+		)
+		return nil, err
+	}
+
+	responseBody, err := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	responseAttributes := []any{
+		slog.String("method", request.Method),
+		slog.String("url", request.URL.String()),
+		slog.Int("status", response.StatusCode),
+		slog.Int64("duration_ms", time.Since(start).Milliseconds()),
+	}
+	if location := response.Header.Get("Location"); location != "" {
+		responseAttributes = append(responseAttributes, slog.String("location", location))
+	}
+	if len(responseBody) != 0 {
+		responseAttributes = append(responseAttributes, bodyAttribute(responseBody))
+	}
+	loggingTransport.logger.Debug("ACME response.", responseAttributes...)
+
+	return response, nil
+}
+
+// bodyAttribute returns the attribute with which a body is logged: the redacted body if it is JSON, and only its
+// length otherwise, e.g. for a certificate chain.
+func bodyAttribute(body []byte) slog.Attr {
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return slog.Int("body_length", len(body))
+	}
+
+	redactedBody, err := json.Marshal(redactValue(value))
+	if err != nil {
+		return slog.Int("body_length", len(body))
+	}
+	return slog.String("body", string(redactedBody))
+}
+
+// redactValue redacts the JWS signatures and the keys, which are JWKs, of a JSON value, and masks the email addresses
+// of contacts. The base64url-encoded JSON of the protected header and the payload of a JWS is decoded.
+func redactValue(value any) any {
+	switch typedValue := value.(type) {
+	case map[string]any:
+		if _, ok := typedValue["kty"]; ok {
+			return redactedValue
+		}
+
+		for name, fieldValue := range typedValue {
+			switch name {
+			case "signature":
+				typedValue[name] = redactedValue
+			case "protected", "payload":
+				typedValue[name] = redactValue(decodeJwsField(fieldValue))
+			case "contact":
+				typedValue[name] = redactContacts(fieldValue)
+			default:
+				typedValue[name] = redactValue(fieldValue)
+			}
+		}
+		return typedValue
+	case []any:
+		for index, element := range typedValue {
+			typedValue[index] = redactValue(element)
+		}
+		return typedValue
+	default:
+		return value
+	}
+}
+
+// decodeJwsField decodes a base64url-encoded JSON field of a JWS, returning the value as is if it is not one.
+func decodeJwsField(value any) any {
+	encoded, ok := value.(string)
+	if !ok || encoded == "" {
+		return value
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return value
+	}
+
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return value
+	}
+	return decoded
+}
+
+func redactContacts(value any) any {
+	elements, ok := value.([]any)
+	if !ok {
+		return redactValue(value)
+	}
+
+	contacts := make([]string, 0, len(elements))
+	for _, element := range elements {
+		contact, ok := element.(string)
+		if !ok {
+			return redactedValue
+		}
+		contacts = append(contacts, contact)
+	}
+	return letsencryptUtilsRedact.Contacts(contacts)
+}
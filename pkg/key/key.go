@@ -0,0 +1,410 @@
+package key
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"golang.org/x/crypto/acme"
+	"io"
+	"math/big"
+)
+
+type Type string
+
+const (
+	TypeEcdsa Type = "ecdsa"
+	TypeRsa   Type = "rsa"
+	// TypeEd25519 keys are stored in PKCS #8 form, as there is no SEC 1 or PKCS #1 form for them. They cannot be
+	// used as account keys, as golang.org/x/crypto/acme cannot sign requests with them.
+	TypeEd25519 Type = "ed25519"
+)
+
+const (
+	EcPrivateKeyBlockType  = "EC PRIVATE KEY"
+	RsaPrivateKeyBlockType = "RSA PRIVATE KEY"
+	PrivateKeyBlockType    = "PRIVATE KEY"
+)
+
+type Curve string
+
+const (
+	CurveP256 Curve = "p256"
+	CurveP384 Curve = "p384"
+	CurveP521 Curve = "p521"
+)
+
+const DefaultRsaBits = 2048
+
+// Spec describes the key to be generated. The zero value describes a P-256 ECDSA key.
+type Spec struct {
+	Type    Type
+	Curve   Curve
+	RsaBits int
+}
+
+// MinRsaBits is the smallest RSA key size accepted by ValidateSpec.
+const MinRsaBits = 2048
+
+// ValidateSpec checks that keys can be generated as described by the spec, so that an invalid type, curve, or size is
+// reported before anything else is done.
+func ValidateSpec(spec *Spec) error {
+	if spec == nil {
+		return nil
+	}
+
+	switch spec.Type {
+	case TypeEcdsa, "":
+		_, err := ellipticCurve(spec.Curve)
+		return err
+	case TypeRsa:
+		if spec.RsaBits != 0 && spec.RsaBits < MinRsaBits {
+			return &motmedelErrors.InputError{
+				Message: fmt.Sprintf("The RSA key size is too small; use at least %d bits.", MinRsaBits),
+				Input:   spec.RsaBits,
+			}
+		}
+		return nil
+	case TypeEd25519:
+		return nil
+	default:
+		return &motmedelErrors.InputError{Message: "The key type is unsupported.", Input: spec.Type}
+	}
+}
+
+func ellipticCurve(curve Curve) (elliptic.Curve, error) {
+	switch curve {
+	case CurveP256, "":
+		return elliptic.P256(), nil
+	case CurveP384:
+		return elliptic.P384(), nil
+	case CurveP521:
+		return elliptic.P521(), nil
+	default:
+		return nil, &motmedelErrors.InputError{Message: "The curve is unsupported.", Input: curve}
+	}
+}
+
+// singleByteSkippingReader is a reader that answers single-byte reads without reading from the underlying reader.
+type singleByteSkippingReader struct {
+	reader io.Reader
+}
+
+func (reader *singleByteSkippingReader) Read(data []byte) (int, error) {
+	if len(data) == 1 {
+		data[0] = 0
+		return 1, nil
+	}
+	return reader.reader.Read(data)
+}
+
+// skipSingleByteReads wraps a reader other than crypto/rand so that the single-byte read that the standard library
+// makes at random, to keep callers from depending on the output of key generation, does not consume from it.
+func skipSingleByteReads(random io.Reader) io.Reader {
+	if random == rand.Reader {
+		return random
+	}
+	return &singleByteSkippingReader{reader: random}
+}
+
+// deriveEcdsaKey derives an ECDSA key from the bytes read from the reader. Unlike ecdsa.GenerateKey, which may read
+// an extra byte to keep callers from depending on its output, the key is fully determined by the reader.
+func deriveEcdsaKey(curve elliptic.Curve, random io.Reader) (*ecdsa.PrivateKey, error) {
+	var ecdhCurve ecdh.Curve
+	switch curve {
+	case elliptic.P256():
+		ecdhCurve = ecdh.P256()
+	case elliptic.P384():
+		ecdhCurve = ecdh.P384()
+	case elliptic.P521():
+		ecdhCurve = ecdh.P521()
+	default:
+		return nil, &motmedelErrors.InputError{Message: "The curve is unsupported.", Input: curve.Params().Name}
+	}
+
+	params := curve.Params()
+	scalar := make([]byte, (params.BitSize+7)/8)
+	for {
+		if _, err := io.ReadFull(random, scalar); err != nil {
+			return nil, &motmedelErrors.CauseError{Message: "An error occurred when reading random data.", Cause: err}
+		}
+		// Mask the excess bits of P-521 scalars and reject scalars out of range, as in FIPS 186-5 A.2.2.
+		if excessBits := len(scalar)*8 - params.BitSize; excessBits > 0 {
+			scalar[0] &= 0xff >> excessBits
+		}
+
+		ecdhKey, err := ecdhCurve.NewPrivateKey(scalar)
+		if err != nil {
+			continue
+		}
+
+		publicKeyBytes := ecdhKey.PublicKey().Bytes()
+		coordinateSize := (len(publicKeyBytes) - 1) / 2
+		return &ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{
+				Curve: curve,
+				X:     new(big.Int).SetBytes(publicKeyBytes[1 : 1+coordinateSize]),
+				Y:     new(big.Int).SetBytes(publicKeyBytes[1+coordinateSize:]),
+			},
+			D: new(big.Int).SetBytes(scalar),
+		}, nil
+	}
+}
+
+// Generate generates a key as described by the spec, using crypto/rand as the source of randomness.
+func Generate(spec *Spec) (crypto.Signer, error) {
+	return GenerateWithRand(spec, nil)
+}
+
+// GenerateWithRand generates a key as described by the spec, reading randomness from the reader, or from crypto/rand
+// if it is nil. Given a deterministic reader, the same key is generated each time, which is meant for tests only.
+func GenerateWithRand(spec *Spec, random io.Reader) (crypto.Signer, error) {
+	if spec == nil {
+		spec = &Spec{}
+	}
+
+	if random == nil {
+		random = rand.Reader
+	}
+
+	switch spec.Type {
+	case TypeEcdsa, "":
+		curve, err := ellipticCurve(spec.Curve)
+		if err != nil {
+			return nil, err
+		}
+		var key *ecdsa.PrivateKey
+		if random == rand.Reader {
+			key, err = ecdsa.GenerateKey(curve, random)
+		} else {
+			key, err = deriveEcdsaKey(curve, random)
+		}
+		if err != nil {
+			return nil, &motmedelErrors.CauseError{Message: "An error occurred when generating an ECDSA key.", Cause: err}
+		}
+		return key, nil
+	case TypeRsa:
+		rsaBits := spec.RsaBits
+		if rsaBits == 0 {
+			rsaBits = DefaultRsaBits
+		}
+		key, err := rsa.GenerateKey(skipSingleByteReads(random), rsaBits)
+		if err != nil {
+			return nil, &motmedelErrors.InputError{
+				Message: "An error occurred when generating an RSA key.",
+				Cause:   err,
+				Input:   rsaBits,
+			}
+		}
+		return key, nil
+	case TypeEd25519:
+		_, key, err := ed25519.GenerateKey(random)
+		if err != nil {
+			return nil, &motmedelErrors.CauseError{
+				Message: "An error occurred when generating an Ed25519 key.",
+				Cause:   err,
+			}
+		}
+		return key, nil
+	default:
+		return nil, &motmedelErrors.InputError{Message: "The key type is unsupported.", Input: spec.Type}
+	}
+}
+
+// MarshalPem encodes a private key as a PEM block whose type matches the key's algorithm.
+func MarshalPem(key crypto.Signer) ([]byte, error) {
+	blockType, derData, err := marshal(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: derData}), nil
+}
+
+// MarshalDer encodes a private key in the DER form of its algorithm: SEC 1 for ECDSA, PKCS #1 for RSA, and PKCS #8 for
+// Ed25519 keys, as in the PEM blocks of MarshalPem.
+func MarshalDer(key crypto.Signer) ([]byte, error) {
+	_, derData, err := marshal(key)
+	return derData, err
+}
+
+// marshal encodes a private key in DER form and returns it along with the PEM block type of the form.
+func marshal(key crypto.Signer) (string, []byte, error) {
+	switch typedKey := key.(type) {
+	case *ecdsa.PrivateKey:
+		derData, err := x509.MarshalECPrivateKey(typedKey)
+		if err != nil {
+			return "", nil, &motmedelErrors.CauseError{
+				Message: "An error occurred when marshalling the ECDSA key data.",
+				Cause:   err,
+			}
+		}
+		return EcPrivateKeyBlockType, derData, nil
+	case *rsa.PrivateKey:
+		return RsaPrivateKeyBlockType, x509.MarshalPKCS1PrivateKey(typedKey), nil
+	case ed25519.PrivateKey:
+		derData, err := x509.MarshalPKCS8PrivateKey(typedKey)
+		if err != nil {
+			return "", nil, &motmedelErrors.CauseError{
+				Message: "An error occurred when marshalling the Ed25519 key data.",
+				Cause:   err,
+			}
+		}
+		return PrivateKeyBlockType, derData, nil
+	default:
+		return "", nil, &motmedelErrors.InputError{
+			Message: "The key type is unsupported.",
+			Input:   fmt.Sprintf("%T", key),
+		}
+	}
+}
+
+// ParsePem decodes the first PEM block in the data into a private key, based on the block type.
+func ParsePem(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, &motmedelErrors.CauseError{Message: "No PEM block could be decoded from the key data."}
+	}
+
+	switch block.Type {
+	case EcPrivateKeyBlockType:
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, &motmedelErrors.CauseError{Message: "An error occurred when parsing the ECDSA key.", Cause: err}
+		}
+		return key, nil
+	case RsaPrivateKeyBlockType:
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, &motmedelErrors.CauseError{Message: "An error occurred when parsing the RSA key.", Cause: err}
+		}
+		return key, nil
+	case PrivateKeyBlockType:
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, &motmedelErrors.CauseError{Message: "An error occurred when parsing the PKCS #8 key.", Cause: err}
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, &motmedelErrors.InputError{
+				Message: "The PKCS #8 key is not a signer.",
+				Input:   fmt.Sprintf("%T", key),
+			}
+		}
+		return signer, nil
+	default:
+		return nil, &motmedelErrors.InputError{Message: "The PEM block type is unrecognized.", Input: block.Type}
+	}
+}
+
+// Parse decodes a private key in either a PEM block, as with ParsePem, or one of the DER forms of MarshalDer.
+func Parse(data []byte) (crypto.Signer, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		return ParsePem(data)
+	}
+
+	if key, err := x509.ParseECPrivateKey(data); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(data); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(data)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{
+			Message: "The key data is neither a PEM block nor a DER-encoded SEC 1, PKCS #1, or PKCS #8 key.",
+			Cause:   err,
+		}
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, &motmedelErrors.InputError{
+			Message: "The PKCS #8 key is not a signer.",
+			Input:   fmt.Sprintf("%T", key),
+		}
+	}
+	return signer, nil
+}
+
+// CheckCertificateKey checks that the key is of a type that CAs such as Let's Encrypt issue certificates for: ECDSA
+// on the P-256 or P-384 curve, or RSA of 2048, 3072, or 4096 bits.
+func CheckCertificateKey(key crypto.Signer) error {
+	spec, err := SpecOf(key)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case spec.Type == TypeEcdsa && (spec.Curve == CurveP256 || spec.Curve == CurveP384):
+		return nil
+	case spec.Type == TypeRsa && (spec.RsaBits == 2048 || spec.RsaBits == 3072 || spec.RsaBits == 4096):
+		return nil
+	default:
+		return &motmedelErrors.InputError{
+			Message: "The key type is not accepted for certificates; use an ECDSA P-256 or P-384 key, or an RSA key " +
+				"of 2048, 3072, or 4096 bits.",
+			Input: *spec,
+		}
+	}
+}
+
+// SpecOf returns a spec that describes keys of the same type, and curve or size, as the key.
+func SpecOf(key crypto.Signer) (*Spec, error) {
+	switch typedKey := key.(type) {
+	case *ecdsa.PrivateKey:
+		var curve Curve
+		switch typedKey.Curve {
+		case elliptic.P256():
+			curve = CurveP256
+		case elliptic.P384():
+			curve = CurveP384
+		case elliptic.P521():
+			curve = CurveP521
+		default:
+			return nil, &motmedelErrors.InputError{
+				Message: "The curve is unsupported.",
+				Input:   typedKey.Curve.Params().Name,
+			}
+		}
+		return &Spec{Type: TypeEcdsa, Curve: curve}, nil
+	case *rsa.PrivateKey:
+		return &Spec{Type: TypeRsa, RsaBits: typedKey.N.BitLen()}, nil
+	case ed25519.PrivateKey:
+		return &Spec{Type: TypeEd25519}, nil
+	default:
+		return nil, &motmedelErrors.InputError{
+			Message: "The key type is unsupported.",
+			Input:   fmt.Sprintf("%T", key),
+		}
+	}
+}
+
+// Thumbprint returns the base64url-encoded JWK SHA-256 thumbprint of the public key of the key, as specified in
+// RFC 7638.
+func Thumbprint(key crypto.Signer) (string, error) {
+	if key == nil {
+		return "", &motmedelErrors.CauseError{Message: "The key is nil."}
+	}
+
+	// The acme package does not support Ed25519 keys, whose JWK is specified in RFC 8037.
+	if publicKey, ok := key.Public().(ed25519.PublicKey); ok {
+		jwk := fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":"%s"}`, base64.RawURLEncoding.EncodeToString(publicKey))
+		digest := sha256.Sum256([]byte(jwk))
+		return base64.RawURLEncoding.EncodeToString(digest[:]), nil
+	}
+
+	thumbprint, err := acme.JWKThumbprint(key.Public())
+	if err != nil {
+		return "", &motmedelErrors.CauseError{Message: "An error occurred when computing the JWK thumbprint.", Cause: err}
+	}
+
+	return thumbprint, nil
+}
@@ -0,0 +1,104 @@
+package ocsp
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"golang.org/x/crypto/ocsp"
+	"io"
+	"net/http"
+)
+
+const requestContentType = "application/ocsp-request"
+
+// Check queries the OCSP responder of the certificate for its status.
+func Check(
+	ctx context.Context,
+	httpClient *http.Client,
+	certificate *x509.Certificate,
+	issuer *x509.Certificate,
+) (*ocsp.Response, error) {
+	if certificate == nil {
+		return nil, &motmedelErrors.CauseError{Message: "The certificate is nil."}
+	}
+	if issuer == nil {
+		return nil, &motmedelErrors.CauseError{Message: "The issuer certificate is nil."}
+	}
+
+	if len(certificate.OCSPServer) == 0 {
+		return nil, &motmedelErrors.InputError{
+			Message: "The certificate does not specify an OCSP responder.",
+			Input:   certificate.SerialNumber.String(),
+		}
+	}
+	responderUrl := certificate.OCSPServer[0]
+
+	requestData, err := ocsp.CreateRequest(certificate, issuer, nil)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when creating the OCSP request.", Cause: err}
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, responderUrl, bytes.NewReader(requestData))
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when creating the HTTP request.",
+			Cause:   err,
+			Input:   responderUrl,
+		}
+	}
+	request.Header.Set("Content-Type", requestContentType)
+
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when querying the OCSP responder.",
+			Cause:   err,
+			Input:   responderUrl,
+		}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, &motmedelErrors.InputError{
+			Message: "The OCSP responder returned an unexpected status code.",
+			Input:   []any{responderUrl, response.StatusCode},
+		}
+	}
+
+	responseData, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when reading the OCSP response.",
+			Cause:   err,
+			Input:   responderUrl,
+		}
+	}
+
+	ocspResponse, err := ocsp.ParseResponseForCert(responseData, certificate, issuer)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when parsing the OCSP response.",
+			Cause:   err,
+			Input:   responderUrl,
+		}
+	}
+
+	return ocspResponse, nil
+}
+
+// StatusString returns the textual representation of an OCSP status: "good", "revoked", or "unknown".
+func StatusString(status int) string {
+	switch status {
+	case ocsp.Good:
+		return "good"
+	case ocsp.Revoked:
+		return "revoked"
+	default:
+		return "unknown"
+	}
+}
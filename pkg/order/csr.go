@@ -0,0 +1,123 @@
+package order
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsCertificate "github.com/altshiftab/letsencrypt_utils/pkg/certificate"
+	"net"
+	"slices"
+	"strings"
+)
+
+const (
+	CertificateRequestBlockType = "CERTIFICATE REQUEST"
+	// legacyCertificateRequestBlockType is the PEM block type of CSRs produced by some older tools.
+	legacyCertificateRequestBlockType = "NEW CERTIFICATE REQUEST"
+)
+
+// BuildCSR creates a DER-encoded CSR for the domains, signed with the key. Entries that are IP addresses are placed in
+// the IP address SANs rather than the DNS name SANs. The common name of the subject is set to the first domain name
+// unless the subject specifies one. Of the options, only WithRand and WithMustStaple apply; note that only RSA and
+// Ed25519 signatures are reproducible given a deterministic source of randomness.
+func BuildCSR(key crypto.Signer, domains []string, subject pkix.Name, options ...Option) ([]byte, error) {
+	if key == nil {
+		return nil, &motmedelErrors.CauseError{Message: "The key is nil."}
+	}
+
+	if len(domains) == 0 {
+		return nil, &motmedelErrors.InputError{Message: "No domains were provided."}
+	}
+
+	var config Config
+	for _, option := range options {
+		if option != nil {
+			option(&config)
+		}
+	}
+
+	random := config.Rand
+	if random == nil {
+		random = rand.Reader
+	}
+
+	var dnsNames []string
+	var ipAddresses []net.IP
+	for _, domain := range domains {
+		if ipAddress := net.ParseIP(domain); ipAddress != nil {
+			ipAddresses = append(ipAddresses, ipAddress)
+		} else {
+			dnsNames = append(dnsNames, domain)
+		}
+	}
+
+	if subject.CommonName == "" && len(dnsNames) != 0 {
+		subject.CommonName = dnsNames[0]
+	}
+
+	template := &x509.CertificateRequest{Subject: subject, DNSNames: dnsNames, IPAddresses: ipAddresses}
+	if config.MustStaple {
+		template.ExtraExtensions = append(template.ExtraExtensions, letsencryptUtilsCertificate.MustStapleExtension())
+	}
+
+	csr, err := x509.CreateCertificateRequest(random, template, key)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when creating the CSR.",
+			Cause:   err,
+			Input:   domains,
+		}
+	}
+
+	return csr, nil
+}
+
+// ParseCSR decodes a PKCS #10 CSR in either PEM or DER form and checks its signature.
+func ParseCSR(data []byte) (*x509.CertificateRequest, error) {
+	derData := data
+	if block, _ := pem.Decode(data); block != nil {
+		if block.Type != CertificateRequestBlockType && block.Type != legacyCertificateRequestBlockType {
+			return nil, &motmedelErrors.InputError{Message: "The PEM block is not a CSR.", Input: block.Type}
+		}
+		derData = block.Bytes
+	}
+
+	csr, err := x509.ParseCertificateRequest(derData)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when parsing the CSR.", Cause: err}
+	}
+
+	if err := csr.CheckSignature(); err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "The signature of the CSR is invalid.", Cause: err}
+	}
+
+	return csr, nil
+}
+
+// CSRDomains returns the domain names and IP addresses of the CSR, lowercased and without duplicates: those of the
+// SANs, followed by the common name if it is not among them, as the CA includes it in the certificate.
+func CSRDomains(csr *x509.CertificateRequest) []string {
+	var domains []string
+	add := func(domain string) {
+		if domain != "" && !slices.Contains(domains, domain) {
+			domains = append(domains, domain)
+		}
+	}
+
+	for _, dnsName := range csr.DNSNames {
+		add(strings.ToLower(dnsName))
+	}
+	for _, ipAddress := range csr.IPAddresses {
+		add(ipAddress.String())
+	}
+	if ipAddress := net.ParseIP(csr.Subject.CommonName); ipAddress != nil {
+		add(ipAddress.String())
+	} else {
+		add(strings.ToLower(csr.Subject.CommonName))
+	}
+
+	return domains
+}
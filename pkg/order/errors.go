@@ -0,0 +1,21 @@
+package order
+
+import "errors"
+
+var (
+	// ErrAuthorizationFailed is returned when the CA does not validate an authorization of the order.
+	ErrAuthorizationFailed = errors.New("the authorization failed")
+	// ErrOrderInvalid is returned when an existing order to be continued is in a terminal error state.
+	ErrOrderInvalid = errors.New("the order is invalid")
+	// ErrOrderExpired is returned when an existing order to be continued has expired.
+	ErrOrderExpired = errors.New("the order has expired")
+	// ErrOrderFinalizedWithOtherKey is returned when an existing order to be continued was already finalized with a
+	// CSR for another key than that of the provided CSR.
+	ErrOrderFinalizedWithOtherKey = errors.New("the order was finalized with another key")
+	// ErrPollTimeout is returned when an authorization does not become valid, or the order is not issued, within the
+	// poll timeout.
+	ErrPollTimeout = errors.New("timed out waiting for the order")
+	// ErrNoChallengeSolver is returned when a solver is registered for none of the challenge types offered for an
+	// authorization that can be solved.
+	ErrNoChallengeSolver = errors.New("no solver is registered for the offered challenge types")
+)
@@ -0,0 +1,71 @@
+package order
+
+import (
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"golang.org/x/net/idna"
+	"strings"
+)
+
+// isAscii reports whether the string consists of ASCII characters only.
+func isAscii(s string) bool {
+	for index := range len(s) {
+		if s[index] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// ToAscii returns the ASCII-compatible encoding (the A-label form) of an internationalized domain, e.g.
+// "xn--bcher-kva.example" for "bücher.example", which is the form used in ACME identifiers and CSRs. Only the labels
+// of a wildcard domain are converted, and IP addresses and domains that are already ASCII are returned unchanged.
+func ToAscii(domain string) (string, error) {
+	if IsIp(domain) || isAscii(domain) {
+		return domain, nil
+	}
+
+	labels, wildcard := strings.CutPrefix(domain, wildcardPrefix)
+
+	asciiLabels, err := idna.Lookup.ToASCII(labels)
+	if err != nil {
+		return "", &motmedelErrors.InputError{
+			Message: "The domain could not be converted to its ASCII-compatible encoding.",
+			Cause:   err,
+			Input:   domain,
+		}
+	}
+
+	if wildcard {
+		return wildcardPrefix + asciiLabels, nil
+	}
+	return asciiLabels, nil
+}
+
+// ToAsciiDomains applies ToAscii to each of the domains.
+func ToAsciiDomains(domains []string) ([]string, error) {
+	asciiDomains := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		asciiDomain, err := ToAscii(domain)
+		if err != nil {
+			return nil, err
+		}
+		asciiDomains = append(asciiDomains, asciiDomain)
+	}
+	return asciiDomains, nil
+}
+
+// ToUnicode returns the Unicode form of a domain in A-label form, for display. The domain is returned unchanged if it
+// cannot be converted.
+func ToUnicode(domain string) string {
+	labels, wildcard := strings.CutPrefix(domain, wildcardPrefix)
+
+	unicodeLabels, err := idna.Display.ToUnicode(labels)
+	if err != nil {
+		return domain
+	}
+
+	if wildcard {
+		return wildcardPrefix + unicodeLabels
+	}
+	return unicodeLabels
+}
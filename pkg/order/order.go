@@ -0,0 +1,690 @@
+package order
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	motmedelLog "github.com/Motmedel/utils_go/pkg/log"
+	letsencryptUtilsCertificate "github.com/altshiftab/letsencrypt_utils/pkg/certificate"
+	letsencryptUtilsRetry "github.com/altshiftab/letsencrypt_utils/pkg/retry"
+	letsencryptUtilsSolver "github.com/altshiftab/letsencrypt_utils/pkg/solver"
+	"golang.org/x/crypto/acme"
+	"io"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+const CertificateBlockType = "CERTIFICATE"
+
+type Config struct {
+	// Solvers maps challenge types to the solvers used to fulfil them.
+	Solvers map[string]letsencryptUtilsSolver.Solver
+	// ChallengePreference orders the challenge types by preference. Of the challenges offered for an authorization,
+	// one of the first type in the preference for which a solver is registered is solved. Types not in the preference
+	// come after those in it, in the order offered by the CA.
+	ChallengePreference []string
+	// Profile is the name of the certificate profile to be requested, if any.
+	Profile string
+	// StatePath, if set, is the path of a file in which the progress of the order is persisted, so that an
+	// interrupted order can be resumed.
+	StatePath string
+	// DeactivateAuthorizations, if set, deactivates the authorizations of the order once the certificate has been
+	// issued, so that the next order requires fresh validation.
+	DeactivateAuthorizations bool
+	// Rand, if set, is the source of randomness for signing CSRs, rather than crypto/rand.
+	Rand io.Reader
+	// MustStaple indicates that CSRs request the OCSP Must-Staple extension.
+	MustStaple bool
+	// OrderUrl, if set, is the URL of an existing order to be continued rather than a new order being created. It
+	// takes precedence over an order recorded in the state file.
+	OrderUrl string
+	// PollInterval, if set, is the interval at which authorizations and the order are polled, rather than as suggested
+	// by the CA via Retry-After, and otherwise every second.
+	PollInterval time.Duration
+	// PollTimeout, if set, is the longest each authorization is waited for to become valid, and the order to become
+	// ready or be issued. Otherwise, they are waited for until the context is done.
+	PollTimeout time.Duration
+}
+
+type Option func(*Config)
+
+// WithSolver registers a solver for a challenge type.
+func WithSolver(challengeType string, solver letsencryptUtilsSolver.Solver) Option {
+	return func(config *Config) {
+		if config.Solvers == nil {
+			config.Solvers = make(map[string]letsencryptUtilsSolver.Solver)
+		}
+		config.Solvers[challengeType] = solver
+	}
+}
+
+// WithChallengePreference orders the challenge types by preference, the most preferred first.
+func WithChallengePreference(challengeTypes ...string) Option {
+	return func(config *Config) {
+		config.ChallengePreference = challengeTypes
+	}
+}
+
+// WithProfile requests a certificate profile offered by the CA.
+func WithProfile(profile string) Option {
+	return func(config *Config) {
+		config.Profile = profile
+	}
+}
+
+// WithStateFile persists the progress of the order to the path and resumes the order recorded in it, if usable.
+func WithStateFile(path string) Option {
+	return func(config *Config) {
+		config.StatePath = path
+	}
+}
+
+// WithDeactivateAuthorizations deactivates the authorizations of the order after issuance.
+func WithDeactivateAuthorizations(deactivate bool) Option {
+	return func(config *Config) {
+		config.DeactivateAuthorizations = deactivate
+	}
+}
+
+// WithOrderUrl continues the existing order at the URL, e.g. one created by an earlier run that was interrupted,
+// rather than creating a new order.
+func WithOrderUrl(orderUrl string) Option {
+	return func(config *Config) {
+		config.OrderUrl = orderUrl
+	}
+}
+
+// WithPollInterval sets the interval at which authorizations and the order are polled.
+func WithPollInterval(interval time.Duration) Option {
+	return func(config *Config) {
+		config.PollInterval = interval
+	}
+}
+
+// WithPollTimeout sets the longest duration waited for each authorization to become valid, and for the order to
+// become ready or be issued.
+func WithPollTimeout(timeout time.Duration) Option {
+	return func(config *Config) {
+		config.PollTimeout = timeout
+	}
+}
+
+// WithRand sets the source of randomness for signing CSRs. It is meant for tests that need reproducible CSRs;
+// otherwise crypto/rand is used.
+func WithRand(random io.Reader) Option {
+	return func(config *Config) {
+		config.Rand = random
+	}
+}
+
+// WithMustStaple selects whether CSRs request the OCSP Must-Staple extension, which requires servers using the
+// certificate to staple OCSP responses.
+func WithMustStaple(mustStaple bool) Option {
+	return func(config *Config) {
+		config.MustStaple = mustStaple
+	}
+}
+
+const wildcardPrefix = "*."
+
+// ProfileShortLived is the name of the Let's Encrypt short-lived certificate profile.
+const ProfileShortLived = "shortlived"
+
+// IpProfiles are the names of the certificate profiles under which certificates for IP addresses can be issued.
+var IpProfiles = []string{ProfileShortLived}
+
+// IsIp reports whether the identifier is an IP address rather than a domain name.
+func IsIp(identifier string) bool {
+	return net.ParseIP(identifier) != nil
+}
+
+// HasIp reports whether any of the identifiers is an IP address.
+func HasIp(identifiers []string) bool {
+	return slices.ContainsFunc(identifiers, IsIp)
+}
+
+// identifiers returns the ACME identifiers of the domains, using the IP type for IP addresses.
+func identifiers(domains []string) []acme.AuthzID {
+	var authzIds []acme.AuthzID
+	for _, domain := range domains {
+		if IsIp(domain) {
+			authzIds = append(authzIds, acme.AuthzID{Type: "ip", Value: domain})
+		} else {
+			authzIds = append(authzIds, acme.AuthzID{Type: "dns", Value: domain})
+		}
+	}
+	return authzIds
+}
+
+// sameDomains reports whether the domain lists hold the same domains, regardless of order and case.
+func sameDomains(domains []string, otherDomains []string) bool {
+	normalize := func(domains []string) []string {
+		normalized := make([]string, 0, len(domains))
+		for _, domain := range domains {
+			normalized = append(normalized, strings.ToLower(domain))
+		}
+		slices.Sort(normalized)
+		return normalized
+	}
+	return slices.Equal(normalize(domains), normalize(otherDomains))
+}
+
+// IsWildcard reports whether the domain is a wildcard domain, e.g. "*.example.com".
+func IsWildcard(domain string) bool {
+	return strings.HasPrefix(domain, wildcardPrefix)
+}
+
+// preferredChallenge returns the challenge whose type comes first in the preference. Types not in the preference rank
+// last, and ties are broken by the order of the challenges.
+func preferredChallenge(challenges []*acme.Challenge, preference []string) *acme.Challenge {
+	rank := func(challenge *acme.Challenge) int {
+		if index := slices.Index(preference, challenge.Type); index >= 0 {
+			return index
+		}
+		return len(preference)
+	}
+
+	preferred := challenges[0]
+	for _, challenge := range challenges[1:] {
+		if rank(challenge) < rank(preferred) {
+			preferred = challenge
+		}
+	}
+	return preferred
+}
+
+func keyAuthorization(client *acme.Client, challenge *acme.Challenge) (string, error) {
+	switch challenge.Type {
+	case letsencryptUtilsSolver.ChallengeTypeHttp01, letsencryptUtilsSolver.ChallengeTypeTlsAlpn01:
+		return client.HTTP01ChallengeResponse(challenge.Token)
+	case letsencryptUtilsSolver.ChallengeTypeDns01:
+		return client.DNS01ChallengeRecord(challenge.Token)
+	default:
+		return "", &motmedelErrors.InputError{Message: "The challenge type is unsupported.", Input: challenge.Type}
+	}
+}
+
+func authorize(
+	ctx context.Context,
+	client *acme.Client,
+	authorizationUrl string,
+	config *Config,
+) error {
+	authorization, err := client.GetAuthorization(ctx, authorizationUrl)
+	if err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when getting the authorization.",
+			Cause:   err,
+			Input:   authorizationUrl,
+		}
+	}
+	if authorization == nil {
+		return &motmedelErrors.InputError{Message: "The authorization is nil.", Input: authorizationUrl}
+	}
+
+	if authorization.Status == acme.StatusValid {
+		return nil
+	}
+
+	domain := authorization.Identifier.Value
+
+	// Wildcard authorizations can only be fulfilled via DNS-01, and IP address authorizations are only supported via
+	// HTTP-01 here.
+	wildcard := authorization.Wildcard
+	ip := authorization.Identifier.Type == "ip"
+
+	var candidates []*acme.Challenge
+	var offeredChallengeTypes []string
+	for _, authorizationChallenge := range authorization.Challenges {
+		if authorizationChallenge == nil {
+			continue
+		}
+		offeredChallengeTypes = append(offeredChallengeTypes, authorizationChallenge.Type)
+		if wildcard && authorizationChallenge.Type != letsencryptUtilsSolver.ChallengeTypeDns01 {
+			continue
+		}
+		if ip && authorizationChallenge.Type != letsencryptUtilsSolver.ChallengeTypeHttp01 {
+			continue
+		}
+		if challengeSolver, ok := config.Solvers[authorizationChallenge.Type]; ok && challengeSolver != nil {
+			candidates = append(candidates, authorizationChallenge)
+		}
+	}
+	if len(candidates) == 0 {
+		var configuredChallengeTypes []string
+		for _, challengeType := range slices.Sorted(maps.Keys(config.Solvers)) {
+			if config.Solvers[challengeType] != nil {
+				configuredChallengeTypes = append(configuredChallengeTypes, challengeType)
+			}
+		}
+		return &motmedelErrors.InputError{
+			Message: "No solver is available for any of the offered challenge types.",
+			Cause:   ErrNoChallengeSolver,
+			Input: map[string]any{
+				"domain":     domain,
+				"wildcard":   wildcard,
+				"ip":         ip,
+				"offered":    offeredChallengeTypes,
+				"configured": configuredChallengeTypes,
+			},
+		}
+	}
+
+	challenge := preferredChallenge(candidates, config.ChallengePreference)
+	solver := config.Solvers[challenge.Type]
+
+	keyAuth, err := keyAuthorization(client, challenge)
+	if err != nil {
+		return &motmedelErrors.CauseError{
+			Message: "An error occurred when computing the key authorization.",
+			Cause:   err,
+		}
+	}
+
+	defer func() {
+		// The clean-up is to be performed even if presenting failed midway or the context has been cancelled.
+		cleanUpCtx := context.WithoutCancel(ctx)
+		if err := solver.CleanUp(cleanUpCtx, domain, challenge.Token, keyAuth); err != nil {
+			motmedelLog.LogWarning(
+				"An error occurred when cleaning up the challenge response.",
+				&motmedelErrors.InputError{
+					Message: "An error occurred when cleaning up the challenge response.",
+					Cause:   err,
+					Input:   []any{domain, challenge.Type},
+				},
+				motmedelLog.GetLoggerFromCtxWithDefault(ctx, nil),
+			)
+		}
+	}()
+
+	if err := solver.Present(ctx, domain, challenge.Token, keyAuth); err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when presenting the challenge response.",
+			Cause:   err,
+			Input:   []any{domain, challenge.Type},
+		}
+	}
+
+	if _, err := client.Accept(ctx, challenge); err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when accepting the challenge.",
+			Cause:   err,
+			Input:   []any{domain, challenge.URI},
+		}
+	}
+
+	err = config.withPollTimeout(ctx, func(ctx context.Context) error {
+		return config.waitAuthorization(ctx, client, authorizationUrl)
+	})
+	if errors.Is(err, ErrPollTimeout) {
+		return &motmedelErrors.InputError{
+			Message: "Timed out waiting for the authorization.",
+			Cause:   err,
+			Input:   []any{domain, authorizationUrl},
+		}
+	}
+	if err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when waiting for the authorization.",
+			Cause:   errors.Join(ErrAuthorizationFailed, err),
+			Input:   []any{domain, authorizationUrl},
+		}
+	}
+
+	return nil
+}
+
+// Order creates an order for the domains, or continues an existing one, fulfils its authorizations, finalizes it with
+// the CSR, and returns the DER-encoded certificate chain, leaf first.
+func Order(
+	ctx context.Context,
+	client *acme.Client,
+	domains []string,
+	csr []byte,
+	opts ...Option,
+) ([][]byte, error) {
+	if client == nil {
+		return nil, &motmedelErrors.CauseError{Message: "The ACME client is nil."}
+	}
+
+	if len(domains) == 0 {
+		return nil, &motmedelErrors.InputError{Message: "No domains were provided."}
+	}
+
+	config := &Config{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(config)
+		}
+	}
+
+	if _, ok := config.Solvers[letsencryptUtilsSolver.ChallengeTypeDns01]; !ok {
+		for _, domain := range domains {
+			if IsWildcard(domain) {
+				return nil, &motmedelErrors.InputError{
+					Message: "Wildcard domains can only be validated via DNS-01, but no DNS-01 solver is configured.",
+					Input:   domain,
+				}
+			}
+		}
+	}
+
+	if HasIp(domains) && !slices.Contains(IpProfiles, config.Profile) {
+		return nil, &motmedelErrors.InputError{
+			Message: "Certificates for IP addresses can only be ordered with a profile that supports them.",
+			Input:   []any{config.Profile, IpProfiles},
+		}
+	}
+
+	var order *acme.Order
+	var err error
+	switch {
+	case config.OrderUrl != "":
+		order, err = existingOrder(ctx, client, config.OrderUrl, domains)
+	case config.StatePath != "":
+		order, err = resumeOrder(ctx, client, config.StatePath, domains, config.Profile)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if order == nil {
+		err = letsencryptUtilsRetry.Do(ctx, letsencryptUtilsRetry.DefaultMaxAttempts, func(ctx context.Context) error {
+			var err error
+			if config.Profile != "" {
+				order, err = authorizeOrderWithProfile(ctx, client, domains, config.Profile)
+			} else {
+				order, err = client.AuthorizeOrder(ctx, identifiers(domains))
+			}
+			return err
+		})
+		if err != nil {
+			return nil, &motmedelErrors.InputError{
+				Message: "An error occurred when creating the order.",
+				Cause:   err,
+				Input:   domains,
+			}
+		}
+		if order == nil {
+			return nil, &motmedelErrors.InputError{Message: "The order is nil.", Input: domains}
+		}
+
+		motmedelLog.GetLoggerFromCtxWithDefault(ctx, nil).Info(
+			"Created the order.",
+			slog.String("order_url", order.URI),
+		)
+	}
+
+	// An existing order may already have been finalized, in which case only its certificate remains to be downloaded.
+	if order.Status == acme.StatusProcessing || order.Status == acme.StatusValid {
+		derChain, err := finalizedOrderChain(ctx, client, order.URI, csr, config)
+		if err != nil {
+			return nil, err
+		}
+		if config.DeactivateAuthorizations {
+			deactivateAuthorizations(ctx, client, order.AuthzURLs)
+		}
+		return derChain, nil
+	}
+
+	var state *State
+	saveState := func() error { return nil }
+	if config.StatePath != "" {
+		state = &State{
+			OrderUrl:       order.URI,
+			Domains:        domains,
+			Profile:        config.Profile,
+			Authorizations: make(map[string]string),
+		}
+		for _, authorizationUrl := range order.AuthzURLs {
+			state.Authorizations[authorizationUrl] = acme.StatusPending
+		}
+		saveState = func() error { return state.Save(config.StatePath) }
+		if err := saveState(); err != nil {
+			return nil, err
+		}
+	}
+
+	// A timed out order is reported along with the authorizations that it was still waiting for.
+	authorizationUrls := order.AuthzURLs
+	timedOut := func(orderUri string, err error) error {
+		return &motmedelErrors.InputError{
+			Message: "Timed out waiting for the order.",
+			Cause:   err,
+			Input:   []any{orderUri, pendingAuthorizations(ctx, client, authorizationUrls)},
+		}
+	}
+
+	for _, authorizationUrl := range order.AuthzURLs {
+		if err := authorize(ctx, client, authorizationUrl, config); err != nil {
+			if errors.Is(err, ErrPollTimeout) {
+				return nil, timedOut(order.URI, err)
+			}
+			return nil, err
+		}
+		if state != nil {
+			state.Authorizations[authorizationUrl] = acme.StatusValid
+			if err := saveState(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	orderUri := order.URI
+	err = config.withPollTimeout(ctx, func(ctx context.Context) error {
+		var err error
+		order, err = config.waitOrder(ctx, client, orderUri)
+		return err
+	})
+	if errors.Is(err, ErrPollTimeout) {
+		return nil, timedOut(orderUri, err)
+	}
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when waiting for the order.",
+			Cause:   err,
+			Input:   orderUri,
+		}
+	}
+	if order == nil {
+		return nil, &motmedelErrors.InputError{Message: "The order is nil.", Input: orderUri}
+	}
+
+	// Once finalized, the order cannot be resumed, as the key of the CSR is not retained.
+	if config.StatePath != "" {
+		if err := os.Remove(config.StatePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, &motmedelErrors.InputError{
+				Message: "An error occurred when removing the order state file.",
+				Cause:   err,
+				Input:   config.StatePath,
+			}
+		}
+	}
+
+	// The ACME client polls the processing order itself, as suggested by the CA, so only the poll timeout applies.
+	var derChain [][]byte
+	err = config.withPollTimeout(ctx, func(ctx context.Context) error {
+		return letsencryptUtilsRetry.Do(ctx, letsencryptUtilsRetry.DefaultMaxAttempts, func(ctx context.Context) error {
+			var err error
+			derChain, _, err = client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+			return err
+		})
+	})
+	if errors.Is(err, ErrPollTimeout) {
+		return nil, timedOut(orderUri, err)
+	}
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when finalizing the order.",
+			Cause:   err,
+			Input:   order.FinalizeURL,
+		}
+	}
+
+	if config.DeactivateAuthorizations {
+		deactivateAuthorizations(ctx, client, order.AuthzURLs)
+	}
+
+	return derChain, nil
+}
+
+// existingOrder retrieves the order at the URL so that it can be continued. The order must be for the domains, and
+// neither in a terminal error state nor expired.
+func existingOrder(ctx context.Context, client *acme.Client, orderUrl string, domains []string) (*acme.Order, error) {
+	var order *acme.Order
+	err := letsencryptUtilsRetry.Do(ctx, letsencryptUtilsRetry.DefaultMaxAttempts, func(ctx context.Context) error {
+		var err error
+		order, err = client.GetOrder(ctx, orderUrl)
+		return err
+	})
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when getting the order.",
+			Cause:   err,
+			Input:   orderUrl,
+		}
+	}
+	if order == nil {
+		return nil, &motmedelErrors.InputError{Message: "The order is nil.", Input: orderUrl}
+	}
+
+	var orderDomains []string
+	for _, identifier := range order.Identifiers {
+		orderDomains = append(orderDomains, identifier.Value)
+	}
+	if !sameDomains(orderDomains, domains) {
+		return nil, &motmedelErrors.InputError{
+			Message: "The order is for other domains.",
+			Input:   []any{orderUrl, orderDomains, domains},
+		}
+	}
+
+	switch order.Status {
+	case acme.StatusPending, acme.StatusReady:
+		if !order.Expires.IsZero() && time.Now().After(order.Expires) {
+			return nil, &motmedelErrors.InputError{
+				Message: "The order has expired.",
+				Cause:   ErrOrderExpired,
+				Input:   []any{orderUrl, order.Expires},
+			}
+		}
+	case acme.StatusProcessing, acme.StatusValid:
+	default:
+		cause := ErrOrderInvalid
+		if order.Error != nil {
+			cause = errors.Join(ErrOrderInvalid, order.Error)
+		}
+		return nil, &motmedelErrors.InputError{
+			Message: "The order is in a terminal error state.",
+			Cause:   cause,
+			Input:   []any{orderUrl, order.Status},
+		}
+	}
+
+	motmedelLog.GetLoggerFromCtxWithDefault(ctx, nil).Info(
+		"Continuing the order.",
+		slog.String("order_url", order.URI),
+		slog.String("status", order.Status),
+	)
+
+	return order, nil
+}
+
+// finalizedOrderChain waits for the certificate of an order that was finalized earlier and downloads its chain. As
+// the CSR with which the order was finalized is not known, the certificate must be for the key of the provided CSR.
+func finalizedOrderChain(
+	ctx context.Context,
+	client *acme.Client,
+	orderUrl string,
+	csr []byte,
+	config *Config,
+) ([][]byte, error) {
+	var order *acme.Order
+	err := config.withPollTimeout(ctx, func(ctx context.Context) error {
+		var err error
+		order, err = config.waitOrder(ctx, client, orderUrl)
+		return err
+	})
+	if errors.Is(err, ErrPollTimeout) {
+		return nil, &motmedelErrors.InputError{
+			Message: "Timed out waiting for the order.",
+			Cause:   err,
+			Input:   orderUrl,
+		}
+	}
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when waiting for the order.",
+			Cause:   err,
+			Input:   orderUrl,
+		}
+	}
+	if order == nil {
+		return nil, &motmedelErrors.InputError{Message: "The order is nil.", Input: orderUrl}
+	}
+
+	var derChain [][]byte
+	err = letsencryptUtilsRetry.Do(ctx, letsencryptUtilsRetry.DefaultMaxAttempts, func(ctx context.Context) error {
+		var err error
+		derChain, err = client.FetchCert(ctx, order.CertURL, true)
+		return err
+	})
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when downloading the certificate.",
+			Cause:   err,
+			Input:   order.CertURL,
+		}
+	}
+
+	csrRequest, err := x509.ParseCertificateRequest(csr)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when parsing the CSR.", Cause: err}
+	}
+	err = letsencryptUtilsCertificate.VerifyPublicKey(EncodeChainPem(derChain), csrRequest.PublicKey)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "The order was finalized with a CSR for another key, which was not retained.",
+			Cause:   errors.Join(ErrOrderFinalizedWithOtherKey, err),
+			Input:   orderUrl,
+		}
+	}
+
+	return derChain, nil
+}
+
+// deactivateAuthorizations deactivates the authorizations, logging rather than returning failures, as the certificate
+// has already been obtained.
+func deactivateAuthorizations(ctx context.Context, client *acme.Client, authorizationUrls []string) {
+	logger := motmedelLog.GetLoggerFromCtxWithDefault(ctx, nil)
+	for _, authorizationUrl := range authorizationUrls {
+		if err := client.RevokeAuthorization(ctx, authorizationUrl); err != nil {
+			motmedelLog.LogWarning(
+				"An error occurred when deactivating the authorization.",
+				&motmedelErrors.InputError{
+					Message: "An error occurred when deactivating the authorization.",
+					Cause:   err,
+					Input:   authorizationUrl,
+				},
+				logger,
+			)
+		}
+	}
+}
+
+// EncodeChainPem encodes a DER-encoded certificate chain as concatenated PEM blocks.
+func EncodeChainPem(derChain [][]byte) []byte {
+	var pemData []byte
+	for _, derData := range derChain {
+		pemData = append(pemData, pem.EncodeToMemory(&pem.Block{Type: CertificateBlockType, Bytes: derData})...)
+	}
+	return pemData
+}
@@ -0,0 +1,541 @@
+package order_test
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	letsencryptUtilsAccount "github.com/altshiftab/letsencrypt_utils/pkg/account"
+	letsencryptUtilsAcmetest "github.com/altshiftab/letsencrypt_utils/pkg/acmetest"
+	letsencryptUtilsCertificate "github.com/altshiftab/letsencrypt_utils/pkg/certificate"
+	letsencryptUtilsKey "github.com/altshiftab/letsencrypt_utils/pkg/key"
+	letsencryptUtilsOrder "github.com/altshiftab/letsencrypt_utils/pkg/order"
+	letsencryptUtilsSolver "github.com/altshiftab/letsencrypt_utils/pkg/solver"
+	"golang.org/x/crypto/acme"
+	mathRand "math/rand"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingSolver records the key authorizations it is asked to present, and whether they were cleaned up.
+type recordingSolver struct {
+	mutex     sync.Mutex
+	presented map[string]string
+	cleanedUp []string
+}
+
+func (solver *recordingSolver) Present(_ context.Context, domain string, _ string, keyAuth string) error {
+	solver.mutex.Lock()
+	defer solver.mutex.Unlock()
+
+	if solver.presented == nil {
+		solver.presented = make(map[string]string)
+	}
+	solver.presented[domain] = keyAuth
+	return nil
+}
+
+func (solver *recordingSolver) CleanUp(_ context.Context, domain string, _ string, _ string) error {
+	solver.mutex.Lock()
+	defer solver.mutex.Unlock()
+
+	solver.cleanedUp = append(solver.cleanedUp, domain)
+	return nil
+}
+
+// validator returns a validator that accepts a challenge only if the solver presented the expected key authorization
+// for it.
+func (solver *recordingSolver) validator(challengeType string) letsencryptUtilsAcmetest.Validator {
+	return func(validatedType string, identifier string, _ string, keyAuthorization string) error {
+		solver.mutex.Lock()
+		defer solver.mutex.Unlock()
+
+		if validatedType != challengeType {
+			return fmt.Errorf("the challenge type %q was validated rather than %q", validatedType, challengeType)
+		}
+		if presented := solver.presented[identifier]; presented != keyAuthorization {
+			return fmt.Errorf("the presented key authorization %q is not %q", presented, keyAuthorization)
+		}
+		return nil
+	}
+}
+
+func newClient(
+	t *testing.T,
+	options ...letsencryptUtilsAcmetest.Option,
+) (*letsencryptUtilsAcmetest.Server, *acme.Client) {
+	t.Helper()
+
+	server, err := letsencryptUtilsAcmetest.NewServer(options...)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	t.Cleanup(server.Close)
+
+	accountCredentials, err := letsencryptUtilsAccount.RegisterAccount(
+		context.Background(),
+		"user@example.org",
+		letsencryptUtilsAccount.WithDirectoryUrl(server.DirectoryUrl()),
+	)
+	if err != nil {
+		t.Fatalf("RegisterAccount: %v", err)
+	}
+
+	client, err := accountCredentials.Client(server.DirectoryUrl())
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+
+	return server, client
+}
+
+func buildCsr(t *testing.T, domains []string, options ...letsencryptUtilsOrder.Option) []byte {
+	t.Helper()
+
+	certificateKey, err := letsencryptUtilsKey.Generate(nil)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	csr, err := letsencryptUtilsOrder.BuildCSR(certificateKey, domains, pkix.Name{}, options...)
+	if err != nil {
+		t.Fatalf("BuildCSR: %v", err)
+	}
+
+	return csr
+}
+
+func verifyChain(t *testing.T, server *letsencryptUtilsAcmetest.Server, derChain [][]byte, domains []string) {
+	t.Helper()
+
+	if len(derChain) != 2 {
+		t.Fatalf("the chain has %d certificates, want 2", len(derChain))
+	}
+	leaf, err := x509.ParseCertificate(derChain[0])
+	if err != nil {
+		t.Fatalf("ParseCertificate: %v", err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.CaCertificate())
+	for _, domain := range domains {
+		if _, err := leaf.Verify(x509.VerifyOptions{DNSName: domain, Roots: roots}); err != nil {
+			t.Errorf("Verify %q: %v", domain, err)
+		}
+	}
+}
+
+func TestOrderHttp01(t *testing.T) {
+	solver := &recordingSolver{}
+	server, client := newClient(
+		t,
+		letsencryptUtilsAcmetest.WithValidator(solver.validator(letsencryptUtilsSolver.ChallengeTypeHttp01)),
+	)
+
+	domains := []string{"example.org", "www.example.org"}
+	derChain, err := letsencryptUtilsOrder.Order(
+		context.Background(),
+		client,
+		domains,
+		buildCsr(t, domains),
+		letsencryptUtilsOrder.WithSolver(letsencryptUtilsSolver.ChallengeTypeHttp01, solver),
+	)
+	if err != nil {
+		t.Fatalf("Order: %v", err)
+	}
+
+	verifyChain(t, server, derChain, domains)
+
+	cleanedUp := slices.Sorted(slices.Values(solver.cleanedUp))
+	if !slices.Equal(cleanedUp, domains) {
+		t.Errorf("cleaned up %q, want %q", cleanedUp, domains)
+	}
+}
+
+func TestOrderWildcardDns01(t *testing.T) {
+	solver := &recordingSolver{}
+	server, client := newClient(
+		t,
+		letsencryptUtilsAcmetest.WithValidator(solver.validator(letsencryptUtilsSolver.ChallengeTypeDns01)),
+	)
+
+	domains := []string{"*.example.org"}
+	derChain, err := letsencryptUtilsOrder.Order(
+		context.Background(),
+		client,
+		domains,
+		buildCsr(t, domains),
+		letsencryptUtilsOrder.WithSolver(letsencryptUtilsSolver.ChallengeTypeHttp01, &recordingSolver{}),
+		letsencryptUtilsOrder.WithSolver(letsencryptUtilsSolver.ChallengeTypeDns01, solver),
+	)
+	if err != nil {
+		t.Fatalf("Order: %v", err)
+	}
+
+	verifyChain(t, server, derChain, []string{"www.example.org"})
+}
+
+func TestOrderChallengePreference(t *testing.T) {
+	solver := &recordingSolver{}
+	server, client := newClient(
+		t,
+		letsencryptUtilsAcmetest.WithValidator(solver.validator(letsencryptUtilsSolver.ChallengeTypeDns01)),
+	)
+
+	// HTTP-01 is offered first, but DNS-01 is preferred.
+	domains := []string{"www.example.org"}
+	httpSolver := &recordingSolver{}
+	derChain, err := letsencryptUtilsOrder.Order(
+		context.Background(),
+		client,
+		domains,
+		buildCsr(t, domains),
+		letsencryptUtilsOrder.WithSolver(letsencryptUtilsSolver.ChallengeTypeHttp01, httpSolver),
+		letsencryptUtilsOrder.WithSolver(letsencryptUtilsSolver.ChallengeTypeDns01, solver),
+		letsencryptUtilsOrder.WithChallengePreference(
+			letsencryptUtilsSolver.ChallengeTypeDns01,
+			letsencryptUtilsSolver.ChallengeTypeHttp01,
+		),
+	)
+	if err != nil {
+		t.Fatalf("Order: %v", err)
+	}
+	if len(httpSolver.presented) != 0 {
+		t.Errorf("HTTP-01 was presented for %v", httpSolver.presented)
+	}
+
+	verifyChain(t, server, derChain, domains)
+}
+
+func TestOrderNoSolverForOfferedChallenges(t *testing.T) {
+	_, client := newClient(
+		t,
+		letsencryptUtilsAcmetest.WithProfile(letsencryptUtilsOrder.ProfileShortLived, "Short-lived certificates."),
+	)
+
+	// IP address authorizations are only solved via HTTP-01.
+	domains := []string{"192.0.2.1"}
+	_, err := letsencryptUtilsOrder.Order(
+		context.Background(),
+		client,
+		domains,
+		buildCsr(t, domains),
+		letsencryptUtilsOrder.WithSolver(letsencryptUtilsSolver.ChallengeTypeTlsAlpn01, &recordingSolver{}),
+		letsencryptUtilsOrder.WithProfile(letsencryptUtilsOrder.ProfileShortLived),
+	)
+	if !errors.Is(err, letsencryptUtilsOrder.ErrNoChallengeSolver) {
+		t.Fatalf("Order error = %v, want ErrNoChallengeSolver", err)
+	}
+}
+
+func TestOrderWithProfile(t *testing.T) {
+	server, client := newClient(t, letsencryptUtilsAcmetest.WithProfile("shortlived", "Short-lived certificates."))
+
+	domains := []string{"example.org"}
+	solverOption := letsencryptUtilsOrder.WithSolver(letsencryptUtilsSolver.ChallengeTypeHttp01, &recordingSolver{})
+
+	derChain, err := letsencryptUtilsOrder.Order(
+		context.Background(),
+		client,
+		domains,
+		buildCsr(t, domains),
+		solverOption,
+		letsencryptUtilsOrder.WithProfile("shortlived"),
+	)
+	if err != nil {
+		t.Fatalf("Order: %v", err)
+	}
+	verifyChain(t, server, derChain, domains)
+
+	_, err = letsencryptUtilsOrder.Order(
+		context.Background(),
+		client,
+		domains,
+		buildCsr(t, domains),
+		solverOption,
+		letsencryptUtilsOrder.WithProfile("unknown"),
+	)
+	if err == nil {
+		t.Fatal("Order succeeded with a profile that is not offered")
+	}
+}
+
+func TestOrderFailedValidation(t *testing.T) {
+	_, client := newClient(
+		t,
+		letsencryptUtilsAcmetest.WithValidator(func(string, string, string, string) error {
+			return errors.New("the challenge response was not found")
+		}),
+	)
+
+	domains := []string{"example.org"}
+	_, err := letsencryptUtilsOrder.Order(
+		context.Background(),
+		client,
+		domains,
+		buildCsr(t, domains),
+		letsencryptUtilsOrder.WithSolver(letsencryptUtilsSolver.ChallengeTypeHttp01, &recordingSolver{}),
+	)
+	if !errors.Is(err, letsencryptUtilsOrder.ErrAuthorizationFailed) {
+		t.Fatalf("Order error = %v, want ErrAuthorizationFailed", err)
+	}
+}
+
+func TestOrderCsrMismatch(t *testing.T) {
+	_, client := newClient(t)
+
+	_, err := letsencryptUtilsOrder.Order(
+		context.Background(),
+		client,
+		[]string{"example.org"},
+		buildCsr(t, []string{"example.org", "other.example.org"}),
+		letsencryptUtilsOrder.WithSolver(letsencryptUtilsSolver.ChallengeTypeHttp01, &recordingSolver{}),
+	)
+	if err == nil {
+		t.Fatal("Order succeeded with a CSR whose names do not match the order")
+	}
+}
+
+func TestOrderMustStaple(t *testing.T) {
+	_, client := newClient(t)
+
+	for _, mustStaple := range []bool{false, true} {
+		domains := []string{"example.org"}
+		derChain, err := letsencryptUtilsOrder.Order(
+			context.Background(),
+			client,
+			domains,
+			buildCsr(t, domains, letsencryptUtilsOrder.WithMustStaple(mustStaple)),
+			letsencryptUtilsOrder.WithSolver(letsencryptUtilsSolver.ChallengeTypeHttp01, &recordingSolver{}),
+		)
+		if err != nil {
+			t.Fatalf("Order: %v", err)
+		}
+
+		err = letsencryptUtilsCertificate.VerifyMustStaple(letsencryptUtilsOrder.EncodeChainPem(derChain))
+		if mustStaple && err != nil {
+			t.Errorf("VerifyMustStaple: %v", err)
+		} else if !mustStaple && err == nil {
+			t.Error("VerifyMustStaple succeeded for a certificate ordered without Must-Staple")
+		}
+	}
+}
+
+func TestParseCsr(t *testing.T) {
+	certificateKey, err := letsencryptUtilsKey.Generate(nil)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	derData, err := x509.CreateCertificateRequest(
+		rand.Reader,
+		&x509.CertificateRequest{
+			Subject:     pkix.Name{CommonName: "Example.org"},
+			DNSNames:    []string{"WWW.example.org", "example.org"},
+			IPAddresses: []net.IP{net.ParseIP("192.0.2.1")},
+		},
+		certificateKey,
+	)
+	if err != nil {
+		t.Fatalf("CreateCertificateRequest: %v", err)
+	}
+	pemData := pem.EncodeToMemory(&pem.Block{Type: letsencryptUtilsOrder.CertificateRequestBlockType, Bytes: derData})
+
+	wantDomains := []string{"www.example.org", "example.org", "192.0.2.1"}
+	for _, data := range [][]byte{derData, pemData} {
+		csr, err := letsencryptUtilsOrder.ParseCSR(data)
+		if err != nil {
+			t.Fatalf("ParseCSR: %v", err)
+		}
+		if domains := letsencryptUtilsOrder.CSRDomains(csr); !slices.Equal(domains, wantDomains) {
+			t.Errorf("CSRDomains = %q, want %q", domains, wantDomains)
+		}
+	}
+
+	if _, err := letsencryptUtilsOrder.ParseCSR(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE"})); err == nil {
+		t.Error("ParseCSR succeeded for a PEM block that is not a CSR")
+	}
+}
+
+func TestOrderContinueByUrl(t *testing.T) {
+	server, client := newClient(t)
+	ctx := context.Background()
+
+	domains := []string{"example.org"}
+	existingOrder, err := client.AuthorizeOrder(ctx, acme.DomainIDs(domains...))
+	if err != nil {
+		t.Fatalf("AuthorizeOrder: %v", err)
+	}
+
+	csr := buildCsr(t, domains)
+	solverOption := letsencryptUtilsOrder.WithSolver(letsencryptUtilsSolver.ChallengeTypeHttp01, &recordingSolver{})
+	derChain, err := letsencryptUtilsOrder.Order(
+		ctx,
+		client,
+		domains,
+		csr,
+		solverOption,
+		letsencryptUtilsOrder.WithOrderUrl(existingOrder.URI),
+	)
+	if err != nil {
+		t.Fatalf("Order: %v", err)
+	}
+	verifyChain(t, server, derChain, domains)
+
+	// The order is now valid, so its certificate is downloaded if the CSR is for the key it was finalized with.
+	downloadedChain, err := letsencryptUtilsOrder.Order(
+		ctx,
+		client,
+		domains,
+		csr,
+		solverOption,
+		letsencryptUtilsOrder.WithOrderUrl(existingOrder.URI),
+	)
+	if err != nil {
+		t.Fatalf("Order of the valid order: %v", err)
+	}
+	if !slices.EqualFunc(downloadedChain, derChain, slices.Equal) {
+		t.Error("the downloaded chain differs from the issued one")
+	}
+
+	_, err = letsencryptUtilsOrder.Order(
+		ctx,
+		client,
+		domains,
+		buildCsr(t, domains),
+		solverOption,
+		letsencryptUtilsOrder.WithOrderUrl(existingOrder.URI),
+	)
+	if !errors.Is(err, letsencryptUtilsOrder.ErrOrderFinalizedWithOtherKey) {
+		t.Errorf("Order error = %v, want ErrOrderFinalizedWithOtherKey", err)
+	}
+
+	_, err = letsencryptUtilsOrder.Order(
+		ctx,
+		client,
+		[]string{"other.example.org"},
+		buildCsr(t, []string{"other.example.org"}),
+		solverOption,
+		letsencryptUtilsOrder.WithOrderUrl(existingOrder.URI),
+	)
+	if err == nil {
+		t.Error("Order succeeded with an order for other domains")
+	}
+}
+
+func TestOrderContinueInvalidByUrl(t *testing.T) {
+	_, client := newClient(
+		t,
+		letsencryptUtilsAcmetest.WithValidator(func(string, string, string, string) error {
+			return errors.New("the challenge response was not found")
+		}),
+	)
+	ctx := context.Background()
+
+	domains := []string{"example.org"}
+	existingOrder, err := client.AuthorizeOrder(ctx, acme.DomainIDs(domains...))
+	if err != nil {
+		t.Fatalf("AuthorizeOrder: %v", err)
+	}
+
+	solverOption := letsencryptUtilsOrder.WithSolver(letsencryptUtilsSolver.ChallengeTypeHttp01, &recordingSolver{})
+	orderUrlOption := letsencryptUtilsOrder.WithOrderUrl(existingOrder.URI)
+
+	_, err = letsencryptUtilsOrder.Order(ctx, client, domains, buildCsr(t, domains), solverOption, orderUrlOption)
+	if !errors.Is(err, letsencryptUtilsOrder.ErrAuthorizationFailed) {
+		t.Fatalf("Order error = %v, want ErrAuthorizationFailed", err)
+	}
+
+	_, err = letsencryptUtilsOrder.Order(ctx, client, domains, buildCsr(t, domains), solverOption, orderUrlOption)
+	if !errors.Is(err, letsencryptUtilsOrder.ErrOrderInvalid) {
+		t.Errorf("Order error = %v, want ErrOrderInvalid", err)
+	}
+}
+
+func TestOrderPollInterval(t *testing.T) {
+	server, client := newClient(t)
+
+	domains := []string{"example.org", "www.example.org"}
+	derChain, err := letsencryptUtilsOrder.Order(
+		context.Background(),
+		client,
+		domains,
+		buildCsr(t, domains),
+		letsencryptUtilsOrder.WithSolver(letsencryptUtilsSolver.ChallengeTypeHttp01, &recordingSolver{}),
+		letsencryptUtilsOrder.WithPollInterval(10*time.Millisecond),
+		letsencryptUtilsOrder.WithPollTimeout(time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("Order: %v", err)
+	}
+	verifyChain(t, server, derChain, domains)
+
+	_, client = newClient(
+		t,
+		letsencryptUtilsAcmetest.WithValidator(func(string, string, string, string) error {
+			return errors.New("the challenge response was not found")
+		}),
+	)
+	_, err = letsencryptUtilsOrder.Order(
+		context.Background(),
+		client,
+		domains,
+		buildCsr(t, domains),
+		letsencryptUtilsOrder.WithSolver(letsencryptUtilsSolver.ChallengeTypeHttp01, &recordingSolver{}),
+		letsencryptUtilsOrder.WithPollInterval(10*time.Millisecond),
+	)
+	if !errors.Is(err, letsencryptUtilsOrder.ErrAuthorizationFailed) {
+		t.Fatalf("Order error = %v, want ErrAuthorizationFailed", err)
+	}
+}
+
+func TestBuildCSRWithRandIsReproducible(t *testing.T) {
+	// Only RSA and Ed25519 signatures are reproducible, but keys of all types are.
+	testCases := []struct {
+		name            string
+		spec            *letsencryptUtilsKey.Spec
+		reproducibleCsr bool
+	}{
+		{name: "ecdsa", spec: &letsencryptUtilsKey.Spec{Type: letsencryptUtilsKey.TypeEcdsa}},
+		{name: "rsa", spec: &letsencryptUtilsKey.Spec{Type: letsencryptUtilsKey.TypeRsa}, reproducibleCsr: true},
+		{name: "ed25519", spec: &letsencryptUtilsKey.Spec{Type: letsencryptUtilsKey.TypeEd25519}, reproducibleCsr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			build := func() (crypto.Signer, []byte) {
+				key, err := letsencryptUtilsKey.GenerateWithRand(testCase.spec, mathRand.New(mathRand.NewSource(1)))
+				if err != nil {
+					t.Fatalf("GenerateWithRand: %v", err)
+				}
+				// A nil option is ignored.
+				csr, err := letsencryptUtilsOrder.BuildCSR(
+					key,
+					[]string{"example.org"},
+					pkix.Name{},
+					nil,
+					letsencryptUtilsOrder.WithRand(mathRand.New(mathRand.NewSource(2))),
+				)
+				if err != nil {
+					t.Fatalf("BuildCSR: %v", err)
+				}
+				return key, csr
+			}
+
+			firstKey, firstCsr := build()
+			secondKey, secondCsr := build()
+			if !firstKey.(interface{ Equal(crypto.PrivateKey) bool }).Equal(secondKey) {
+				t.Error("the keys generated from the same seed differ")
+			}
+			if testCase.reproducibleCsr && !bytes.Equal(firstCsr, secondCsr) {
+				t.Error("the CSRs built from the same seed differ")
+			}
+		})
+	}
+}
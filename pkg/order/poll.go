@@ -0,0 +1,124 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"golang.org/x/crypto/acme"
+	"time"
+)
+
+// waitAuthorization waits for the authorization to become valid. Without a poll interval, the authorization is polled
+// as the ACME client does by default: as suggested by the CA via Retry-After, and otherwise every second.
+func (config *Config) waitAuthorization(ctx context.Context, client *acme.Client, authorizationUrl string) error {
+	if config.PollInterval <= 0 {
+		_, err := client.WaitAuthorization(ctx, authorizationUrl)
+		return err
+	}
+
+	return poll(ctx, config.PollInterval, func(ctx context.Context) (bool, error) {
+		authorization, err := client.GetAuthorization(ctx, authorizationUrl)
+		if err != nil {
+			return false, err
+		}
+
+		switch authorization.Status {
+		case acme.StatusValid:
+			return true, nil
+		case acme.StatusPending, acme.StatusProcessing:
+			return false, nil
+		default:
+			authorizationError := &acme.AuthorizationError{
+				URI:        authorizationUrl,
+				Identifier: authorization.Identifier.Value,
+			}
+			for _, challenge := range authorization.Challenges {
+				if challenge != nil && challenge.Error != nil {
+					authorizationError.Errors = append(authorizationError.Errors, challenge.Error)
+				}
+			}
+			return false, authorizationError
+		}
+	})
+}
+
+// waitOrder waits for the order to become ready or valid, polling as waitAuthorization does.
+func (config *Config) waitOrder(ctx context.Context, client *acme.Client, orderUrl string) (*acme.Order, error) {
+	if config.PollInterval <= 0 {
+		return client.WaitOrder(ctx, orderUrl)
+	}
+
+	var order *acme.Order
+	err := poll(ctx, config.PollInterval, func(ctx context.Context) (bool, error) {
+		var err error
+		order, err = client.GetOrder(ctx, orderUrl)
+		if err != nil {
+			return false, err
+		}
+
+		switch order.Status {
+		case acme.StatusReady, acme.StatusValid:
+			return true, nil
+		case acme.StatusInvalid:
+			return false, &acme.OrderError{OrderURL: orderUrl, Status: order.Status}
+		default:
+			return false, nil
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// withPollTimeout runs the wait within the poll timeout, if any, and reports a wait that it cuts short as
+// ErrPollTimeout.
+func (config *Config) withPollTimeout(ctx context.Context, wait func(ctx context.Context) error) error {
+	if config.PollTimeout <= 0 {
+		return wait(ctx)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, config.PollTimeout)
+	defer cancel()
+
+	err := wait(timeoutCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) {
+		return errors.Join(ErrPollTimeout, err)
+	}
+	return err
+}
+
+// poll calls the check at the interval until it reports completion or fails, or the context is done.
+func poll(ctx context.Context, interval time.Duration, check func(ctx context.Context) (bool, error)) error {
+	for {
+		done, err := check(ctx)
+		if err != nil || done {
+			return err
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// pendingAuthorizations returns the identifiers of the authorizations that are not yet valid, for reporting a timed
+// out order. Authorizations that cannot be retrieved are reported by URL.
+func pendingAuthorizations(ctx context.Context, client *acme.Client, authorizationUrls []string) []string {
+	var pending []string
+	for _, authorizationUrl := range authorizationUrls {
+		authorization, err := client.GetAuthorization(ctx, authorizationUrl)
+		if err != nil || authorization == nil {
+			pending = append(pending, authorizationUrl)
+			continue
+		}
+		if authorization.Status != acme.StatusValid {
+			pending = append(pending, authorization.Identifier.Value)
+		}
+	}
+	return pending
+}
@@ -0,0 +1,63 @@
+package order
+
+import (
+	"context"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsJws "github.com/altshiftab/letsencrypt_utils/internal/jws"
+	"golang.org/x/crypto/acme"
+)
+
+type identifier struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type newOrderRequest struct {
+	Identifiers []identifier `json:"identifiers"`
+	Profile     string       `json:"profile,omitempty"`
+}
+
+// authorizeOrderWithProfile creates an order with a profile field, which the acme package does not support, and
+// retrieves the created order with the acme package.
+func authorizeOrderWithProfile(
+	ctx context.Context,
+	client *acme.Client,
+	domains []string,
+	profile string,
+) (*acme.Order, error) {
+	directory, err := client.Discover(ctx)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when discovering the directory.", Cause: err}
+	}
+
+	request := newOrderRequest{Profile: profile}
+	for _, authzId := range identifiers(domains) {
+		request.Identifiers = append(request.Identifiers, identifier{Type: authzId.Type, Value: authzId.Value})
+	}
+
+	response, err := letsencryptUtilsJws.Post(ctx, client, directory.OrderURL, request)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when posting the new order.",
+			Cause:   err,
+			Input:   profile,
+		}
+	}
+	response.Body.Close()
+
+	orderUri := response.Header.Get("Location")
+	if orderUri == "" {
+		return nil, &motmedelErrors.CauseError{Message: "The new order response has no location."}
+	}
+
+	order, err := client.GetOrder(ctx, orderUri)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when getting the order.",
+			Cause:   err,
+			Input:   orderUri,
+		}
+	}
+
+	return order, nil
+}
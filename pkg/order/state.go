@@ -0,0 +1,125 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	motmedelLog "github.com/Motmedel/utils_go/pkg/log"
+	letsencryptUtilsFileutil "github.com/altshiftab/letsencrypt_utils/internal/fileutil"
+	"golang.org/x/crypto/acme"
+	"io/fs"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// State is the persisted progress of an order, from which an interrupted order can be resumed.
+type State struct {
+	OrderUrl string   `json:"order_url"`
+	Domains  []string `json:"domains"`
+	Profile  string   `json:"profile,omitempty"`
+	// Authorizations maps the authorization URLs of the order to their last known status.
+	Authorizations map[string]string `json:"authorizations"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// LoadState reads the order state file at the path. A missing file yields a nil state.
+func LoadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when reading the order state file.",
+			Cause:   err,
+			Input:   path,
+		}
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when parsing the order state file.",
+			Cause:   err,
+			Input:   path,
+		}
+	}
+
+	return &state, nil
+}
+
+// Save writes the order state to the path atomically.
+func (state *State) Save(path string) error {
+	state.UpdatedAt = time.Now().UTC()
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return &motmedelErrors.CauseError{Message: "An error occurred when marshalling the order state.", Cause: err}
+	}
+
+	if err := letsencryptUtilsFileutil.WriteFileAtomic(path, data, 0600); err != nil {
+		return &motmedelErrors.CauseError{Message: "An error occurred when writing the order state.", Cause: err}
+	}
+
+	return nil
+}
+
+// matches reports whether the state describes an order for the same domains and profile.
+func (state *State) matches(domains []string, profile string) bool {
+	return state.Profile == profile && sameDomains(state.Domains, domains)
+}
+
+// resumeOrder returns the order of the state file if it exists, matches the domains and profile, and is still
+// usable, i.e. pending or ready and not expired. A stale state file is removed. A nil order means that a new order
+// is to be created.
+func resumeOrder(
+	ctx context.Context,
+	client *acme.Client,
+	statePath string,
+	domains []string,
+	profile string,
+) (*acme.Order, error) {
+	state, err := LoadState(statePath)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, nil
+	}
+
+	logger := motmedelLog.GetLoggerFromCtxWithDefault(ctx, nil)
+	discard := func(reason string) (*acme.Order, error) {
+		logger.Info("Discarding the stale order state.", slog.String("reason", reason))
+		if err := os.Remove(statePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, &motmedelErrors.InputError{
+				Message: "An error occurred when removing the order state file.",
+				Cause:   err,
+				Input:   statePath,
+			}
+		}
+		return nil, nil
+	}
+
+	if state.OrderUrl == "" || !state.matches(domains, profile) {
+		return discard("The order state is for different domains or a different profile.")
+	}
+
+	order, err := client.GetOrder(ctx, state.OrderUrl)
+	if err != nil {
+		return discard("The order could not be retrieved.")
+	}
+
+	// An order that is processing or valid has been finalized with a key that has not been retained.
+	if order.Status != acme.StatusPending && order.Status != acme.StatusReady {
+		return discard("The order is " + order.Status + ".")
+	}
+	if !order.Expires.IsZero() && time.Now().After(order.Expires) {
+		return discard("The order has expired.")
+	}
+
+	logger.Info("Resuming the order.", slog.String("order_url", order.URI))
+
+	return order, nil
+}
@@ -0,0 +1,51 @@
+package redact
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+const mailtoPrefix = "mailto:"
+
+var disabled atomic.Bool
+
+// SetEnabled turns the redaction on or off. Redaction is on by default; turning it off is meant for debugging.
+func SetEnabled(enabled bool) {
+	disabled.Store(!enabled)
+}
+
+// Enabled reports whether redaction is on.
+func Enabled() bool {
+	return !disabled.Load()
+}
+
+// Email masks all but the first character of the local part of the email address, e.g. "j***@example.com", so that
+// it can be logged. Values that are not email addresses are masked entirely.
+func Email(email string) string {
+	if !Enabled() {
+		return email
+	}
+
+	localPart, domain, found := strings.Cut(email, "@")
+	if !found || localPart == "" {
+		return "***"
+	}
+
+	return localPart[:1] + "***@" + domain
+}
+
+// Contacts masks the email addresses of "mailto:" contact URIs, leaving other contacts intact.
+func Contacts(contacts []string) []string {
+	if !Enabled() || contacts == nil {
+		return contacts
+	}
+
+	redactedContacts := make([]string, 0, len(contacts))
+	for _, contact := range contacts {
+		if email, ok := strings.CutPrefix(contact, mailtoPrefix); ok {
+			contact = mailtoPrefix + Email(email)
+		}
+		redactedContacts = append(redactedContacts, contact)
+	}
+	return redactedContacts
+}
@@ -0,0 +1,49 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"golang.org/x/crypto/acme"
+	"time"
+)
+
+// MaxRateLimitWait is the longest rate-limit delay that is waited out when the context has no deadline.
+const MaxRateLimitWait = 5 * time.Minute
+
+// RateLimitError reports that the ACME server rate-limited a request, and when it may be tried again.
+type RateLimitError struct {
+	// RetryAfter is when the request may be tried again, or the zero time if the server did not say.
+	RetryAfter time.Time
+	Cause      error
+}
+
+func (rateLimitError *RateLimitError) Error() string {
+	if rateLimitError.RetryAfter.IsZero() {
+		return "The ACME server rate-limited the request; try again later."
+	}
+	return "The ACME server rate-limited the request; try again after " +
+		rateLimitError.RetryAfter.Format(time.RFC3339) + "."
+}
+
+func (rateLimitError *RateLimitError) Unwrap() error {
+	return rateLimitError.Cause
+}
+
+// RateLimitDelay reports whether the error is an ACME rate-limit error, and the delay the server asked for in its
+// Retry-After header, if any.
+func RateLimitDelay(err error) (time.Duration, bool) {
+	var acmeError *acme.Error
+	if !errors.As(err, &acmeError) {
+		return 0, false
+	}
+	return acme.RateLimit(acmeError)
+}
+
+// canWait reports whether a delay can be waited out within the deadline of the context, or within MaxRateLimitWait
+// if the context has no deadline.
+func canWait(ctx context.Context, delay time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok {
+		return time.Now().Add(delay).Before(deadline)
+	}
+	return delay <= MaxRateLimitWait
+}
@@ -0,0 +1,117 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"golang.org/x/crypto/acme"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	DefaultMaxAttempts = 3
+	DefaultBaseDelay   = 1 * time.Second
+	DefaultMaxDelay    = 30 * time.Second
+	// MaxClientRetries is the number of times an ACME client using ClientBackoff retries a request itself.
+	MaxClientRetries = 3
+)
+
+// IsRetryable reports whether the error is transient: a network error or a server error (5xx) reported by the ACME
+// server. Client errors (4xx), such as an invalid contact, are permanent.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var acmeError *acme.Error
+	if errors.As(err, &acmeError) {
+		return acmeError.StatusCode >= 500
+	}
+
+	var netError net.Error
+	if errors.As(err, &netError) {
+		return true
+	}
+
+	return false
+}
+
+// Backoff returns the delay before the retry following the attempt with the (zero-based) number, using exponential
+// backoff with full jitter.
+func Backoff(attempt int) time.Duration {
+	delay := DefaultBaseDelay << min(attempt, 16)
+	if delay <= 0 || delay > DefaultMaxDelay {
+		delay = DefaultMaxDelay
+	}
+	return rand.N(delay) + 1
+}
+
+// ClientBackoff is to be set as the RetryBackoff of ACME clients. Otherwise, a client retries rate-limited (429) and
+// server error (5xx) responses itself until its context is done, so that Do never sees them. ClientBackoff leaves
+// those to Do, which bounds the attempts and honors Retry-After; the other responses that the client retries, such as
+// bad nonces, are retried up to MaxClientRetries times with Backoff.
+func ClientBackoff(n int, _ *http.Request, response *http.Response) time.Duration {
+	if response != nil &&
+		(response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= http.StatusInternalServerError) {
+		return -1
+	}
+	if n > MaxClientRetries {
+		return -1
+	}
+	return Backoff(n - 1)
+}
+
+// Do calls the function until it succeeds, returns a permanent error, or has been called the maximum number of times.
+// The delays between attempts honor the context, whose error is returned if it ends first. A rate-limit error is
+// retried after the delay requested by the server if it can be waited out; otherwise a *RateLimitError stating when
+// to try again is returned.
+func Do(ctx context.Context, maxAttempts int, function func(ctx context.Context) error) error {
+	if function == nil {
+		return &motmedelErrors.CauseError{Message: "The function is nil."}
+	}
+
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+
+	var err error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		err = function(ctx)
+		if err == nil {
+			return nil
+		}
+
+		delay := Backoff(attempt)
+		if rateLimitDelay, ok := RateLimitDelay(err); ok {
+			if rateLimitDelay > 0 {
+				delay = rateLimitDelay
+			}
+			if attempt == maxAttempts-1 || !canWait(ctx, delay) {
+				rateLimitError := &RateLimitError{Cause: err}
+				if rateLimitDelay > 0 {
+					rateLimitError.RetryAfter = time.Now().Add(rateLimitDelay)
+				}
+				return rateLimitError
+			}
+		} else if !IsRetryable(err) || attempt == maxAttempts-1 {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+
+	return err
+}
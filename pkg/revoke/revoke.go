@@ -0,0 +1,123 @@
+package revoke
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsCertificate "github.com/altshiftab/letsencrypt_utils/pkg/certificate"
+	letsencryptUtilsHttpclient "github.com/altshiftab/letsencrypt_utils/pkg/httpclient"
+	letsencryptUtilsRetry "github.com/altshiftab/letsencrypt_utils/pkg/retry"
+	"golang.org/x/crypto/acme"
+)
+
+// ProblemTypeAlreadyRevoked is the ACME problem type reported when the certificate is already revoked.
+const ProblemTypeAlreadyRevoked = "urn:ietf:params:acme:error:alreadyRevoked"
+
+// AlreadyRevokedError is returned when the CA reports that the certificate is already revoked, which callers may
+// choose to treat as success.
+type AlreadyRevokedError struct {
+	SerialNumber string
+	Cause        error
+}
+
+func (alreadyRevokedError *AlreadyRevokedError) Error() string {
+	return "The certificate is already revoked."
+}
+
+func (alreadyRevokedError *AlreadyRevokedError) Unwrap() error {
+	return alreadyRevokedError.Cause
+}
+
+var reasons = map[string]acme.CRLReasonCode{
+	"unspecified":          acme.CRLReasonUnspecified,
+	"keyCompromise":        acme.CRLReasonKeyCompromise,
+	"superseded":           acme.CRLReasonSuperseded,
+	"cessationOfOperation": acme.CRLReasonCessationOfOperation,
+}
+
+// ParseReason maps a friendly reason name, e.g. "keyCompromise", to its CRL reason code.
+func ParseReason(name string) (acme.CRLReasonCode, error) {
+	reason, ok := reasons[name]
+	if !ok {
+		return 0, &motmedelErrors.InputError{Message: "The revocation reason is unsupported.", Input: name}
+	}
+	return reason, nil
+}
+
+type publicKeyEqualer interface {
+	Equal(crypto.PublicKey) bool
+}
+
+// RevokeCertWithCertificateKey revokes the leaf certificate of the PEM data with the certificate's own private key,
+// which requires no account, e.g. when the account that obtained the certificate is lost. An error wrapping
+// ErrKeyMismatch is returned, without contacting the CA, if the key does not correspond to the certificate.
+func RevokeCertWithCertificateKey(
+	ctx context.Context,
+	certPem []byte,
+	certificateKey crypto.Signer,
+	reason acme.CRLReasonCode,
+	directoryUrl string,
+) error {
+	if certificateKey == nil {
+		return &motmedelErrors.CauseError{Message: "The certificate key is nil."}
+	}
+
+	if err := letsencryptUtilsCertificate.VerifyPublicKey(certPem, certificateKey.Public()); err != nil {
+		return &motmedelErrors.CauseError{
+			Message: "The certificate key does not correspond to the certificate.",
+			Cause:   err,
+		}
+	}
+
+	return RevokeCert(ctx, certPem, certificateKey, reason, directoryUrl)
+}
+
+// RevokeCert revokes the leaf certificate of the PEM data. The signer may be either the account key of an account
+// that is authorized for the certificate, or the certificate's own private key.
+func RevokeCert(
+	ctx context.Context,
+	certPem []byte,
+	signer crypto.Signer,
+	reason acme.CRLReasonCode,
+	directoryUrl string,
+) error {
+	if signer == nil {
+		return &motmedelErrors.CauseError{Message: "The signer is nil."}
+	}
+
+	leaf, err := letsencryptUtilsCertificate.ParsePemLeaf(certPem)
+	if err != nil {
+		return &motmedelErrors.CauseError{Message: "An error occurred when parsing the leaf certificate.", Cause: err}
+	}
+
+	client := &acme.Client{
+		Key:          signer,
+		DirectoryURL: directoryUrl,
+		HTTPClient:   letsencryptUtilsHttpclient.ClientFromContext(ctx),
+		RetryBackoff: letsencryptUtilsRetry.ClientBackoff,
+	}
+
+	// A request signed with the certificate's own key identifies the key with a JWK rather than an account URL.
+	var certificateKey crypto.Signer
+	if publicKey, ok := signer.Public().(publicKeyEqualer); ok && publicKey.Equal(leaf.PublicKey) {
+		certificateKey = signer
+	}
+
+	err = letsencryptUtilsRetry.Do(ctx, letsencryptUtilsRetry.DefaultMaxAttempts, func(ctx context.Context) error {
+		return client.RevokeCert(ctx, certificateKey, leaf.Raw, reason)
+	})
+	var acmeError *acme.Error
+	if errors.As(err, &acmeError) && acmeError.ProblemType == ProblemTypeAlreadyRevoked {
+		return &AlreadyRevokedError{SerialNumber: leaf.SerialNumber.String(), Cause: err}
+	}
+	if err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when revoking the certificate.",
+			Cause:   err,
+			Input:   []any{leaf.SerialNumber.String(), reason, directoryUrl},
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,280 @@
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsSolver "github.com/altshiftab/letsencrypt_utils/pkg/solver"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ApiTokenEnvironmentVariable = "CLOUDFLARE_API_TOKEN"
+	DefaultBaseUrl              = "https://api.cloudflare.com/client/v4"
+	DefaultTtl                  = time.Minute
+)
+
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type apiResponse struct {
+	Success bool            `json:"success"`
+	Errors  []apiError      `json:"errors"`
+	Result  json.RawMessage `json:"result"`
+}
+
+type zone struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type dnsRecord struct {
+	Id      string `json:"id,omitempty"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	Ttl     int    `json:"ttl"`
+}
+
+type createdRecord struct {
+	zoneId   string
+	recordId string
+}
+
+// Solver fulfils DNS-01 challenges by creating TXT records via the Cloudflare API.
+type Solver struct {
+	ApiToken            string
+	BaseUrl             string
+	HttpClient          *http.Client
+	PropagationTimeout  time.Duration
+	PropagationInterval time.Duration
+	Ttl                 time.Duration
+
+	mutex   sync.Mutex
+	records map[string]createdRecord
+}
+
+func New(apiToken string) *Solver {
+	return &Solver{ApiToken: apiToken}
+}
+
+// NewFromEnvironment creates a solver using the API token in the CLOUDFLARE_API_TOKEN environment variable.
+func NewFromEnvironment() (*Solver, error) {
+	apiToken := os.Getenv(ApiTokenEnvironmentVariable)
+	if apiToken == "" {
+		return nil, &motmedelErrors.InputError{
+			Message: "The Cloudflare API token environment variable is empty.",
+			Input:   ApiTokenEnvironmentVariable,
+		}
+	}
+	return New(apiToken), nil
+}
+
+func (solver *Solver) ttl() int {
+	if solver.Ttl <= 0 {
+		return int(DefaultTtl / time.Second)
+	}
+	return int(solver.Ttl / time.Second)
+}
+
+func recordKey(domain string, value string) string {
+	return domain + "\x00" + value
+}
+
+func (solver *Solver) request(ctx context.Context, method string, path string, body any, result any) error {
+	baseUrl := solver.BaseUrl
+	if baseUrl == "" {
+		baseUrl = DefaultBaseUrl
+	}
+	requestUrl := baseUrl + path
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyData, err := json.Marshal(body)
+		if err != nil {
+			return &motmedelErrors.CauseError{Message: "An error occurred when marshalling the request body.", Cause: err}
+		}
+		bodyReader = bytes.NewReader(bodyData)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, requestUrl, bodyReader)
+	if err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when creating the request.",
+			Cause:   err,
+			Input:   []any{method, requestUrl},
+		}
+	}
+	request.Header.Set("Authorization", "Bearer "+solver.ApiToken)
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := solver.HttpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when performing the request.",
+			Cause:   err,
+			Input:   []any{method, requestUrl},
+		}
+	}
+	defer response.Body.Close()
+
+	responseData, err := io.ReadAll(response.Body)
+	if err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when reading the response body.",
+			Cause:   err,
+			Input:   []any{method, requestUrl},
+		}
+	}
+
+	var envelope apiResponse
+	if err := json.Unmarshal(responseData, &envelope); err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when unmarshalling the response body.",
+			Cause:   err,
+			Input:   []any{method, requestUrl, response.StatusCode},
+		}
+	}
+
+	if !envelope.Success {
+		return &motmedelErrors.InputError{
+			Message: "The Cloudflare API reported an error.",
+			Cause:   fmt.Errorf("status %d: %+v", response.StatusCode, envelope.Errors),
+			Input:   []any{method, requestUrl},
+		}
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(envelope.Result, result); err != nil {
+			return &motmedelErrors.InputError{
+				Message: "An error occurred when unmarshalling the response result.",
+				Cause:   err,
+				Input:   []any{method, requestUrl},
+			}
+		}
+	}
+
+	return nil
+}
+
+// findZone finds the zone that the domain belongs to by trying the domain and each of its parents in turn.
+func (solver *Solver) findZone(ctx context.Context, domain string) (*zone, error) {
+	labels := strings.Split(strings.TrimSuffix(domain, "."), ".")
+	for i := 0; i < len(labels)-1; i++ {
+		candidate := strings.Join(labels[i:], ".")
+
+		var zones []zone
+		if err := solver.request(
+			ctx,
+			http.MethodGet,
+			"/zones?"+url.Values{"name": {candidate}}.Encode(),
+			nil,
+			&zones,
+		); err != nil {
+			return nil, err
+		}
+
+		if len(zones) != 0 {
+			return &zones[0], nil
+		}
+	}
+
+	return nil, &motmedelErrors.InputError{Message: "No Cloudflare zone was found for the domain.", Input: domain}
+}
+
+func (solver *Solver) Present(ctx context.Context, domain string, token string, keyAuth string) error {
+	recordName := letsencryptUtilsSolver.Dns01RecordName(domain)
+
+	recordZone, err := solver.findZone(ctx, recordName)
+	if err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when finding the zone.",
+			Cause:   err,
+			Input:   recordName,
+		}
+	}
+
+	var record dnsRecord
+	if err := solver.request(
+		ctx,
+		http.MethodPost,
+		"/zones/"+url.PathEscape(recordZone.Id)+"/dns_records",
+		&dnsRecord{Type: "TXT", Name: recordName, Content: keyAuth, Ttl: solver.ttl()},
+		&record,
+	); err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when creating the TXT record.",
+			Cause:   err,
+			Input:   []any{recordName, recordZone.Name},
+		}
+	}
+
+	solver.mutex.Lock()
+	if solver.records == nil {
+		solver.records = make(map[string]createdRecord)
+	}
+	solver.records[recordKey(domain, keyAuth)] = createdRecord{zoneId: recordZone.Id, recordId: record.Id}
+	solver.mutex.Unlock()
+
+	if err := letsencryptUtilsSolver.WaitForTxtRecord(
+		ctx,
+		recordZone.Name,
+		recordName,
+		keyAuth,
+		solver.PropagationTimeout,
+		solver.PropagationInterval,
+	); err != nil {
+		return &motmedelErrors.CauseError{
+			Message: "An error occurred when waiting for the TXT record to propagate.",
+			Cause:   err,
+		}
+	}
+
+	return nil
+}
+
+func (solver *Solver) CleanUp(ctx context.Context, domain string, token string, keyAuth string) error {
+	key := recordKey(domain, keyAuth)
+
+	solver.mutex.Lock()
+	record, ok := solver.records[key]
+	delete(solver.records, key)
+	solver.mutex.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	if err := solver.request(
+		ctx,
+		http.MethodDelete,
+		"/zones/"+url.PathEscape(record.zoneId)+"/dns_records/"+url.PathEscape(record.recordId),
+		nil,
+		nil,
+	); err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when deleting the TXT record.",
+			Cause:   err,
+			Input:   letsencryptUtilsSolver.Dns01RecordName(domain),
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package cloudflare
+
+import (
+	letsencryptUtilsSolver "github.com/altshiftab/letsencrypt_utils/pkg/solver"
+)
+
+// ProviderName is the name under which the solver is registered as a DNS-01 provider.
+const ProviderName = "cloudflare"
+
+// OptionApiToken is the provider option of the API token, which otherwise is taken from the CLOUDFLARE_API_TOKEN
+// environment variable.
+const OptionApiToken = "api-token"
+
+func init() {
+	letsencryptUtilsSolver.Register(ProviderName, newProvider)
+}
+
+func newProvider(options map[string]string) (letsencryptUtilsSolver.Solver, error) {
+	propagationTimeout, err := letsencryptUtilsSolver.DurationOption(
+		options,
+		letsencryptUtilsSolver.OptionPropagationTimeout,
+		letsencryptUtilsSolver.DefaultPropagationTimeout,
+	)
+	if err != nil {
+		return nil, err
+	}
+	propagationInterval, err := letsencryptUtilsSolver.DurationOption(
+		options,
+		letsencryptUtilsSolver.OptionPropagationInterval,
+		letsencryptUtilsSolver.DefaultPropagationInterval,
+	)
+	if err != nil {
+		return nil, err
+	}
+	ttl, err := letsencryptUtilsSolver.DurationOption(options, letsencryptUtilsSolver.OptionTtl, DefaultTtl)
+	if err != nil {
+		return nil, err
+	}
+
+	solver := New(options[OptionApiToken])
+	if solver.ApiToken == "" {
+		solver, err = NewFromEnvironment()
+		if err != nil {
+			return nil, err
+		}
+	}
+	solver.PropagationTimeout = propagationTimeout
+	solver.PropagationInterval = propagationInterval
+	solver.Ttl = ttl
+
+	return solver, nil
+}
@@ -0,0 +1,130 @@
+package solver
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	motmedelLog "github.com/Motmedel/utils_go/pkg/log"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+const Dns01RecordLabel = "_acme-challenge"
+
+// Dns01RecordName returns the name of the TXT record used to validate the domain.
+func Dns01RecordName(domain string) string {
+	return Dns01RecordLabel + "." + strings.TrimSuffix(strings.TrimPrefix(domain, "*."), ".")
+}
+
+// Dns01ManualSolver fulfils DNS-01 challenges by instructing the user to create the TXT record, and then either
+// waiting for the user to confirm or waiting a fixed duration.
+type Dns01ManualSolver struct {
+	// Wait, if non-zero, is the duration to wait after instructing the user, rather than waiting for confirmation.
+	Wait time.Duration
+	// Ttl, if non-zero, is the TTL with which the user is instructed to create the record.
+	Ttl    time.Duration
+	Reader io.Reader
+	Writer io.Writer
+}
+
+func NewDns01ManualSolver(reader io.Reader, writer io.Writer, wait time.Duration) *Dns01ManualSolver {
+	return &Dns01ManualSolver{Wait: wait, Reader: reader, Writer: writer}
+}
+
+func (solver *Dns01ManualSolver) Present(ctx context.Context, domain string, token string, keyAuth string) error {
+	if solver.Writer == nil {
+		return &motmedelErrors.CauseError{Message: "The writer is nil."}
+	}
+
+	instructions := fmt.Sprintf("Create a TXT record with the name %q and the value %q", Dns01RecordName(domain), keyAuth)
+	if solver.Ttl > 0 {
+		instructions += fmt.Sprintf(" and a TTL of %d seconds", int64(solver.Ttl/time.Second))
+	}
+	if _, err := fmt.Fprintln(solver.Writer, instructions+"."); err != nil {
+		return &motmedelErrors.CauseError{Message: "An error occurred when writing the instructions.", Cause: err}
+	}
+
+	if solver.Wait > 0 {
+		timer := time.NewTimer(solver.Wait)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return nil
+		}
+	}
+
+	if solver.Reader == nil {
+		return &motmedelErrors.CauseError{Message: "The reader is nil."}
+	}
+
+	if _, err := fmt.Fprint(solver.Writer, "Press enter once the record has been created."); err != nil {
+		return &motmedelErrors.CauseError{Message: "An error occurred when writing the prompt.", Cause: err}
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		_, err := bufio.NewReader(solver.Reader).ReadString('\n')
+		errChan <- err
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-errChan:
+		if err != nil {
+			return &motmedelErrors.CauseError{Message: "An error occurred when reading the confirmation.", Cause: err}
+		}
+		return nil
+	}
+}
+
+func (solver *Dns01ManualSolver) CleanUp(ctx context.Context, domain string, token string, keyAuth string) error {
+	if solver.Writer == nil {
+		return &motmedelErrors.CauseError{Message: "The writer is nil."}
+	}
+
+	if _, err := fmt.Fprintf(
+		solver.Writer,
+		"The TXT record with the name %q and the value %q may now be removed.\n",
+		Dns01RecordName(domain),
+		keyAuth,
+	); err != nil {
+		return &motmedelErrors.CauseError{Message: "An error occurred when writing the instructions.", Cause: err}
+	}
+
+	return nil
+}
+
+// Dns01KeepSolver wraps a DNS-01 solver, skipping the clean-up so that the records remain available for debugging a
+// failed validation.
+type Dns01KeepSolver struct {
+	Solver Solver
+}
+
+func NewDns01KeepSolver(solver Solver) *Dns01KeepSolver {
+	return &Dns01KeepSolver{Solver: solver}
+}
+
+func (solver *Dns01KeepSolver) Present(ctx context.Context, domain string, token string, keyAuth string) error {
+	if solver.Solver == nil {
+		return &motmedelErrors.CauseError{Message: "The wrapped solver is nil."}
+	}
+
+	return solver.Solver.Present(ctx, domain, token, keyAuth)
+}
+
+func (solver *Dns01KeepSolver) CleanUp(ctx context.Context, domain string, token string, keyAuth string) error {
+	motmedelLog.GetLoggerFromCtxWithDefault(ctx, nil).InfoContext(
+		ctx,
+		"Keeping the DNS-01 record.",
+		slog.String("record_name", Dns01RecordName(domain)),
+		slog.String("value", keyAuth),
+	)
+
+	return nil
+}
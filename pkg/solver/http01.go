@@ -0,0 +1,223 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	motmedelLog "github.com/Motmedel/utils_go/pkg/log"
+	letsencryptUtilsHttpclient "github.com/altshiftab/letsencrypt_utils/pkg/httpclient"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const Http01PathPrefix = "/.well-known/acme-challenge/"
+
+const DefaultHttp01Port = 80
+
+const (
+	DefaultSelfCheckTimeout  = 30 * time.Second
+	DefaultSelfCheckInterval = 2 * time.Second
+)
+
+// Http01Solver fulfils HTTP-01 challenges with a standalone HTTP server that runs only while there are challenge
+// responses to serve.
+type Http01Solver struct {
+	Port int
+	// SelfCheck indicates whether Present is to confirm that the challenge response is reachable via the domain
+	// before returning, so that an unreachable server does not consume a validation attempt.
+	SelfCheck         bool
+	SelfCheckTimeout  time.Duration
+	SelfCheckInterval time.Duration
+
+	mutex     sync.Mutex
+	responses map[string]string
+	server    *http.Server
+}
+
+func NewHttp01Solver(port int) *Http01Solver {
+	return &Http01Solver{Port: port}
+}
+
+func (solver *Http01Solver) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
+	token, ok := strings.CutPrefix(request.URL.Path, Http01PathPrefix)
+	if !ok || request.Method != http.MethodGet {
+		http.NotFound(responseWriter, request)
+		return
+	}
+
+	solver.mutex.Lock()
+	keyAuth, ok := solver.responses[token]
+	solver.mutex.Unlock()
+	if !ok {
+		http.NotFound(responseWriter, request)
+		return
+	}
+
+	responseWriter.Header().Set("Content-Type", "text/plain")
+	_, _ = responseWriter.Write([]byte(keyAuth))
+}
+
+// SelfCheckHttp01 requests the challenge response from the domain over plain HTTP, as the CA would, until the body
+// matches the key authorization or the timeout elapses.
+func SelfCheckHttp01(
+	ctx context.Context,
+	domain string,
+	token string,
+	keyAuth string,
+	timeout time.Duration,
+	interval time.Duration,
+) error {
+	if timeout == 0 {
+		timeout = DefaultSelfCheckTimeout
+	}
+	if interval == 0 {
+		interval = DefaultSelfCheckInterval
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	host := domain
+	if ipAddress := net.ParseIP(domain); ipAddress != nil && ipAddress.To4() == nil {
+		host = "[" + domain + "]"
+	}
+	challengeUrl := "http://" + host + Http01PathPrefix + token
+	// The self-check is routed through the same proxy as the requests to the ACME server.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = letsencryptUtilsHttpclient.Proxy(ctx)
+	httpClient := &http.Client{Timeout: interval + 5*time.Second, Transport: transport}
+
+	var lastErr error
+	for {
+		lastErr = func() error {
+			request, err := http.NewRequestWithContext(ctx, http.MethodGet, challengeUrl, nil)
+			if err != nil {
+				return err
+			}
+
+			response, err := httpClient.Do(request)
+			if err != nil {
+				return err
+			}
+			defer response.Body.Close()
+
+			body, err := io.ReadAll(io.LimitReader(response.Body, 4096))
+			if err != nil {
+				return err
+			}
+
+			if response.StatusCode != http.StatusOK {
+				return &motmedelErrors.InputError{
+					Message: "The challenge response request returned an unexpected status code.",
+					Input:   response.StatusCode,
+				}
+			}
+			if strings.TrimSpace(string(body)) != keyAuth {
+				return &motmedelErrors.CauseError{Message: "The challenge response does not match the key authorization."}
+			}
+			return nil
+		}()
+		if lastErr == nil {
+			return nil
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return &motmedelErrors.InputError{
+				Message: "The HTTP-01 self-check failed; the challenge response is not reachable via the domain.",
+				Cause:   errors.Join(lastErr, ctx.Err()),
+				Input:   []any{domain, challengeUrl},
+			}
+		case <-timer.C:
+		}
+	}
+}
+
+func (solver *Http01Solver) Present(ctx context.Context, domain string, token string, keyAuth string) error {
+	if err := solver.present(ctx, token, keyAuth); err != nil {
+		return err
+	}
+
+	if !solver.SelfCheck {
+		return nil
+	}
+
+	return SelfCheckHttp01(ctx, domain, token, keyAuth, solver.SelfCheckTimeout, solver.SelfCheckInterval)
+}
+
+func (solver *Http01Solver) present(ctx context.Context, token string, keyAuth string) error {
+	solver.mutex.Lock()
+	defer solver.mutex.Unlock()
+
+	if solver.responses == nil {
+		solver.responses = make(map[string]string)
+	}
+	solver.responses[token] = keyAuth
+
+	if solver.server != nil {
+		return nil
+	}
+
+	port := solver.Port
+	if port == 0 {
+		port = DefaultHttp01Port
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort("", strconv.Itoa(port)))
+	if err != nil {
+		delete(solver.responses, token)
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when listening for HTTP-01 validation requests.",
+			Cause:   err,
+			Input:   port,
+		}
+	}
+
+	server := &http.Server{Handler: solver, ReadHeaderTimeout: 10 * time.Second}
+	solver.server = server
+
+	logger := motmedelLog.GetLoggerFromCtxWithDefault(ctx, nil)
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			motmedelLog.LogError(
+				"An error occurred when serving HTTP-01 validation requests.",
+				&motmedelErrors.InputError{
+					Message: "An error occurred when serving HTTP-01 validation requests.",
+					Cause:   err,
+					Input:   port,
+				},
+				logger,
+			)
+		}
+	}()
+
+	return nil
+}
+
+func (solver *Http01Solver) CleanUp(ctx context.Context, domain string, token string, keyAuth string) error {
+	solver.mutex.Lock()
+	defer solver.mutex.Unlock()
+
+	delete(solver.responses, token)
+	if len(solver.responses) != 0 || solver.server == nil {
+		return nil
+	}
+
+	server := solver.server
+	solver.server = nil
+	if err := server.Shutdown(ctx); err != nil {
+		return &motmedelErrors.CauseError{
+			Message: "An error occurred when shutting down the HTTP-01 server.",
+			Cause:   err,
+		}
+	}
+
+	return nil
+}
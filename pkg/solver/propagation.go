@@ -0,0 +1,149 @@
+package solver
+
+import (
+	"context"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultPropagationTimeout  = 2 * time.Minute
+	DefaultPropagationInterval = 5 * time.Second
+)
+
+// nameserverResolver returns a resolver that sends all queries directly to the nameserver, bypassing any local
+// resolver and its cache.
+func nameserverResolver(nameserver string) *net.Resolver {
+	address := net.JoinHostPort(strings.TrimSuffix(nameserver, "."), "53")
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network string, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, address)
+		},
+	}
+}
+
+// FindZone returns the closest enclosing zone of the name, i.e. the name itself or its closest ancestor that has
+// nameservers.
+func FindZone(ctx context.Context, name string) (string, error) {
+	candidate := strings.TrimSuffix(name, ".")
+	for candidate != "" {
+		if nameservers, err := net.DefaultResolver.LookupNS(ctx, candidate); err == nil && len(nameservers) != 0 {
+			return candidate, nil
+		}
+
+		_, parent, found := strings.Cut(candidate, ".")
+		if !found {
+			break
+		}
+		candidate = parent
+	}
+
+	return "", &motmedelErrors.InputError{Message: "No enclosing zone with nameservers was found.", Input: name}
+}
+
+// WaitForTxtRecord polls each authoritative nameserver of the zone directly until all of them serve the TXT record
+// with the name and value, or until the timeout elapses, in which case the nameservers still lacking the record are
+// reported.
+func WaitForTxtRecord(
+	ctx context.Context,
+	zone string,
+	recordName string,
+	value string,
+	timeout time.Duration,
+	interval time.Duration,
+) error {
+	if timeout == 0 {
+		timeout = DefaultPropagationTimeout
+	}
+	if interval == 0 {
+		interval = DefaultPropagationInterval
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	nameserverRecords, err := net.DefaultResolver.LookupNS(ctx, zone)
+	if err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when looking up the nameservers of the zone.",
+			Cause:   err,
+			Input:   zone,
+		}
+	}
+
+	var pending []string
+	for _, nameserverRecord := range nameserverRecords {
+		if nameserverRecord != nil && nameserverRecord.Host != "" {
+			pending = append(pending, nameserverRecord.Host)
+		}
+	}
+	if len(pending) == 0 {
+		return &motmedelErrors.InputError{Message: "The zone has no nameservers.", Input: zone}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		pending = slices.DeleteFunc(pending, func(nameserver string) bool {
+			values, _ := nameserverResolver(nameserver).LookupTXT(ctx, recordName)
+			return slices.Contains(values, value)
+		})
+		if len(pending) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return &motmedelErrors.InputError{
+				Message: "The TXT record did not propagate to all nameservers in time.",
+				Cause:   ctx.Err(),
+				Input:   []any{recordName, pending},
+			}
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dns01PropagationSolver wraps a DNS-01 solver, waiting after each presented record until it has propagated to all
+// authoritative nameservers of its zone, so that the CA is not signalled before the record can be observed.
+type Dns01PropagationSolver struct {
+	Solver   Solver
+	Timeout  time.Duration
+	Interval time.Duration
+}
+
+func NewDns01PropagationSolver(solver Solver, timeout time.Duration, interval time.Duration) *Dns01PropagationSolver {
+	return &Dns01PropagationSolver{Solver: solver, Timeout: timeout, Interval: interval}
+}
+
+func (solver *Dns01PropagationSolver) Present(ctx context.Context, domain string, token string, keyAuth string) error {
+	if solver.Solver == nil {
+		return &motmedelErrors.CauseError{Message: "The wrapped solver is nil."}
+	}
+
+	if err := solver.Solver.Present(ctx, domain, token, keyAuth); err != nil {
+		return err
+	}
+
+	recordName := Dns01RecordName(domain)
+	zone, err := FindZone(ctx, recordName)
+	if err != nil {
+		return err
+	}
+
+	return WaitForTxtRecord(ctx, zone, recordName, keyAuth, solver.Timeout, solver.Interval)
+}
+
+func (solver *Dns01PropagationSolver) CleanUp(ctx context.Context, domain string, token string, keyAuth string) error {
+	if solver.Solver == nil {
+		return &motmedelErrors.CauseError{Message: "The wrapped solver is nil."}
+	}
+
+	return solver.Solver.CleanUp(ctx, domain, token, keyAuth)
+}
@@ -0,0 +1,132 @@
+package solver
+
+import (
+	"fmt"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// The options understood by the built-in DNS-01 providers. Providers ignore the options they do not use.
+const (
+	// OptionTtl is the TTL of the DNS-01 records, as a duration.
+	OptionTtl = "ttl"
+	// OptionPropagationTimeout is the longest a DNS-01 record is waited for to propagate, as a duration.
+	OptionPropagationTimeout = "propagation-timeout"
+	// OptionPropagationInterval is the interval at which the propagation of a DNS-01 record is checked, as a
+	// duration.
+	OptionPropagationInterval = "propagation-interval"
+	// OptionWait is the duration the manual provider waits after a record is to be created, instead of waiting for
+	// enter to be pressed.
+	OptionWait = "wait"
+)
+
+// Factory creates a DNS-01 solver from the options of a provider, e.g. API credentials or record settings.
+type Factory func(options map[string]string) (Solver, error)
+
+var (
+	factoriesMutex sync.RWMutex
+	factories      = make(map[string]Factory)
+)
+
+func init() {
+	Register("manual", newDns01ManualProvider)
+}
+
+// Register makes a DNS-01 provider available by the name, typically from the init function of the package
+// implementing it. It panics if the name is already registered or the factory is nil.
+func Register(name string, factory Factory) {
+	factoriesMutex.Lock()
+	defer factoriesMutex.Unlock()
+
+	if factory == nil {
+		panic(fmt.Sprintf("solver: the factory of the DNS-01 provider %q is nil", name))
+	}
+	if _, ok := factories[name]; ok {
+		panic(fmt.Sprintf("solver: the DNS-01 provider %q is registered twice", name))
+	}
+	factories[name] = factory
+}
+
+// Providers returns the names of the registered DNS-01 providers, sorted.
+func Providers() []string {
+	factoriesMutex.RLock()
+	defer factoriesMutex.RUnlock()
+
+	return slices.Sorted(maps.Keys(factories))
+}
+
+// NewFromProvider creates a DNS-01 solver with the factory registered by the provider name.
+func NewFromProvider(name string, options map[string]string) (Solver, error) {
+	factoriesMutex.RLock()
+	factory, ok := factories[name]
+	factoriesMutex.RUnlock()
+
+	if !ok {
+		return nil, &motmedelErrors.InputError{
+			Message: "The DNS provider is unsupported.",
+			Input:   []any{name, Providers()},
+		}
+	}
+
+	solver, err := factory(options)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when creating the solver of the DNS provider.",
+			Cause:   err,
+			Input:   name,
+		}
+	}
+	return solver, nil
+}
+
+// DurationOption returns the option parsed as a duration, or the default if the option is not set.
+func DurationOption(options map[string]string, key string, defaultValue time.Duration) (time.Duration, error) {
+	value, ok := options[key]
+	if !ok || value == "" {
+		return defaultValue, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, &motmedelErrors.InputError{
+			Message: "The option is not a duration.",
+			Cause:   err,
+			Input:   []any{key, value},
+		}
+	}
+	return duration, nil
+}
+
+// NewDns01PropagationSolverFromOptions wraps the solver so that records are waited for to propagate, with the timeout
+// and interval of the options.
+func NewDns01PropagationSolverFromOptions(solver Solver, options map[string]string) (*Dns01PropagationSolver, error) {
+	timeout, err := DurationOption(options, OptionPropagationTimeout, DefaultPropagationTimeout)
+	if err != nil {
+		return nil, err
+	}
+	interval, err := DurationOption(options, OptionPropagationInterval, DefaultPropagationInterval)
+	if err != nil {
+		return nil, err
+	}
+	return NewDns01PropagationSolver(solver, timeout, interval), nil
+}
+
+// newDns01ManualProvider creates a manual solver that prompts on standard error and waits for standard input.
+func newDns01ManualProvider(options map[string]string) (Solver, error) {
+	wait, err := DurationOption(options, OptionWait, 0)
+	if err != nil {
+		return nil, err
+	}
+	ttl, err := DurationOption(options, OptionTtl, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	manualSolver := NewDns01ManualSolver(os.Stdin, os.Stderr, wait)
+	manualSolver.Ttl = ttl
+	return NewDns01PropagationSolverFromOptions(manualSolver, options)
+}
@@ -0,0 +1,59 @@
+package rfc2136
+
+import (
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsSolver "github.com/altshiftab/letsencrypt_utils/pkg/solver"
+	"os"
+)
+
+// ProviderName is the name under which the solver is registered as a DNS-01 provider.
+const ProviderName = "rfc2136"
+
+// The provider options of the nameserver and the TSIG key. The secret is otherwise taken from the RFC2136_TSIG_SECRET
+// environment variable.
+const (
+	OptionServer        = "server"
+	OptionTsigKeyName   = "tsig-key"
+	OptionTsigAlgorithm = "tsig-algorithm"
+	OptionTsigSecret    = "tsig-secret"
+)
+
+func init() {
+	letsencryptUtilsSolver.Register(ProviderName, newProvider)
+}
+
+func newProvider(options map[string]string) (letsencryptUtilsSolver.Solver, error) {
+	server := options[OptionServer]
+	if server == "" {
+		return nil, &motmedelErrors.InputError{
+			Message: "The rfc2136 DNS provider requires a nameserver.",
+			Input:   OptionServer,
+		}
+	}
+
+	tsigKeyName := options[OptionTsigKeyName]
+	tsigAlgorithm := options[OptionTsigAlgorithm]
+	if tsigAlgorithm == "" {
+		tsigAlgorithm = DefaultTsigAlgorithm
+	}
+	tsigSecret := options[OptionTsigSecret]
+	if tsigSecret == "" {
+		tsigSecret = os.Getenv(TsigSecretEnvironmentVariable)
+	}
+	if tsigKeyName != "" && tsigSecret == "" {
+		return nil, &motmedelErrors.InputError{
+			Message: "The TSIG key has no secret.",
+			Input:   tsigKeyName,
+		}
+	}
+
+	ttl, err := letsencryptUtilsSolver.DurationOption(options, letsencryptUtilsSolver.OptionTtl, DefaultTtl)
+	if err != nil {
+		return nil, err
+	}
+
+	solver := New(server, tsigKeyName, tsigAlgorithm, tsigSecret)
+	solver.Ttl = ttl
+
+	return letsencryptUtilsSolver.NewDns01PropagationSolverFromOptions(solver, options)
+}
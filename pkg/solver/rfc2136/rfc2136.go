@@ -0,0 +1,156 @@
+package rfc2136
+
+import (
+	"context"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsSolver "github.com/altshiftab/letsencrypt_utils/pkg/solver"
+	"github.com/miekg/dns"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	TsigSecretEnvironmentVariable = "RFC2136_TSIG_SECRET"
+	DefaultTsigAlgorithm          = "hmac-sha256"
+	DefaultTtl                    = time.Minute
+	DefaultPort                   = "53"
+	tsigFudge                     = 300
+)
+
+type createdRecord struct {
+	zone   string
+	record *dns.TXT
+}
+
+// Solver fulfils DNS-01 challenges by adding and removing TXT records with TSIG-authenticated RFC 2136 dynamic
+// updates, as supported by e.g. BIND, Knot, and PowerDNS.
+type Solver struct {
+	// Server is the address of the nameserver accepting dynamic updates, with an optional port.
+	Server        string
+	TsigKeyName   string
+	TsigAlgorithm string
+	// TsigSecret is the base64-encoded TSIG secret.
+	TsigSecret string
+	Ttl        time.Duration
+	// Zone, if set, is the zone to be updated; otherwise the closest enclosing zone of the record is used.
+	Zone    string
+	Timeout time.Duration
+
+	mutex   sync.Mutex
+	records map[string]createdRecord
+}
+
+func New(server string, tsigKeyName string, tsigAlgorithm string, tsigSecret string) *Solver {
+	return &Solver{Server: server, TsigKeyName: tsigKeyName, TsigAlgorithm: tsigAlgorithm, TsigSecret: tsigSecret}
+}
+
+func recordKey(domain string, value string) string {
+	return domain + "\x00" + value
+}
+
+func (solver *Solver) address() string {
+	if _, _, err := net.SplitHostPort(solver.Server); err == nil {
+		return solver.Server
+	}
+	return net.JoinHostPort(solver.Server, DefaultPort)
+}
+
+func (solver *Solver) ttl() uint32 {
+	if solver.Ttl <= 0 {
+		return uint32(DefaultTtl / time.Second)
+	}
+	return uint32(solver.Ttl / time.Second)
+}
+
+// update sends the dynamic update message, signed with the TSIG key if one is configured.
+func (solver *Solver) update(ctx context.Context, message *dns.Msg) error {
+	if solver.Server == "" {
+		return &motmedelErrors.CauseError{Message: "The RFC 2136 server is empty."}
+	}
+
+	client := &dns.Client{Net: "tcp", Timeout: solver.Timeout}
+	if solver.TsigKeyName != "" {
+		algorithm := solver.TsigAlgorithm
+		if algorithm == "" {
+			algorithm = DefaultTsigAlgorithm
+		}
+		keyName := dns.Fqdn(strings.ToLower(solver.TsigKeyName))
+		client.TsigSecret = map[string]string{keyName: solver.TsigSecret}
+		message.SetTsig(keyName, dns.Fqdn(strings.ToLower(algorithm)), tsigFudge, time.Now().Unix())
+	}
+
+	response, _, err := client.ExchangeContext(ctx, message, solver.address())
+	if err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when sending the dynamic update.",
+			Cause:   err,
+			Input:   solver.Server,
+		}
+	}
+	if response.Rcode != dns.RcodeSuccess {
+		return &motmedelErrors.InputError{
+			Message: "The dynamic update was refused.",
+			Input:   []any{solver.Server, dns.RcodeToString[response.Rcode]},
+		}
+	}
+
+	return nil
+}
+
+func (solver *Solver) Present(ctx context.Context, domain string, token string, keyAuth string) error {
+	recordName := letsencryptUtilsSolver.Dns01RecordName(domain)
+
+	zone := solver.Zone
+	if zone == "" {
+		var err error
+		zone, err = letsencryptUtilsSolver.FindZone(ctx, recordName)
+		if err != nil {
+			return &motmedelErrors.CauseError{Message: "An error occurred when finding the zone.", Cause: err}
+		}
+	}
+
+	record := &dns.TXT{
+		Hdr: dns.RR_Header{Name: dns.Fqdn(recordName), Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: solver.ttl()},
+		Txt: []string{keyAuth},
+	}
+
+	message := new(dns.Msg)
+	message.SetUpdate(dns.Fqdn(zone))
+	message.Insert([]dns.RR{record})
+
+	if err := solver.update(ctx, message); err != nil {
+		return &motmedelErrors.CauseError{Message: "An error occurred when adding the TXT record.", Cause: err}
+	}
+
+	solver.mutex.Lock()
+	defer solver.mutex.Unlock()
+	if solver.records == nil {
+		solver.records = make(map[string]createdRecord)
+	}
+	solver.records[recordKey(domain, keyAuth)] = createdRecord{zone: zone, record: record}
+
+	return nil
+}
+
+func (solver *Solver) CleanUp(ctx context.Context, domain string, token string, keyAuth string) error {
+	solver.mutex.Lock()
+	created, ok := solver.records[recordKey(domain, keyAuth)]
+	delete(solver.records, recordKey(domain, keyAuth))
+	solver.mutex.Unlock()
+	if !ok {
+		return nil
+	}
+
+	// Only the record with the value is removed, leaving any other TXT records with the same name intact.
+	message := new(dns.Msg)
+	message.SetUpdate(dns.Fqdn(created.zone))
+	message.Remove([]dns.RR{created.record})
+
+	if err := solver.update(ctx, message); err != nil {
+		return &motmedelErrors.CauseError{Message: "An error occurred when removing the TXT record.", Cause: err}
+	}
+
+	return nil
+}
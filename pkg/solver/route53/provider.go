@@ -0,0 +1,29 @@
+package route53
+
+import (
+	"context"
+	letsencryptUtilsSolver "github.com/altshiftab/letsencrypt_utils/pkg/solver"
+)
+
+// ProviderName is the name under which the solver is registered as a DNS-01 provider. The client uses the standard
+// AWS credential chain.
+const ProviderName = "route53"
+
+func init() {
+	letsencryptUtilsSolver.Register(ProviderName, newProvider)
+}
+
+func newProvider(options map[string]string) (letsencryptUtilsSolver.Solver, error) {
+	ttl, err := letsencryptUtilsSolver.DurationOption(options, letsencryptUtilsSolver.OptionTtl, DefaultTtl)
+	if err != nil {
+		return nil, err
+	}
+
+	solver, err := NewFromEnvironment(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	solver.Ttl = ttl
+
+	return letsencryptUtilsSolver.NewDns01PropagationSolverFromOptions(solver, options)
+}
@@ -0,0 +1,300 @@
+package route53
+
+import (
+	"context"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsSolver "github.com/altshiftab/letsencrypt_utils/pkg/solver"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	route53Types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultTtl         = time.Minute
+	DefaultSyncTimeout = 2 * time.Minute
+)
+
+type recordSet struct {
+	hostedZoneId string
+	// existingValues are the quoted values of the record set as it was before the solver changed it.
+	existingValues []string
+	values         []string
+}
+
+// resourceRecordValues returns the quoted values to be written to the record set.
+func (set *recordSet) resourceRecordValues(values []string) []string {
+	resourceRecordValues := slices.Clone(set.existingValues)
+	for _, value := range values {
+		resourceRecordValues = append(resourceRecordValues, strconv.Quote(value))
+	}
+	return resourceRecordValues
+}
+
+// Solver fulfils DNS-01 challenges by upserting TXT records in Route53 hosted zones.
+//
+// As a record set holds all TXT values of a name, e.g. those of a wildcard and its base domain or ones unrelated to
+// the solver, the values present before the first change are retained, and the values presented by the solver are
+// tracked so that only those are removed.
+type Solver struct {
+	Client      *route53.Client
+	SyncTimeout time.Duration
+	Ttl         time.Duration
+
+	mutex      sync.Mutex
+	recordSets map[string]*recordSet
+}
+
+func New(client *route53.Client) *Solver {
+	return &Solver{Client: client}
+}
+
+// NewFromEnvironment creates a solver whose client uses the standard AWS credential chain.
+func NewFromEnvironment(ctx context.Context) (*Solver, error) {
+	awsConfig, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{
+			Message: "An error occurred when loading the AWS configuration.",
+			Cause:   err,
+		}
+	}
+	return New(route53.NewFromConfig(awsConfig)), nil
+}
+
+// findHostedZoneId finds the public hosted zone that the record name belongs to by trying the name and each of its
+// parents in turn.
+func (solver *Solver) findHostedZoneId(ctx context.Context, recordName string) (string, error) {
+	labels := strings.Split(strings.TrimSuffix(recordName, "."), ".")
+	for i := 0; i < len(labels)-1; i++ {
+		candidate := strings.Join(labels[i:], ".") + "."
+
+		output, err := solver.Client.ListHostedZonesByName(
+			ctx,
+			&route53.ListHostedZonesByNameInput{DNSName: aws.String(candidate)},
+		)
+		if err != nil {
+			return "", &motmedelErrors.InputError{
+				Message: "An error occurred when listing hosted zones.",
+				Cause:   err,
+				Input:   candidate,
+			}
+		}
+
+		for _, hostedZone := range output.HostedZones {
+			if aws.ToString(hostedZone.Name) != candidate {
+				continue
+			}
+			if hostedZone.Config != nil && hostedZone.Config.PrivateZone {
+				continue
+			}
+			return strings.TrimPrefix(aws.ToString(hostedZone.Id), "/hostedzone/"), nil
+		}
+	}
+
+	return "", &motmedelErrors.InputError{Message: "No hosted zone was found for the record.", Input: recordName}
+}
+
+func (solver *Solver) ttl() int64 {
+	if solver.Ttl <= 0 {
+		return int64(DefaultTtl / time.Second)
+	}
+	return int64(solver.Ttl / time.Second)
+}
+
+// existingValues returns the quoted values of the TXT record set with the name, if it exists.
+func (solver *Solver) existingValues(ctx context.Context, hostedZoneId string, recordName string) ([]string, error) {
+	output, err := solver.Client.ListResourceRecordSets(
+		ctx,
+		&route53.ListResourceRecordSetsInput{
+			HostedZoneId:    aws.String(hostedZoneId),
+			StartRecordName: aws.String(recordName),
+			StartRecordType: route53Types.RRTypeTxt,
+			MaxItems:        aws.Int32(1),
+		},
+	)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when listing the resource record sets.",
+			Cause:   err,
+			Input:   []any{hostedZoneId, recordName},
+		}
+	}
+
+	for _, resourceRecordSet := range output.ResourceRecordSets {
+		if !strings.EqualFold(strings.TrimSuffix(aws.ToString(resourceRecordSet.Name), "."), recordName) {
+			continue
+		}
+		if resourceRecordSet.Type != route53Types.RRTypeTxt {
+			continue
+		}
+
+		var values []string
+		for _, resourceRecord := range resourceRecordSet.ResourceRecords {
+			values = append(values, aws.ToString(resourceRecord.Value))
+		}
+		return values, nil
+	}
+
+	return nil, nil
+}
+
+func (solver *Solver) change(
+	ctx context.Context,
+	hostedZoneId string,
+	action route53Types.ChangeAction,
+	recordName string,
+	resourceRecordValues []string,
+) error {
+	var resourceRecords []route53Types.ResourceRecord
+	for _, value := range resourceRecordValues {
+		resourceRecords = append(resourceRecords, route53Types.ResourceRecord{Value: aws.String(value)})
+	}
+
+	output, err := solver.Client.ChangeResourceRecordSets(
+		ctx,
+		&route53.ChangeResourceRecordSetsInput{
+			HostedZoneId: aws.String(hostedZoneId),
+			ChangeBatch: &route53Types.ChangeBatch{
+				Changes: []route53Types.Change{
+					{
+						Action: action,
+						ResourceRecordSet: &route53Types.ResourceRecordSet{
+							Name:            aws.String(recordName),
+							Type:            route53Types.RRTypeTxt,
+							TTL:             aws.Int64(solver.ttl()),
+							ResourceRecords: resourceRecords,
+						},
+					},
+				},
+			},
+		},
+	)
+	if err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when changing the resource record set.",
+			Cause:   err,
+			Input:   []any{hostedZoneId, action, recordName},
+		}
+	}
+	if output == nil || output.ChangeInfo == nil {
+		return &motmedelErrors.InputError{Message: "The change info is nil.", Input: recordName}
+	}
+
+	syncTimeout := solver.SyncTimeout
+	if syncTimeout == 0 {
+		syncTimeout = DefaultSyncTimeout
+	}
+
+	waiter := route53.NewResourceRecordSetsChangedWaiter(solver.Client)
+	if err := waiter.Wait(ctx, &route53.GetChangeInput{Id: output.ChangeInfo.Id}, syncTimeout); err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when waiting for the change to be in sync.",
+			Cause:   err,
+			Input:   aws.ToString(output.ChangeInfo.Id),
+		}
+	}
+
+	return nil
+}
+
+func (solver *Solver) Present(ctx context.Context, domain string, token string, keyAuth string) error {
+	if solver.Client == nil {
+		return &motmedelErrors.CauseError{Message: "The Route53 client is nil."}
+	}
+
+	recordName := letsencryptUtilsSolver.Dns01RecordName(domain)
+
+	solver.mutex.Lock()
+	defer solver.mutex.Unlock()
+
+	set, ok := solver.recordSets[recordName]
+	if !ok {
+		hostedZoneId, err := solver.findHostedZoneId(ctx, recordName)
+		if err != nil {
+			return &motmedelErrors.CauseError{Message: "An error occurred when finding the hosted zone.", Cause: err}
+		}
+		existingValues, err := solver.existingValues(ctx, hostedZoneId, recordName)
+		if err != nil {
+			return &motmedelErrors.CauseError{
+				Message: "An error occurred when obtaining the existing TXT record values.",
+				Cause:   err,
+			}
+		}
+		set = &recordSet{hostedZoneId: hostedZoneId, existingValues: existingValues}
+	}
+
+	values := append(slices.Clone(set.values), keyAuth)
+	if err := solver.change(
+		ctx,
+		set.hostedZoneId,
+		route53Types.ChangeActionUpsert,
+		recordName,
+		set.resourceRecordValues(values),
+	); err != nil {
+		return &motmedelErrors.CauseError{Message: "An error occurred when upserting the TXT record.", Cause: err}
+	}
+
+	set.values = values
+	if solver.recordSets == nil {
+		solver.recordSets = make(map[string]*recordSet)
+	}
+	solver.recordSets[recordName] = set
+
+	return nil
+}
+
+func (solver *Solver) CleanUp(ctx context.Context, domain string, token string, keyAuth string) error {
+	if solver.Client == nil {
+		return &motmedelErrors.CauseError{Message: "The Route53 client is nil."}
+	}
+
+	recordName := letsencryptUtilsSolver.Dns01RecordName(domain)
+
+	solver.mutex.Lock()
+	defer solver.mutex.Unlock()
+
+	set, ok := solver.recordSets[recordName]
+	if !ok || !slices.Contains(set.values, keyAuth) {
+		return nil
+	}
+
+	remainingValues := slices.DeleteFunc(slices.Clone(set.values), func(value string) bool {
+		return value == keyAuth
+	})
+
+	if len(remainingValues) == 0 && len(set.existingValues) == 0 {
+		// A deletion must match the current record set exactly.
+		if err := solver.change(
+			ctx,
+			set.hostedZoneId,
+			route53Types.ChangeActionDelete,
+			recordName,
+			set.resourceRecordValues(set.values),
+		); err != nil {
+			return &motmedelErrors.CauseError{Message: "An error occurred when deleting the TXT record.", Cause: err}
+		}
+		delete(solver.recordSets, recordName)
+		return nil
+	}
+
+	if err := solver.change(
+		ctx,
+		set.hostedZoneId,
+		route53Types.ChangeActionUpsert,
+		recordName,
+		set.resourceRecordValues(remainingValues),
+	); err != nil {
+		return &motmedelErrors.CauseError{Message: "An error occurred when updating the TXT record.", Cause: err}
+	}
+	set.values = remainingValues
+	if len(remainingValues) == 0 {
+		delete(solver.recordSets, recordName)
+	}
+
+	return nil
+}
@@ -0,0 +1,20 @@
+package solver
+
+import "context"
+
+const (
+	ChallengeTypeHttp01    = "http-01"
+	ChallengeTypeDns01     = "dns-01"
+	ChallengeTypeTlsAlpn01 = "tls-alpn-01"
+)
+
+// Solver fulfils ACME challenges of a certain type.
+//
+// The key authorization passed to the methods is in the form expected by the challenge type, e.g. the HTTP response
+// body for HTTP-01 and the TXT record value for DNS-01.
+type Solver interface {
+	// Present makes the challenge response available for validation.
+	Present(ctx context.Context, domain string, token string, keyAuth string) error
+	// CleanUp removes what was put in place by Present. It is called regardless of the validation outcome.
+	CleanUp(ctx context.Context, domain string, token string, keyAuth string) error
+}
@@ -0,0 +1,154 @@
+package solver
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"golang.org/x/crypto/acme"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultTlsAlpn01Port = 443
+
+// TlsAlpn01Solver fulfils TLS-ALPN-01 challenges with a standalone TLS listener that runs only while there are
+// validation certificates to present.
+type TlsAlpn01Solver struct {
+	Client *acme.Client
+	Port   int
+
+	mutex        sync.Mutex
+	certificates map[string]*tls.Certificate
+	listener     net.Listener
+	done         chan struct{}
+}
+
+func NewTlsAlpn01Solver(client *acme.Client, port int) *TlsAlpn01Solver {
+	return &TlsAlpn01Solver{Client: client, Port: port}
+}
+
+func (solver *TlsAlpn01Solver) getCertificate(clientHello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	serverName := strings.ToLower(strings.TrimSuffix(clientHello.ServerName, "."))
+
+	solver.mutex.Lock()
+	certificate, ok := solver.certificates[serverName]
+	solver.mutex.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("no validation certificate for %q", serverName)
+	}
+
+	return certificate, nil
+}
+
+func (solver *TlsAlpn01Solver) serve(listener net.Listener, done chan struct{}) {
+	defer close(done)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+
+		go func() {
+			defer conn.Close()
+			_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+			if tlsConn, ok := conn.(*tls.Conn); ok {
+				_ = tlsConn.Handshake()
+			}
+		}()
+	}
+}
+
+func (solver *TlsAlpn01Solver) Present(ctx context.Context, domain string, token string, keyAuth string) error {
+	if solver.Client == nil {
+		return &motmedelErrors.CauseError{Message: "The ACME client is nil."}
+	}
+
+	certificate, err := solver.Client.TLSALPN01ChallengeCert(token, domain)
+	if err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when creating the TLS-ALPN-01 validation certificate.",
+			Cause:   err,
+			Input:   domain,
+		}
+	}
+
+	solver.mutex.Lock()
+	defer solver.mutex.Unlock()
+
+	if solver.certificates == nil {
+		solver.certificates = make(map[string]*tls.Certificate)
+	}
+	solver.certificates[strings.ToLower(domain)] = &certificate
+
+	if solver.listener != nil {
+		return nil
+	}
+
+	port := solver.Port
+	if port == 0 {
+		port = DefaultTlsAlpn01Port
+	}
+
+	listener, err := tls.Listen(
+		"tcp",
+		net.JoinHostPort("", strconv.Itoa(port)),
+		&tls.Config{
+			NextProtos:     []string{acme.ALPNProto},
+			GetCertificate: solver.getCertificate,
+			MinVersion:     tls.VersionTLS12,
+		},
+	)
+	if err != nil {
+		delete(solver.certificates, strings.ToLower(domain))
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when listening for TLS-ALPN-01 validation requests.",
+			Cause:   err,
+			Input:   port,
+		}
+	}
+
+	solver.listener = listener
+	solver.done = make(chan struct{})
+	go solver.serve(listener, solver.done)
+
+	return nil
+}
+
+func (solver *TlsAlpn01Solver) CleanUp(ctx context.Context, domain string, token string, keyAuth string) error {
+	solver.mutex.Lock()
+	defer solver.mutex.Unlock()
+
+	delete(solver.certificates, strings.ToLower(domain))
+	if len(solver.certificates) != 0 || solver.listener == nil {
+		return nil
+	}
+
+	listener := solver.listener
+	done := solver.done
+	solver.listener = nil
+	solver.done = nil
+
+	if err := listener.Close(); err != nil {
+		return &motmedelErrors.CauseError{
+			Message: "An error occurred when closing the TLS-ALPN-01 listener.",
+			Cause:   err,
+		}
+	}
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Http01WebrootSolver fulfils HTTP-01 challenges by writing the challenge responses into the document root of an
+// existing web server, which is relied upon to serve them.
+type Http01WebrootSolver struct {
+	Webroot string
+	// SelfCheck indicates whether Present is to confirm that the challenge response is reachable via the domain
+	// before returning.
+	SelfCheck         bool
+	SelfCheckTimeout  time.Duration
+	SelfCheckInterval time.Duration
+
+	mutex sync.Mutex
+	// files are the challenge response files written by the solver, by token.
+	files map[string]string
+	// directories are the directories created by the solver, outermost first.
+	directories []string
+}
+
+func NewHttp01WebrootSolver(webroot string) *Http01WebrootSolver {
+	return &Http01WebrootSolver{Webroot: webroot}
+}
+
+// challengeDirectories returns the directories of the challenge responses below the webroot, outermost first:
+// .well-known and .well-known/acme-challenge.
+func (solver *Http01WebrootSolver) challengeDirectories() []string {
+	var directories []string
+	directory := solver.Webroot
+	for _, name := range strings.Split(strings.Trim(Http01PathPrefix, "/"), "/") {
+		directory = filepath.Join(directory, name)
+		directories = append(directories, directory)
+	}
+	return directories
+}
+
+// createDirectories creates the missing challenge directories below the webroot, recording those that were created.
+// The webroot itself is never created, so that a mistyped webroot is reported rather than populated.
+func (solver *Http01WebrootSolver) createDirectories() error {
+	fileInfo, err := os.Stat(solver.Webroot)
+	if err != nil {
+		return &motmedelErrors.InputError{
+			Message: "The webroot does not exist or is not accessible.",
+			Cause:   err,
+			Input:   solver.Webroot,
+		}
+	}
+	if !fileInfo.IsDir() {
+		return &motmedelErrors.InputError{Message: "The webroot is not a directory.", Input: solver.Webroot}
+	}
+
+	for _, directory := range solver.challengeDirectories() {
+		if err := os.Mkdir(directory, 0755); err != nil {
+			if errors.Is(err, fs.ErrExist) {
+				continue
+			}
+			return &motmedelErrors.InputError{
+				Message: "An error occurred when creating the challenge directory.",
+				Cause:   err,
+				Input:   directory,
+			}
+		}
+		solver.directories = append(solver.directories, directory)
+	}
+
+	return nil
+}
+
+func (solver *Http01WebrootSolver) Present(ctx context.Context, domain string, token string, keyAuth string) error {
+	if solver.Webroot == "" {
+		return &motmedelErrors.CauseError{Message: "The webroot is empty."}
+	}
+
+	if token == "" || strings.ContainsAny(token, `/\.`) {
+		return &motmedelErrors.InputError{Message: "The challenge token is malformed.", Input: token}
+	}
+
+	if err := solver.present(token, keyAuth); err != nil {
+		return err
+	}
+
+	if !solver.SelfCheck {
+		return nil
+	}
+
+	return SelfCheckHttp01(ctx, domain, token, keyAuth, solver.SelfCheckTimeout, solver.SelfCheckInterval)
+}
+
+func (solver *Http01WebrootSolver) present(token string, keyAuth string) error {
+	solver.mutex.Lock()
+	defer solver.mutex.Unlock()
+
+	if err := solver.createDirectories(); err != nil {
+		return err
+	}
+
+	challengeDirectories := solver.challengeDirectories()
+	path := filepath.Join(challengeDirectories[len(challengeDirectories)-1], token)
+	if err := os.WriteFile(path, []byte(keyAuth), 0644); err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when writing the challenge response file.",
+			Cause:   err,
+			Input:   path,
+		}
+	}
+
+	if solver.files == nil {
+		solver.files = make(map[string]string)
+	}
+	solver.files[token] = path
+
+	return nil
+}
+
+func (solver *Http01WebrootSolver) CleanUp(ctx context.Context, domain string, token string, keyAuth string) error {
+	solver.mutex.Lock()
+	defer solver.mutex.Unlock()
+
+	path, ok := solver.files[token]
+	if !ok {
+		return nil
+	}
+	delete(solver.files, token)
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when removing the challenge response file.",
+			Cause:   err,
+			Input:   path,
+		}
+	}
+
+	if len(solver.files) != 0 {
+		return nil
+	}
+
+	// Only the directories created by the solver are removed, innermost first, and only if they are empty.
+	for _, directory := range slices.Backward(solver.directories) {
+		_ = os.Remove(directory)
+	}
+	solver.directories = nil
+
+	return nil
+}
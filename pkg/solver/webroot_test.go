@@ -0,0 +1,50 @@
+package solver_test
+
+import (
+	"context"
+	"errors"
+	letsencryptUtilsSolver "github.com/altshiftab/letsencrypt_utils/pkg/solver"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHttp01WebrootSolver(t *testing.T) {
+	webroot := t.TempDir()
+	solver := letsencryptUtilsSolver.NewHttp01WebrootSolver(webroot)
+
+	if err := solver.Present(context.Background(), "example.org", "token", "token.thumbprint"); err != nil {
+		t.Fatalf("Present: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(webroot, ".well-known", "acme-challenge", "token"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(data) != "token.thumbprint" {
+		t.Errorf("the challenge response = %q, want %q", data, "token.thumbprint")
+	}
+
+	if err := solver.CleanUp(context.Background(), "example.org", "token", "token.thumbprint"); err != nil {
+		t.Fatalf("CleanUp: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(webroot, ".well-known")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("the created directories were not removed: %v", err)
+	}
+	if _, err := os.Stat(webroot); err != nil {
+		t.Errorf("the webroot was removed: %v", err)
+	}
+}
+
+func TestHttp01WebrootSolverMissingWebroot(t *testing.T) {
+	webroot := filepath.Join(t.TempDir(), "missing", "www")
+	solver := letsencryptUtilsSolver.NewHttp01WebrootSolver(webroot)
+
+	if err := solver.Present(context.Background(), "example.org", "token", "token.thumbprint"); err == nil {
+		t.Fatal("Present succeeded")
+	}
+	if _, err := os.Stat(filepath.Dir(webroot)); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("a directory was created above the webroot: %v", err)
+	}
+}
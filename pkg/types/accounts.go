@@ -0,0 +1,224 @@
+package types
+
+import (
+	"encoding/json"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"slices"
+	"strings"
+)
+
+// AccountCredentialsFile is the content of an account credentials file, which holds either a single account as a bare
+// object, the legacy form, or several named accounts in the form {"accounts": [...]}.
+type AccountCredentialsFile struct {
+	Accounts []*AccountCredentials `json:"accounts"`
+
+	// legacy records whether the file holds a bare object, so that it is written back in the same form.
+	legacy bool
+}
+
+// ParseAccountCredentialsFile parses account credentials data in either form, decrypting it with the passphrase if it
+// is an encrypted envelope. The plaintext is validated against AccountCredentialsSchema, so that a malformed field is
+// reported with its location.
+func ParseAccountCredentialsFile(data []byte, passphrase []byte) (*AccountCredentialsFile, error) {
+	data, err := decryptIfEncrypted(data, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateAccountCredentialsData(data); err != nil {
+		return nil, err
+	}
+
+	var accountCredentialsFile AccountCredentialsFile
+	if err := json.Unmarshal(data, &accountCredentialsFile); err != nil {
+		return nil, &motmedelErrors.CauseError{
+			Message: "An error occurred when unmarshalling the account credentials.",
+			Cause:   err,
+		}
+	}
+	if accountCredentialsFile.Accounts != nil {
+		return &accountCredentialsFile, nil
+	}
+
+	var accountCredentials AccountCredentials
+	if err := json.Unmarshal(data, &accountCredentials); err != nil {
+		return nil, &motmedelErrors.CauseError{
+			Message: "An error occurred when unmarshalling the account credentials.",
+			Cause:   err,
+		}
+	}
+
+	return &AccountCredentialsFile{Accounts: []*AccountCredentials{&accountCredentials}, legacy: true}, nil
+}
+
+// Names returns the names of the accounts.
+func (accountCredentialsFile *AccountCredentialsFile) Names() []string {
+	var names []string
+	for _, accountCredentials := range accountCredentialsFile.Accounts {
+		if accountCredentials != nil {
+			names = append(names, accountCredentials.Name)
+		}
+	}
+	return names
+}
+
+// AccountSelector selects an account of an account credentials file holding several accounts, by its name, its email
+// address, or both. The single account of a file in the legacy form is selected whatever the selector.
+type AccountSelector struct {
+	Name string
+	// Email is matched case-insensitively against the email addresses of the accounts. Should several accounts share
+	// the email address, the name must also be provided.
+	Email string
+}
+
+// Select returns a copy of the credentials of the account with the name. An empty name selects the only account, and
+// is rejected if the file holds several accounts.
+func (accountCredentialsFile *AccountCredentialsFile) Select(name string) (*AccountCredentials, error) {
+	return accountCredentialsFile.SelectAccount(AccountSelector{Name: name})
+}
+
+// SelectAccount returns a copy of the credentials of the account matched by the selector. An empty selector selects
+// the only account, and is rejected if the file holds several accounts.
+func (accountCredentialsFile *AccountCredentialsFile) SelectAccount(
+	selector AccountSelector,
+) (*AccountCredentials, error) {
+	index, err := accountCredentialsFile.index(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	accountCredentials := *accountCredentialsFile.Accounts[index]
+	return &accountCredentials, nil
+}
+
+// Replace replaces the credentials of the account with the same name as the provided credentials.
+func (accountCredentialsFile *AccountCredentialsFile) Replace(accountCredentials *AccountCredentials) error {
+	if accountCredentials == nil {
+		return &motmedelErrors.CauseError{Message: "The account credentials are nil."}
+	}
+
+	index, err := accountCredentialsFile.index(AccountSelector{Name: accountCredentials.Name})
+	if err != nil {
+		return err
+	}
+
+	accountCredentialsFile.Accounts[index] = accountCredentials
+	return nil
+}
+
+// Add adds the credentials of an account to the file, which then holds several accounts. ErrCredentialsExist is
+// returned if an account with the same name is already in the file, as only one of them could be selected by name.
+func (accountCredentialsFile *AccountCredentialsFile) Add(accountCredentials *AccountCredentials) error {
+	if accountCredentials == nil {
+		return &motmedelErrors.CauseError{Message: "The account credentials are nil."}
+	}
+
+	if slices.Contains(accountCredentialsFile.Names(), accountCredentials.Name) {
+		return &motmedelErrors.InputError{
+			Message: "An account with the name already exists in the account credentials file.",
+			Cause:   ErrCredentialsExist,
+			Input:   accountCredentials.Name,
+		}
+	}
+
+	accountCredentialsFile.Accounts = append(accountCredentialsFile.Accounts, accountCredentials)
+	accountCredentialsFile.legacy = false
+	return nil
+}
+
+// checkNames checks that no two accounts of the file have the same name, so that each of them can be selected.
+func (accountCredentialsFile *AccountCredentialsFile) checkNames() error {
+	names := accountCredentialsFile.Names()
+	for index, name := range names {
+		if slices.Contains(names[:index], name) {
+			return &motmedelErrors.InputError{
+				Message: "Several accounts have the same name in the account credentials file.",
+				Cause:   ErrCredentialsExist,
+				Input:   name,
+			}
+		}
+	}
+	return nil
+}
+
+func (accountCredentialsFile *AccountCredentialsFile) index(selector AccountSelector) (int, error) {
+	if accountCredentialsFile.legacy || (selector.Name == "" && selector.Email == "") {
+		switch len(accountCredentialsFile.Accounts) {
+		case 0:
+			return 0, &motmedelErrors.CauseError{Message: "The account credentials file holds no accounts."}
+		case 1:
+			if accountCredentialsFile.Accounts[0] == nil {
+				return 0, &motmedelErrors.CauseError{Message: "The account credentials are nil."}
+			}
+			return 0, nil
+		default:
+			return 0, &motmedelErrors.InputError{
+				Message: "The account credentials file holds several accounts, so an account name must be provided.",
+				Input:   accountCredentialsFile.Names(),
+			}
+		}
+	}
+
+	var indices []int
+	var names []string
+	for index, accountCredentials := range accountCredentialsFile.Accounts {
+		if accountCredentials == nil {
+			continue
+		}
+		if selector.Name != "" && accountCredentials.Name != selector.Name {
+			continue
+		}
+		if selector.Email != "" && !strings.EqualFold(accountCredentials.Email, selector.Email) {
+			continue
+		}
+		indices = append(indices, index)
+		names = append(names, accountCredentials.Name)
+	}
+
+	switch {
+	case len(indices) == 0 && selector.Email == "":
+		return 0, &motmedelErrors.InputError{
+			Message: "No account with the name exists in the account credentials file.",
+			Input:   []any{selector.Name, accountCredentialsFile.Names()},
+		}
+	case len(indices) == 0:
+		return 0, &motmedelErrors.InputError{
+			Message: "No account with the name and email address exists in the account credentials file.",
+			Input:   []any{selector.Name, selector.Email, accountCredentialsFile.Names()},
+		}
+	case len(indices) > 1 && selector.Name == "":
+		return 0, &motmedelErrors.InputError{
+			Message: "Several accounts have the email address, so an account name must be provided.",
+			Input:   []any{selector.Email, names},
+		}
+	}
+
+	return indices[0], nil
+}
+
+// Encode serializes the file in the form it was parsed from, encrypting it with the passphrase if one is provided.
+// ErrCredentialsExist is returned if several accounts have the same name.
+func (accountCredentialsFile *AccountCredentialsFile) Encode(passphrase []byte) ([]byte, error) {
+	if err := accountCredentialsFile.checkNames(); err != nil {
+		return nil, err
+	}
+
+	var value any = accountCredentialsFile
+	if accountCredentialsFile.legacy && len(accountCredentialsFile.Accounts) == 1 {
+		value = accountCredentialsFile.Accounts[0]
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{
+			Message: "An error occurred when marshalling the account credentials.",
+			Cause:   err,
+		}
+	}
+
+	if len(passphrase) == 0 {
+		return data, nil
+	}
+
+	return encrypt(data, passphrase)
+}
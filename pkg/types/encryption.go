@@ -0,0 +1,201 @@
+package types
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	KdfScrypt       = "scrypt"
+	CipherAes256Gcm = "aes-256-gcm"
+)
+
+const (
+	scryptN       = 1 << 15
+	scryptR       = 8
+	scryptP       = 1
+	scryptKeySize = 32
+	saltSize      = 16
+)
+
+// The largest scrypt parameters accepted from an envelope, which bound the memory and time spent deriving the key of
+// a file that is not trusted: 128 * N * r bytes, 2 GiB at the limits. The parameters used when encrypting are well
+// within them.
+const (
+	maxScryptN = 1 << 20
+	maxScryptR = 16
+	maxScryptP = 4
+)
+
+// EncryptedEnvelope holds serialized account credentials encrypted with a passphrase-derived key.
+type EncryptedEnvelope struct {
+	Kdf        string `json:"kdf"`
+	Salt       []byte `json:"salt"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Cipher     string `json:"cipher"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func deriveAead(passphrase []byte, salt []byte, n int, r int, p int) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, n, r, p, scryptKeySize)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when deriving the encryption key.", Cause: err}
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when creating the block cipher.", Cause: err}
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when creating the AEAD.", Cause: err}
+	}
+
+	return aead, nil
+}
+
+// EncryptAccountCredentials serializes the account credentials and encrypts them with AES-256-GCM, using a key
+// derived from the passphrase with scrypt. The returned data is the JSON-encoded envelope.
+func EncryptAccountCredentials(accountCredentials *AccountCredentials, passphrase []byte) ([]byte, error) {
+	if accountCredentials == nil {
+		return nil, &motmedelErrors.CauseError{Message: "The account credentials are nil."}
+	}
+	if len(passphrase) == 0 {
+		return nil, &motmedelErrors.CauseError{Message: "The passphrase is empty."}
+	}
+
+	plaintext, err := json.Marshal(accountCredentials)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{
+			Message: "An error occurred when marshalling the account credentials.",
+			Cause:   err,
+		}
+	}
+
+	return encrypt(plaintext, passphrase)
+}
+
+// encrypt encrypts the plaintext with AES-256-GCM, using a key derived from the passphrase with scrypt, and returns
+// the JSON-encoded envelope.
+func encrypt(plaintext []byte, passphrase []byte) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when generating a salt.", Cause: err}
+	}
+
+	aead, err := deriveAead(passphrase, salt, scryptN, scryptR, scryptP)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when generating a nonce.", Cause: err}
+	}
+
+	envelope := EncryptedEnvelope{
+		Kdf:        KdfScrypt,
+		Salt:       salt,
+		N:          scryptN,
+		R:          scryptR,
+		P:          scryptP,
+		Cipher:     CipherAes256Gcm,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, nil),
+	}
+
+	envelopeData, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when marshalling the envelope.", Cause: err}
+	}
+
+	return envelopeData, nil
+}
+
+// IsEncrypted reports whether the data is an encrypted envelope rather than plaintext account credentials.
+func IsEncrypted(data []byte) bool {
+	var probe struct {
+		Ciphertext []byte `json:"ciphertext"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return false
+	}
+	return len(probe.Ciphertext) != 0
+}
+
+func decryptEnvelope(data []byte, passphrase []byte) ([]byte, error) {
+	var envelope EncryptedEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when unmarshalling the envelope.", Cause: err}
+	}
+
+	if envelope.Kdf != KdfScrypt {
+		return nil, &motmedelErrors.InputError{Message: "The key derivation function is unsupported.", Input: envelope.Kdf}
+	}
+	if envelope.Cipher != CipherAes256Gcm {
+		return nil, &motmedelErrors.InputError{Message: "The cipher is unsupported.", Input: envelope.Cipher}
+	}
+
+	if envelope.N < 2 || envelope.N > maxScryptN || envelope.R < 1 || envelope.R > maxScryptR ||
+		envelope.P < 1 || envelope.P > maxScryptP {
+		return nil, &motmedelErrors.InputError{
+			Message: "The scrypt parameters are out of bounds.",
+			Input:   []any{envelope.N, envelope.R, envelope.P},
+		}
+	}
+
+	aead, err := deriveAead(passphrase, envelope.Salt, envelope.N, envelope.R, envelope.P)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(envelope.Nonce) != aead.NonceSize() {
+		return nil, &motmedelErrors.InputError{Message: "The nonce size is invalid.", Input: len(envelope.Nonce)}
+	}
+
+	plaintext, err := aead.Open(nil, envelope.Nonce, envelope.Ciphertext, nil)
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{
+			Message: "An error occurred when decrypting the account credentials; the passphrase may be wrong.",
+			Cause:   err,
+		}
+	}
+
+	return plaintext, nil
+}
+
+// decryptIfEncrypted returns the data decrypted with the passphrase if it is an encrypted envelope, and otherwise the
+// data as is.
+func decryptIfEncrypted(data []byte, passphrase []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+
+	if len(passphrase) == 0 {
+		return nil, &motmedelErrors.CauseError{
+			Message: "The account credentials are encrypted, but no passphrase was provided.",
+		}
+	}
+
+	return decryptEnvelope(data, passphrase)
+}
+
+// ParseAccountCredentials parses account credentials data, decrypting it with the passphrase if it is an encrypted
+// envelope. Plaintext data is parsed as is, and the passphrase is then not needed. The data must hold a single
+// account.
+func ParseAccountCredentials(data []byte, passphrase []byte) (*AccountCredentials, error) {
+	accountCredentialsFile, err := ParseAccountCredentialsFile(data, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return accountCredentialsFile.Select("")
+}
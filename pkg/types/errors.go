@@ -0,0 +1,9 @@
+package types
+
+import (
+	"errors"
+)
+
+// ErrCredentialsExist is returned when account credentials would overwrite existing ones, or an account would be
+// added under the name of another in the same file.
+var ErrCredentialsExist = errors.New("the account credentials already exist")
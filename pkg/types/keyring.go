@@ -0,0 +1,68 @@
+package types
+
+import (
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"github.com/zalando/go-keyring"
+)
+
+// KeyringService is the OS keyring service under which account keys are stored.
+const KeyringService = "letsencrypt_utils"
+
+// KeyringReference identifies an account key stored in the OS keyring.
+type KeyringReference struct {
+	Service string `json:"service"`
+	User    string `json:"user"`
+}
+
+// StoreKeyInKeyring writes the account key into the OS keyring (macOS Keychain, Windows Credential Manager, or the
+// Secret Service on Linux), and returns a copy of the credentials that references the keyring entry instead of
+// embedding the key.
+func StoreKeyInKeyring(accountCredentials *AccountCredentials) (*AccountCredentials, error) {
+	if accountCredentials == nil {
+		return nil, &motmedelErrors.CauseError{Message: "The account credentials are nil."}
+	}
+
+	if accountCredentials.Key == "" {
+		return nil, &motmedelErrors.CauseError{Message: "The account key is empty."}
+	}
+
+	reference := accountCredentials.Keyring
+	if reference == nil {
+		reference = &KeyringReference{Service: KeyringService, User: accountCredentials.Uri}
+	}
+
+	if err := keyring.Set(reference.Service, reference.User, accountCredentials.Key); err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when storing the account key in the keyring.",
+			Cause:   err,
+			Input:   []any{reference.Service, reference.User},
+		}
+	}
+
+	storedAccountCredentials := *accountCredentials
+	storedAccountCredentials.Key = ""
+	storedAccountCredentials.Keyring = reference
+
+	return &storedAccountCredentials, nil
+}
+
+// resolveKeyring reads the account key from the OS keyring if the credentials reference a keyring entry and do not
+// embed the key.
+func (accountCredentials *AccountCredentials) resolveKeyring() error {
+	reference := accountCredentials.Keyring
+	if reference == nil || accountCredentials.Key != "" {
+		return nil
+	}
+
+	key, err := keyring.Get(reference.Service, reference.User)
+	if err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when reading the account key from the keyring.",
+			Cause:   err,
+			Input:   []any{reference.Service, reference.User},
+		}
+	}
+	accountCredentials.Key = key
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package types
+
+import (
+	"errors"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsFileutil "github.com/altshiftab/letsencrypt_utils/internal/fileutil"
+	"io/fs"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLockTimeout is the default maximum duration to wait for the lock of an account credentials file.
+const DefaultLockTimeout = 10 * time.Second
+
+// DefaultBackupCount is the default number of backups kept of an account credentials file that is rewritten.
+const DefaultBackupCount = 3
+
+var lockTimeout atomic.Int64
+
+func init() {
+	lockTimeout.Store(int64(DefaultLockTimeout))
+}
+
+// SetLockTimeout sets the maximum duration to wait for the lock of an account credentials file, which is held by
+// another process while it reads or writes the file.
+func SetLockTimeout(timeout time.Duration) {
+	lockTimeout.Store(int64(timeout))
+}
+
+// LockTimeout returns the maximum duration to wait for the lock of an account credentials file.
+func LockTimeout() time.Duration {
+	return time.Duration(lockTimeout.Load())
+}
+
+// AccountCredentialsLock is an exclusive advisory lock on an account credentials file, under which the file can be
+// read, modified, and written without racing other processes.
+type AccountCredentialsLock struct {
+	path string
+	lock *letsencryptUtilsFileutil.Lock
+}
+
+// LockAccountCredentialsFile acquires an exclusive lock on the account credentials file at the path. The lock must be
+// released with Release, and the file must not be loaded with the other helpers while the lock is held.
+func LockAccountCredentialsFile(path string) (*AccountCredentialsLock, error) {
+	lock, err := letsencryptUtilsFileutil.AcquireLock(path, true, LockTimeout())
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when locking the account credentials file.",
+			Cause:   err,
+			Input:   path,
+		}
+	}
+	return &AccountCredentialsLock{path: path, lock: lock}, nil
+}
+
+// Read reads the account credentials file.
+func (accountCredentialsLock *AccountCredentialsLock) Read() ([]byte, error) {
+	data, err := os.ReadFile(accountCredentialsLock.path)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when reading the account credentials file.",
+			Cause:   err,
+			Input:   accountCredentialsLock.path,
+		}
+	}
+	return data, nil
+}
+
+// Write atomically replaces the account credentials file with the data.
+func (accountCredentialsLock *AccountCredentialsLock) Write(data []byte) error {
+	if err := letsencryptUtilsFileutil.WriteFileAtomic(accountCredentialsLock.path, data, 0600); err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when writing the account credentials file.",
+			Cause:   err,
+			Input:   accountCredentialsLock.path,
+		}
+	}
+	return nil
+}
+
+// Backup keeps the data, the current contents of the account credentials file, as the most recent of up to count
+// backups of the file, <path>.1 through <path>.<count>, before the file is rewritten.
+func (accountCredentialsLock *AccountCredentialsLock) Backup(data []byte, count int) error {
+	if err := letsencryptUtilsFileutil.RotateBackups(accountCredentialsLock.path, data, 0600, count); err != nil {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when backing up the account credentials file.",
+			Cause:   err,
+			Input:   accountCredentialsLock.path,
+		}
+	}
+	return nil
+}
+
+// Release releases the lock.
+func (accountCredentialsLock *AccountCredentialsLock) Release() error {
+	return accountCredentialsLock.lock.Release()
+}
+
+// WriteAccountCredentialsFile atomically replaces the account credentials file at the path with the data, holding
+// the lock of the file while doing so. An existing file is first kept as the most recent of up to backupCount backups.
+func WriteAccountCredentialsFile(path string, data []byte, backupCount int) error {
+	accountCredentialsLock, err := LockAccountCredentialsFile(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = accountCredentialsLock.Release() }()
+
+	existingData, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return &motmedelErrors.InputError{
+			Message: "An error occurred when reading the account credentials file.",
+			Cause:   err,
+			Input:   path,
+		}
+	}
+	if err == nil {
+		if err := accountCredentialsLock.Backup(existingData, backupCount); err != nil {
+			return err
+		}
+	}
+
+	return accountCredentialsLock.Write(data)
+}
+
+// readAccountCredentialsFile reads the account credentials file at the path, holding a shared lock of the file while
+// doing so, so that a concurrent write is not observed midway.
+func readAccountCredentialsFile(path string) ([]byte, error) {
+	lock, err := letsencryptUtilsFileutil.AcquireLock(path, false, LockTimeout())
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when locking the account credentials file.",
+			Cause:   err,
+			Input:   path,
+		}
+	}
+	defer func() { _ = lock.Release() }()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when reading the account credentials file.",
+			Cause:   err,
+			Input:   path,
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,222 @@
+package types
+
+import (
+	_ "embed"
+	"encoding/json"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// AccountCredentialsSchema is the JSON Schema of the plaintext of an account credentials file, in either form. The
+// data of a file is validated against it when parsed.
+//
+//go:embed account_credentials.schema.json
+var AccountCredentialsSchema []byte
+
+var accountCredentialsSchema = func() map[string]any {
+	var schema map[string]any
+	if err := json.Unmarshal(AccountCredentialsSchema, &schema); err != nil {
+		panic(err)
+	}
+	return schema
+}()
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// schemaValidator validates JSON values against the subset of JSON Schema used by AccountCredentialsSchema: local
+// references, type, enum, minLength, the date-time format, properties, required, additionalProperties as a boolean,
+// items, and if/then/else. The errors hold the JSON pointer of the offending value.
+type schemaValidator struct {
+	root map[string]any
+}
+
+// validateAccountCredentialsData validates the plaintext of an account credentials file against the schema.
+func validateAccountCredentialsData(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return &motmedelErrors.CauseError{
+			Message: "An error occurred when unmarshalling the account credentials.",
+			Cause:   err,
+		}
+	}
+
+	validator := &schemaValidator{root: accountCredentialsSchema}
+	if err := validator.validate(accountCredentialsSchema, value, ""); err != nil {
+		return &motmedelErrors.CauseError{
+			Message: "The account credentials do not conform to the schema.",
+			Cause:   err,
+		}
+	}
+
+	return nil
+}
+
+func (validator *schemaValidator) validate(schema map[string]any, value any, pointer string) error {
+	if reference, ok := schema["$ref"].(string); ok {
+		referencedSchema, err := validator.resolve(reference)
+		if err != nil {
+			return err
+		}
+		if err := validator.validate(referencedSchema, value, pointer); err != nil {
+			return err
+		}
+	}
+
+	if schemaType, ok := schema["type"].(string); ok && !hasSchemaType(value, schemaType) {
+		return &motmedelErrors.InputError{
+			Message: "The value is not of the type required by the schema.",
+			Input:   []any{location(pointer), schemaType},
+		}
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		if !slices.ContainsFunc(enum, func(allowed any) bool { return reflect.DeepEqual(allowed, value) }) {
+			return &motmedelErrors.InputError{
+				Message: "The value is not one of those allowed by the schema.",
+				Input:   []any{location(pointer), enum},
+			}
+		}
+	}
+
+	if text, ok := value.(string); ok {
+		if minLength, ok := schema["minLength"].(float64); ok && float64(utf8.RuneCountInString(text)) < minLength {
+			return &motmedelErrors.InputError{
+				Message: "The value is shorter than allowed by the schema.",
+				Input:   []any{location(pointer), minLength},
+			}
+		}
+		if format, _ := schema["format"].(string); format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, text); err != nil {
+				return &motmedelErrors.InputError{
+					Message: "The value is not an RFC 3339 date-time.",
+					Cause:   err,
+					Input:   location(pointer),
+				}
+			}
+		}
+	}
+
+	if object, ok := value.(map[string]any); ok {
+		if err := validator.validateObject(schema, object, pointer); err != nil {
+			return err
+		}
+	}
+
+	if elements, ok := value.([]any); ok {
+		if itemsSchema, ok := schema["items"].(map[string]any); ok {
+			for index, element := range elements {
+				if err := validator.validate(itemsSchema, element, pointer+"/"+strconv.Itoa(index)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if ifSchema, ok := schema["if"].(map[string]any); ok {
+		branch := "else"
+		if validator.validate(ifSchema, value, pointer) == nil {
+			branch = "then"
+		}
+		if branchSchema, ok := schema[branch].(map[string]any); ok {
+			if err := validator.validate(branchSchema, value, pointer); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (validator *schemaValidator) validateObject(schema map[string]any, object map[string]any, pointer string) error {
+	if required, ok := schema["required"].([]any); ok {
+		for _, name := range required {
+			name, _ := name.(string)
+			if _, ok := object[name]; !ok {
+				return &motmedelErrors.InputError{
+					Message: "A field required by the schema is missing.",
+					Input:   location(pointer + "/" + jsonPointerEscaper.Replace(name)),
+				}
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	additionalProperties, restricted := schema["additionalProperties"].(bool)
+
+	for _, name := range slices.Sorted(maps.Keys(object)) {
+		propertyPointer := pointer + "/" + jsonPointerEscaper.Replace(name)
+
+		propertySchema, ok := properties[name].(map[string]any)
+		if !ok {
+			if restricted && !additionalProperties {
+				return &motmedelErrors.InputError{
+					Message: "The field is not allowed by the schema.",
+					Input:   propertyPointer,
+				}
+			}
+			continue
+		}
+
+		if err := validator.validate(propertySchema, object[name], propertyPointer); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolve resolves a reference to a schema within the root schema, of the form "#/$defs/name".
+func (validator *schemaValidator) resolve(reference string) (map[string]any, error) {
+	var schema any = validator.root
+	for _, segment := range strings.Split(strings.TrimPrefix(reference, "#"), "/")[1:] {
+		object, ok := schema.(map[string]any)
+		if !ok {
+			schema = nil
+			break
+		}
+		schema = object[strings.NewReplacer("~1", "/", "~0", "~").Replace(segment)]
+	}
+
+	resolvedSchema, ok := schema.(map[string]any)
+	if !ok || !strings.HasPrefix(reference, "#/") {
+		return nil, &motmedelErrors.InputError{
+			Message: "The schema reference cannot be resolved.",
+			Input:   reference,
+		}
+	}
+	return resolvedSchema, nil
+}
+
+func hasSchemaType(value any, schemaType string) bool {
+	switch typedValue := value.(type) {
+	case map[string]any:
+		return schemaType == "object"
+	case []any:
+		return schemaType == "array"
+	case string:
+		return schemaType == "string"
+	case bool:
+		return schemaType == "boolean"
+	case float64:
+		return schemaType == "number" || (schemaType == "integer" && typedValue == math.Trunc(typedValue))
+	case nil:
+		return schemaType == "null"
+	default:
+		return false
+	}
+}
+
+// location returns the JSON pointer of a value, denoting the root with a slash for legibility.
+func location(pointer string) string {
+	if pointer == "" {
+		return "/"
+	}
+	return pointer
+}
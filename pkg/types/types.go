@@ -1,6 +1,147 @@
 package types
 
+import (
+	"crypto"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsKey "github.com/altshiftab/letsencrypt_utils/pkg/key"
+	letsencryptUtilsRetry "github.com/altshiftab/letsencrypt_utils/pkg/retry"
+	"golang.org/x/crypto/acme"
+	"time"
+)
+
 type AccountCredentials struct {
-	Uri string `json:"uri"`
+	// Name identifies the account in an account credentials file holding several accounts.
+	Name string `json:"name,omitempty"`
+	Uri  string `json:"uri"`
+	// Key is the PEM-encoded account key. It is empty in the file when the key is stored in the OS keyring.
 	Key string `json:"key"`
+	// Keyring, if set, references the OS keyring entry in which the account key is stored.
+	Keyring *KeyringReference `json:"keyring,omitempty"`
+	// Contacts are the contact URIs of the account, e.g. "mailto:" URIs.
+	Contacts []string `json:"contacts,omitempty"`
+	// Email is the primary contact email address the account was registered with.
+	Email string `json:"email,omitempty"`
+	// CreatedAt is when the account was registered.
+	CreatedAt time.Time `json:"created_at,omitzero"`
+	// DirectoryUrl is the URL of the ACME directory the account was registered with.
+	DirectoryUrl string `json:"directory_url,omitempty"`
+	// Environment is the Let's Encrypt environment of the directory, "production" or "staging", and empty for other
+	// directories.
+	Environment string `json:"environment,omitempty"`
+}
+
+// Signer parses the PEM-encoded account key.
+func (accountCredentials *AccountCredentials) Signer() (crypto.Signer, error) {
+	return letsencryptUtilsKey.ParsePem([]byte(accountCredentials.Key))
+}
+
+// Validate checks that the account URI is set and that the key parses into a usable signer.
+func (accountCredentials *AccountCredentials) Validate() error {
+	if accountCredentials.Uri == "" {
+		return &motmedelErrors.CauseError{Message: "The account URI is empty."}
+	}
+
+	if accountCredentials.Key == "" {
+		return &motmedelErrors.CauseError{Message: "The account key is empty."}
+	}
+
+	if _, err := accountCredentials.Signer(); err != nil {
+		return &motmedelErrors.CauseError{Message: "An error occurred when parsing the account key.", Cause: err}
+	}
+
+	return nil
+}
+
+// LoadAccountCredentials reads, parses, and validates the account credentials file at the path. A key stored in the
+// OS keyring is retrieved transparently.
+func LoadAccountCredentials(path string) (*AccountCredentials, error) {
+	return LoadAccountCredentialsWithPassphrase(path, nil)
+}
+
+// LoadAccountCredentialsWithPassphrase is like LoadAccountCredentials, but decrypts the file with the passphrase if it
+// is encrypted.
+func LoadAccountCredentialsWithPassphrase(path string, passphrase []byte) (*AccountCredentials, error) {
+	return LoadNamedAccountCredentials(path, passphrase, "")
+}
+
+// LoadAccountCredentialsFile reads and parses the account credentials file at the path, decrypting it with the
+// passphrase if it is encrypted. The file is read under a shared lock, so that a concurrent write is waited for. The
+// accounts are neither validated nor resolved from the keyring.
+func LoadAccountCredentialsFile(path string, passphrase []byte) (*AccountCredentialsFile, error) {
+	data, err := readAccountCredentialsFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	accountCredentialsFile, err := ParseAccountCredentialsFile(data, passphrase)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when parsing the account credentials file.",
+			Cause:   err,
+			Input:   path,
+		}
+	}
+
+	return accountCredentialsFile, nil
+}
+
+// LoadNamedAccountCredentials is like LoadAccountCredentialsWithPassphrase, but selects the account with the name from
+// a file holding several accounts. An empty name selects the only account of the file.
+func LoadNamedAccountCredentials(path string, passphrase []byte, name string) (*AccountCredentials, error) {
+	return LoadSelectedAccountCredentials(path, passphrase, AccountSelector{Name: name})
+}
+
+// LoadSelectedAccountCredentials is like LoadNamedAccountCredentials, but selects the account with the selector, by
+// its name, its email address, or both.
+func LoadSelectedAccountCredentials(
+	path string,
+	passphrase []byte,
+	selector AccountSelector,
+) (*AccountCredentials, error) {
+	accountCredentialsFile, err := LoadAccountCredentialsFile(path, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	accountCredentials, err := accountCredentialsFile.SelectAccount(selector)
+	if err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when selecting the account.",
+			Cause:   err,
+			Input:   path,
+		}
+	}
+
+	if err := accountCredentials.resolveKeyring(); err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "An error occurred when resolving the account key.",
+			Cause:   err,
+			Input:   path,
+		}
+	}
+
+	if err := accountCredentials.Validate(); err != nil {
+		return nil, &motmedelErrors.InputError{
+			Message: "The account credentials are invalid.",
+			Cause:   err,
+			Input:   path,
+		}
+	}
+
+	return accountCredentials, nil
+}
+
+// Client returns an ACME client for the account, using the stored key and account URI, and the directory URL.
+func (accountCredentials *AccountCredentials) Client(directoryUrl string) (*acme.Client, error) {
+	key, err := accountCredentials.Signer()
+	if err != nil {
+		return nil, &motmedelErrors.CauseError{Message: "An error occurred when parsing the account key.", Cause: err}
+	}
+
+	return &acme.Client{
+		Key:          key,
+		KID:          acme.KeyID(accountCredentials.Uri),
+		DirectoryURL: directoryUrl,
+		RetryBackoff: letsencryptUtilsRetry.ClientBackoff,
+	}, nil
 }
@@ -0,0 +1,577 @@
+package types_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	motmedelErrors "github.com/Motmedel/utils_go/pkg/errors"
+	letsencryptUtilsKey "github.com/altshiftab/letsencrypt_utils/pkg/key"
+	letsencryptUtilsTypes "github.com/altshiftab/letsencrypt_utils/pkg/types"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var keySpecs = []struct {
+	name string
+	spec letsencryptUtilsKey.Spec
+}{
+	{
+		name: "ecdsa p256",
+		spec: letsencryptUtilsKey.Spec{Type: letsencryptUtilsKey.TypeEcdsa, Curve: letsencryptUtilsKey.CurveP256},
+	},
+	{
+		name: "ecdsa p384",
+		spec: letsencryptUtilsKey.Spec{Type: letsencryptUtilsKey.TypeEcdsa, Curve: letsencryptUtilsKey.CurveP384},
+	},
+	{
+		name: "ecdsa p521",
+		spec: letsencryptUtilsKey.Spec{Type: letsencryptUtilsKey.TypeEcdsa, Curve: letsencryptUtilsKey.CurveP521},
+	},
+	{name: "rsa", spec: letsencryptUtilsKey.Spec{Type: letsencryptUtilsKey.TypeRsa}},
+	{name: "ed25519", spec: letsencryptUtilsKey.Spec{Type: letsencryptUtilsKey.TypeEd25519}},
+}
+
+// checkSigner checks that the signer produces signatures that verify with the public key.
+func checkSigner(t *testing.T, signer crypto.Signer, publicKey crypto.PublicKey) {
+	t.Helper()
+
+	message := []byte("round trip")
+	digest := sha256.Sum256(message)
+
+	switch typedKey := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		signature, err := signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+		if err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+		if !ecdsa.VerifyASN1(typedKey, digest[:], signature) {
+			t.Error("the ECDSA signature does not verify")
+		}
+	case *rsa.PublicKey:
+		signature, err := signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+		if err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+		if err := rsa.VerifyPKCS1v15(typedKey, crypto.SHA256, digest[:], signature); err != nil {
+			t.Errorf("the RSA signature does not verify: %v", err)
+		}
+	case ed25519.PublicKey:
+		signature, err := signer.Sign(rand.Reader, message, crypto.Hash(0))
+		if err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+		if !ed25519.Verify(typedKey, message, signature) {
+			t.Error("the Ed25519 signature does not verify")
+		}
+	default:
+		t.Fatalf("unexpected public key type %T", publicKey)
+	}
+}
+
+func TestAccountCredentialsRoundTrip(t *testing.T) {
+	passphrase := []byte("correct horse battery staple")
+
+	forms := []struct {
+		name       string
+		encode     func(*letsencryptUtilsTypes.AccountCredentials) ([]byte, error)
+		passphrase []byte
+	}{
+		{
+			name: "plaintext",
+			encode: func(accountCredentials *letsencryptUtilsTypes.AccountCredentials) ([]byte, error) {
+				return json.Marshal(accountCredentials)
+			},
+		},
+		{
+			name: "encrypted",
+			encode: func(accountCredentials *letsencryptUtilsTypes.AccountCredentials) ([]byte, error) {
+				return letsencryptUtilsTypes.EncryptAccountCredentials(accountCredentials, passphrase)
+			},
+			passphrase: passphrase,
+		},
+	}
+
+	for _, keySpec := range keySpecs {
+		for _, form := range forms {
+			t.Run(keySpec.name+" "+form.name, func(t *testing.T) {
+				key, err := letsencryptUtilsKey.Generate(&keySpec.spec)
+				if err != nil {
+					t.Fatalf("Generate: %v", err)
+				}
+				keyPemData, err := letsencryptUtilsKey.MarshalPem(key)
+				if err != nil {
+					t.Fatalf("MarshalPem: %v", err)
+				}
+
+				accountCredentials := &letsencryptUtilsTypes.AccountCredentials{
+					Name:         "primary",
+					Uri:          "https://acme.example/acct/1",
+					Key:          string(keyPemData),
+					Contacts:     []string{"mailto:user@example.org"},
+					Email:        "user@example.org",
+					CreatedAt:    time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+					DirectoryUrl: "https://acme.example/directory",
+					Environment:  "staging",
+				}
+
+				data, err := form.encode(accountCredentials)
+				if err != nil {
+					t.Fatalf("encode: %v", err)
+				}
+				if encrypted := letsencryptUtilsTypes.IsEncrypted(data); encrypted != (form.passphrase != nil) {
+					t.Errorf("IsEncrypted = %t", encrypted)
+				}
+
+				parsed, err := letsencryptUtilsTypes.ParseAccountCredentials(data, form.passphrase)
+				if err != nil {
+					t.Fatalf("ParseAccountCredentials: %v", err)
+				}
+				if !reflect.DeepEqual(parsed, accountCredentials) {
+					t.Errorf("ParseAccountCredentials = %+v, want %+v", parsed, accountCredentials)
+				}
+
+				if err := parsed.Validate(); err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				signer, err := parsed.Signer()
+				if err != nil {
+					t.Fatalf("Signer: %v", err)
+				}
+				spec, err := letsencryptUtilsKey.SpecOf(signer)
+				if err != nil {
+					t.Fatalf("SpecOf: %v", err)
+				}
+				if wantSpec, _ := letsencryptUtilsKey.SpecOf(key); *spec != *wantSpec {
+					t.Errorf("SpecOf = %+v, want %+v", spec, wantSpec)
+				}
+				checkSigner(t, signer, key.Public())
+			})
+		}
+	}
+}
+
+func TestAccountCredentialsLegacyForm(t *testing.T) {
+	key, err := letsencryptUtilsKey.Generate(nil)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	keyPemData, err := letsencryptUtilsKey.MarshalPem(key)
+	if err != nil {
+		t.Fatalf("MarshalPem: %v", err)
+	}
+
+	data, err := json.Marshal(map[string]string{"uri": "https://acme.example/acct/1", "key": string(keyPemData)})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	accountCredentialsFile, err := letsencryptUtilsTypes.ParseAccountCredentialsFile(data, nil)
+	if err != nil {
+		t.Fatalf("ParseAccountCredentialsFile: %v", err)
+	}
+
+	accountCredentials, err := accountCredentialsFile.Select("")
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	wantAccountCredentials := &letsencryptUtilsTypes.AccountCredentials{
+		Uri: "https://acme.example/acct/1",
+		Key: string(keyPemData),
+	}
+	if !reflect.DeepEqual(accountCredentials, wantAccountCredentials) {
+		t.Errorf("Select = %+v, want %+v", accountCredentials, wantAccountCredentials)
+	}
+
+	// The selectors are ignored for the single account of the legacy form.
+	selectedAccountCredentials, err := accountCredentialsFile.SelectAccount(
+		letsencryptUtilsTypes.AccountSelector{Name: "other", Email: "other@example.org"},
+	)
+	if err != nil {
+		t.Fatalf("SelectAccount: %v", err)
+	}
+	if !reflect.DeepEqual(selectedAccountCredentials, wantAccountCredentials) {
+		t.Errorf("SelectAccount = %+v, want %+v", selectedAccountCredentials, wantAccountCredentials)
+	}
+
+	signer, err := accountCredentials.Signer()
+	if err != nil {
+		t.Fatalf("Signer: %v", err)
+	}
+	checkSigner(t, signer, key.Public())
+
+	// The legacy form is written back as is, without the optional fields.
+	encodedData, err := accountCredentialsFile.Encode(nil)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(encodedData, &fields); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if names := slices.Sorted(maps.Keys(fields)); !slices.Equal(names, []string{"key", "uri"}) {
+		t.Errorf("the encoded fields are %q, want key and uri", names)
+	}
+}
+
+func TestAccountCredentialsFileRoundTrip(t *testing.T) {
+	passphrase := []byte("correct horse battery staple")
+
+	var accounts []*letsencryptUtilsTypes.AccountCredentials
+	for index, keySpec := range keySpecs {
+		key, err := letsencryptUtilsKey.Generate(&keySpec.spec)
+		if err != nil {
+			t.Fatalf("Generate: %v", err)
+		}
+		keyPemData, err := letsencryptUtilsKey.MarshalPem(key)
+		if err != nil {
+			t.Fatalf("MarshalPem: %v", err)
+		}
+		accounts = append(
+			accounts,
+			&letsencryptUtilsTypes.AccountCredentials{
+				Name: keySpec.name,
+				Uri:  "https://acme.example/acct/" + strconv.Itoa(index+1),
+				Key:  string(keyPemData),
+			},
+		)
+	}
+
+	for _, filePassphrase := range [][]byte{nil, passphrase} {
+		data, err := (&letsencryptUtilsTypes.AccountCredentialsFile{Accounts: accounts}).Encode(filePassphrase)
+		if err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+
+		accountCredentialsFile, err := letsencryptUtilsTypes.ParseAccountCredentialsFile(data, filePassphrase)
+		if err != nil {
+			t.Fatalf("ParseAccountCredentialsFile: %v", err)
+		}
+		if !reflect.DeepEqual(accountCredentialsFile.Accounts, accounts) {
+			t.Errorf("Accounts = %+v, want %+v", accountCredentialsFile.Accounts, accounts)
+		}
+
+		if _, err := accountCredentialsFile.Select(""); err == nil {
+			t.Error("Select with no name succeeded for a file holding several accounts")
+		}
+		for _, keySpec := range keySpecs {
+			accountCredentials, err := accountCredentialsFile.Select(keySpec.name)
+			if err != nil {
+				t.Fatalf("Select %q: %v", keySpec.name, err)
+			}
+			if err := accountCredentials.Validate(); err != nil {
+				t.Errorf("Validate %q: %v", keySpec.name, err)
+			}
+		}
+	}
+}
+
+func TestAccountCredentialsFileDuplicateName(t *testing.T) {
+	accountCredentialsFile := &letsencryptUtilsTypes.AccountCredentialsFile{
+		Accounts: []*letsencryptUtilsTypes.AccountCredentials{{Name: "production", Uri: "https://acme.example/acct/1"}},
+	}
+
+	err := accountCredentialsFile.Add(
+		&letsencryptUtilsTypes.AccountCredentials{Name: "production", Uri: "https://acme.example/acct/2"},
+	)
+	if !errors.Is(err, letsencryptUtilsTypes.ErrCredentialsExist) {
+		t.Errorf("Add error = %v, want ErrCredentialsExist", err)
+	}
+
+	err = accountCredentialsFile.Add(
+		&letsencryptUtilsTypes.AccountCredentials{Name: "staging", Uri: "https://acme.example/acct/3"},
+	)
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if names := accountCredentialsFile.Names(); !slices.Equal(names, []string{"production", "staging"}) {
+		t.Errorf("Names = %q", names)
+	}
+
+	// A file in which an account cannot be selected by its name is not saved.
+	accountCredentialsFile.Accounts = append(
+		accountCredentialsFile.Accounts,
+		&letsencryptUtilsTypes.AccountCredentials{Name: "staging", Uri: "https://acme.example/acct/4"},
+	)
+	if _, err := accountCredentialsFile.Encode(nil); !errors.Is(err, letsencryptUtilsTypes.ErrCredentialsExist) {
+		t.Errorf("Encode error = %v, want ErrCredentialsExist", err)
+	}
+}
+
+func TestAccountCredentialsSelectByEmail(t *testing.T) {
+	data, err := json.Marshal(
+		map[string]any{
+			"accounts": []map[string]string{
+				{"name": "production", "uri": "https://acme.example/acct/1", "email": "admin@example.org"},
+				{"name": "staging", "uri": "https://acme.example/acct/2", "email": "Admin@Example.org"},
+				{"name": "other", "uri": "https://acme.example/acct/3", "email": "other@example.org"},
+			},
+		},
+	)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	accountCredentialsFile, err := letsencryptUtilsTypes.ParseAccountCredentialsFile(data, nil)
+	if err != nil {
+		t.Fatalf("ParseAccountCredentialsFile: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		selector letsencryptUtilsTypes.AccountSelector
+		wantUri  string
+	}{
+		{
+			name:     "unique email",
+			selector: letsencryptUtilsTypes.AccountSelector{Email: "OTHER@example.org"},
+			wantUri:  "https://acme.example/acct/3",
+		},
+		{
+			name:     "shared email with name",
+			selector: letsencryptUtilsTypes.AccountSelector{Name: "staging", Email: "admin@example.org"},
+			wantUri:  "https://acme.example/acct/2",
+		},
+		{
+			name:     "shared email",
+			selector: letsencryptUtilsTypes.AccountSelector{Email: "admin@example.org"},
+		},
+		{
+			name:     "unknown email",
+			selector: letsencryptUtilsTypes.AccountSelector{Email: "unknown@example.org"},
+		},
+		{
+			name:     "mismatched name",
+			selector: letsencryptUtilsTypes.AccountSelector{Name: "other", Email: "admin@example.org"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			accountCredentials, err := accountCredentialsFile.SelectAccount(testCase.selector)
+			if testCase.wantUri == "" {
+				if err == nil {
+					t.Fatalf("SelectAccount selected %q, want an error", accountCredentials.Uri)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SelectAccount: %v", err)
+			}
+			if accountCredentials.Uri != testCase.wantUri {
+				t.Errorf("SelectAccount selected %q, want %q", accountCredentials.Uri, testCase.wantUri)
+			}
+		})
+	}
+}
+
+func TestAccountCredentialsSchema(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+		// wantPointer is the JSON pointer of the offending value, or empty if the data is valid.
+		wantPointer string
+	}{
+		{
+			name: "valid legacy form",
+			data: `{"uri": "https://acme.example/acct/1", "key": "", "created_at": "2025-01-02T03:04:05.6Z"}`,
+		},
+		{
+			name: "valid accounts",
+			data: `{"accounts": [{"name": "a", "uri": "https://acme.example/acct/1", "environment": "staging"}]}`,
+		},
+		{
+			name:        "missing uri",
+			data:        `{"key": ""}`,
+			wantPointer: "/uri",
+		},
+		{
+			name:        "unknown field",
+			data:        `{"uri": "https://acme.example/acct/1", "emial": "user@example.org"}`,
+			wantPointer: "/emial",
+		},
+		{
+			name:        "mistyped contacts",
+			data:        `{"accounts": [{"uri": "a"}, {"uri": "b", "contacts": "mailto:user@example.org"}]}`,
+			wantPointer: "/accounts/1/contacts",
+		},
+		{
+			name:        "invalid created_at",
+			data:        `{"uri": "https://acme.example/acct/1", "created_at": "yesterday"}`,
+			wantPointer: "/created_at",
+		},
+		{
+			name:        "invalid environment",
+			data:        `{"uri": "https://acme.example/acct/1", "environment": "testing"}`,
+			wantPointer: "/environment",
+		},
+		{
+			name:        "incomplete keyring",
+			data:        `{"uri": "https://acme.example/acct/1", "keyring": {"service": "letsencrypt_utils"}}`,
+			wantPointer: "/keyring/user",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := letsencryptUtilsTypes.ParseAccountCredentialsFile([]byte(testCase.data), nil)
+			if testCase.wantPointer == "" {
+				if err != nil {
+					t.Fatalf("ParseAccountCredentialsFile: %v", err)
+				}
+				return
+			}
+
+			var inputError *motmedelErrors.InputError
+			if !errors.As(err, &inputError) {
+				t.Fatalf("ParseAccountCredentialsFile = %v, want an input error", err)
+			}
+			pointer := inputError.Input
+			if inputs, ok := pointer.([]any); ok {
+				pointer = inputs[0]
+			}
+			if pointer != testCase.wantPointer {
+				t.Errorf("the offending value is at %v, want %s", pointer, testCase.wantPointer)
+			}
+		})
+	}
+}
+
+func TestEncryptedAccountCredentialsWrongPassphrase(t *testing.T) {
+	key, err := letsencryptUtilsKey.Generate(nil)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	keyPemData, err := letsencryptUtilsKey.MarshalPem(key)
+	if err != nil {
+		t.Fatalf("MarshalPem: %v", err)
+	}
+
+	data, err := letsencryptUtilsTypes.EncryptAccountCredentials(
+		&letsencryptUtilsTypes.AccountCredentials{Uri: "https://acme.example/acct/1", Key: string(keyPemData)},
+		[]byte("right"),
+	)
+	if err != nil {
+		t.Fatalf("EncryptAccountCredentials: %v", err)
+	}
+
+	if _, err := letsencryptUtilsTypes.ParseAccountCredentials(data, []byte("wrong")); err == nil {
+		t.Error("ParseAccountCredentials succeeded with the wrong passphrase")
+	}
+	if _, err := letsencryptUtilsTypes.ParseAccountCredentials(data, nil); err == nil {
+		t.Error("ParseAccountCredentials succeeded without a passphrase")
+	}
+}
+
+func TestEncryptedAccountCredentialsScryptBounds(t *testing.T) {
+	data, err := letsencryptUtilsTypes.EncryptAccountCredentials(
+		&letsencryptUtilsTypes.AccountCredentials{Uri: "https://acme.example/acct/1"},
+		[]byte("passphrase"),
+	)
+	if err != nil {
+		t.Fatalf("EncryptAccountCredentials: %v", err)
+	}
+
+	testCases := map[string]func(envelope *letsencryptUtilsTypes.EncryptedEnvelope){
+		"n too large": func(envelope *letsencryptUtilsTypes.EncryptedEnvelope) { envelope.N = 1 << 21 },
+		"n too small": func(envelope *letsencryptUtilsTypes.EncryptedEnvelope) { envelope.N = 1 },
+		"r too large": func(envelope *letsencryptUtilsTypes.EncryptedEnvelope) { envelope.R = 17 },
+		"r zero":      func(envelope *letsencryptUtilsTypes.EncryptedEnvelope) { envelope.R = 0 },
+		"p too large": func(envelope *letsencryptUtilsTypes.EncryptedEnvelope) { envelope.P = 5 },
+		"p zero":      func(envelope *letsencryptUtilsTypes.EncryptedEnvelope) { envelope.P = 0 },
+	}
+
+	for name, modify := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var envelope letsencryptUtilsTypes.EncryptedEnvelope
+			if err := json.Unmarshal(data, &envelope); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			modify(&envelope)
+			envelopeData, err := json.Marshal(envelope)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+
+			_, err = letsencryptUtilsTypes.ParseAccountCredentials(envelopeData, []byte("passphrase"))
+			var inputError *motmedelErrors.InputError
+			if !errors.As(err, &inputError) || inputError.Message != "The scrypt parameters are out of bounds." {
+				t.Errorf("ParseAccountCredentials error = %v, want the scrypt parameters rejected", err)
+			}
+		})
+	}
+}
+
+func TestWriteAccountCredentialsFileRotatesBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "account_credentials.json")
+
+	const backupCount = 2
+	for index := 1; index <= 4; index++ {
+		data := []byte(strconv.Itoa(index))
+		if err := letsencryptUtilsTypes.WriteAccountCredentialsFile(path, data, backupCount); err != nil {
+			t.Fatalf("WriteAccountCredentialsFile %d: %v", index, err)
+		}
+	}
+
+	for suffix, want := range map[string]string{"": "4", ".1": "3", ".2": "2"} {
+		data, err := os.ReadFile(path + suffix)
+		if err != nil {
+			t.Fatalf("ReadFile %q: %v", suffix, err)
+		}
+		if string(data) != want {
+			t.Errorf("%q holds %q, want %q", suffix, data, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stat of the backup beyond the count: %v, want a not-exist error", err)
+	}
+}
+
+func TestLoadAccountCredentialsFileLeavesNoLockFile(t *testing.T) {
+	directory := t.TempDir()
+	path := filepath.Join(directory, "account_credentials.json")
+
+	key, err := letsencryptUtilsKey.Generate(nil)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	keyPemData, err := letsencryptUtilsKey.MarshalPem(key)
+	if err != nil {
+		t.Fatalf("MarshalPem: %v", err)
+	}
+	data, err := (&letsencryptUtilsTypes.AccountCredentialsFile{
+		Accounts: []*letsencryptUtilsTypes.AccountCredentials{
+			{Uri: "https://acme.example/acct/1", Key: string(keyPemData)},
+		},
+	}).Encode(nil)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	// Reading requires no write access to the directory.
+	if err := os.Chmod(directory, 0500); err != nil {
+		t.Fatalf("Chmod: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chmod(directory, 0700) })
+
+	if _, err := letsencryptUtilsTypes.LoadAccountCredentialsFile(path, nil); err != nil {
+		t.Fatalf("LoadAccountCredentialsFile: %v", err)
+	}
+	if _, err := os.Stat(path + ".lock"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stat of the lock file: %v, want a not-exist error", err)
+	}
+}